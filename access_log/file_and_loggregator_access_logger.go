@@ -13,8 +13,7 @@ import (
 
 	"code.cloudfoundry.org/gorouter/access_log/schema"
 	"code.cloudfoundry.org/gorouter/config"
-
-	"os"
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
 )
 
 //go:generate counterfeiter -o fakes/fake_access_logger.go . AccessLogger
@@ -31,31 +30,57 @@ func (x *NullAccessLogger) Run()                       {}
 func (x *NullAccessLogger) Stop()                      {}
 func (x *NullAccessLogger) Log(schema.AccessLogRecord) {}
 
+// FileAndLoggregatorAccessLogger writes every record to its writers and to
+// Loggregator. Each of them is a sink with its own buffer and goroutine.
 type FileAndLoggregatorAccessLogger struct {
 	dropsondeSourceInstance string
-	channel                 chan schema.AccessLogRecord
 	stopCh                  chan struct{}
-	writer                  io.Writer
-	writerCount             int
+	writers                 []io.Writer
+	sinks                   []*sink
+	formatter               schema.Formatter
+	redactor                *Redactor
+	file                    *RotatingFile
 	logger                  lager.Logger
+
+	// Reporter captures the records dropped or delayed by full sinks
+	Reporter reporter.AccessLogReporter
 }
 
-func CreateRunningAccessLogger(logger lager.Logger, config *config.Config) (AccessLogger, error) {
+func CreateRunningAccessLogger(logger lager.Logger, config *config.Config, reporter reporter.AccessLogReporter) (AccessLogger, error) {
 
 	if config.AccessLog.File == "" && !config.Logging.LoggregatorEnabled {
 		return &NullAccessLogger{}, nil
 	}
 
-	var err error
-	var file *os.File
-	var writers []io.Writer
+	formatter, err := schema.NewFormatter(config.AccessLog)
+	if err != nil {
+		logger.Error("Error creating access log formatter", err)
+		return nil, err
+	}
+
+	redactor, err := NewRedactor(config.AccessLog.Redaction)
+	if err != nil {
+		logger.Error("Error creating access log redactor", err)
+		return nil, err
+	}
+
+	var dropsondeSourceInstance string
+	if config.Logging.LoggregatorEnabled {
+		dropsondeSourceInstance = strconv.FormatUint(uint64(config.Index), 10)
+	}
+
+	accessLogger := newFileAndLoggregatorAccessLogger(logger, formatter, dropsondeSourceInstance, config.AccessLog.Sinks.Loggregator)
+	accessLogger.Reporter = reporter
+	accessLogger.redactor = redactor
+
 	if config.AccessLog.File != "" {
-		file, err = os.OpenFile(config.AccessLog.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+		file, err := NewRotatingFile(config.AccessLog.File, config.AccessLog.Rotation, logger)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Error creating accesslog file, %s", config.AccessLog.File), err)
 			return nil, err
 		}
-		writers = append(writers, file)
+		accessLogger.file = file
+		accessLogger.AddWriter("file", config.AccessLog.Sinks.File, file)
 	}
 
 	if config.AccessLog.EnableStreaming {
@@ -64,54 +89,75 @@ func CreateRunningAccessLogger(logger lager.Logger, config *config.Config) (Acce
 			logger.Error("Error creating syslog writer", err)
 			return nil, err
 		}
-		writers = append(writers, syslogWriter)
+		accessLogger.AddWriter("syslog", config.AccessLog.Sinks.Syslog, syslogWriter)
 	}
 
-	var dropsondeSourceInstance string
-	if config.Logging.LoggregatorEnabled {
-		dropsondeSourceInstance = strconv.FormatUint(uint64(config.Index), 10)
+	if accessLogger.file != nil {
+		go accessLogger.file.Run()
 	}
-
-	accessLogger := NewFileAndLoggregatorAccessLogger(logger, dropsondeSourceInstance, writers...)
 	go accessLogger.Run()
 	return accessLogger, nil
 }
 
-func NewFileAndLoggregatorAccessLogger(logger lager.Logger, dropsondeSourceInstance string, ws ...io.Writer) *FileAndLoggregatorAccessLogger {
+// NewFileAndLoggregatorAccessLogger writes records with formatter, or in the
+// text format when it is nil. The sinks have the default buffer size and
+// block when it is full.
+func NewFileAndLoggregatorAccessLogger(logger lager.Logger, formatter schema.Formatter, dropsondeSourceInstance string, ws ...io.Writer) *FileAndLoggregatorAccessLogger {
+	a := newFileAndLoggregatorAccessLogger(logger, formatter, dropsondeSourceInstance, defaultSink)
+	for _, w := range ws {
+		if w != nil {
+			a.AddWriter("writer", defaultSink, w)
+		}
+	}
+	return a
+}
+
+func newFileAndLoggregatorAccessLogger(logger lager.Logger, formatter schema.Formatter, dropsondeSourceInstance string, loggregatorSink config.AccessLogSink) *FileAndLoggregatorAccessLogger {
 	a := &FileAndLoggregatorAccessLogger{
 		dropsondeSourceInstance: dropsondeSourceInstance,
-		channel:                 make(chan schema.AccessLogRecord, 1024),
 		stopCh:                  make(chan struct{}),
+		formatter:               formatter,
 		logger:                  logger,
 	}
-	configureWriters(a, ws)
+	if dropsondeSourceInstance != "" {
+		s := newSink("loggregator", loggregatorSink, func(e accessLogEntry) {
+			logs.SendAppLog(e.appID, string(e.line), "RTR", dropsondeSourceInstance)
+		})
+		s.appsOnly = true
+		a.sinks = append(a.sinks, s)
+	}
 	return a
 }
 
-func (x *FileAndLoggregatorAccessLogger) Run() {
-	for {
-		select {
-		case record := <-x.channel:
-			if x.writer != nil {
-				_, err := record.WriteTo(x.writer)
-				if err != nil {
-					x.logger.Error("Error when emiting access log to writers ", err)
-				}
-			}
-			if x.dropsondeSourceInstance != "" && record.ApplicationID() != "" {
-				logs.SendAppLog(record.ApplicationID(), record.LogMessage(), "RTR", x.dropsondeSourceInstance)
-			}
-		case <-x.stopCh:
-			return
+// AddWriter adds a sink named name that writes to w. It must be called
+// before Run.
+func (x *FileAndLoggregatorAccessLogger) AddWriter(name string, c config.AccessLogSink, w io.Writer) {
+	x.writers = append(x.writers, w)
+	x.sinks = append(x.sinks, newSink(name, c, func(e accessLogEntry) {
+		_, err := w.Write(e.line)
+		if err != nil {
+			x.logger.Error("Error when emiting access log to writers ", err)
 		}
+	}))
+}
+
+func (x *FileAndLoggregatorAccessLogger) Run() {
+	for _, s := range x.sinks {
+		go s.run(x.stopCh)
 	}
+	<-x.stopCh
 }
 
+// FileWriter returns the first writer, or nil when there is none
 func (x *FileAndLoggregatorAccessLogger) FileWriter() io.Writer {
-	return x.writer
+	if len(x.writers) == 0 {
+		return nil
+	}
+	return x.writers[0]
 }
+
 func (x *FileAndLoggregatorAccessLogger) WriterCount() int {
-	return x.writerCount
+	return len(x.writers)
 }
 
 func (x *FileAndLoggregatorAccessLogger) DropsondeSourceInstance() string {
@@ -120,10 +166,29 @@ func (x *FileAndLoggregatorAccessLogger) DropsondeSourceInstance() string {
 
 func (x *FileAndLoggregatorAccessLogger) Stop() {
 	close(x.stopCh)
+	if x.file != nil {
+		x.file.Stop()
+	}
 }
 
+// Log redacts and formats the record and hands it to every sink. It only
+// blocks when a sink with the block overflow policy is full.
 func (x *FileAndLoggregatorAccessLogger) Log(r schema.AccessLogRecord) {
-	x.channel <- r
+	if len(x.sinks) == 0 {
+		return
+	}
+	x.redactor.Redact(&r)
+	if x.formatter != nil {
+		r.Formatter = x.formatter
+	}
+
+	e := accessLogEntry{appID: r.ApplicationID(), line: r.Bytes()}
+	for _, s := range x.sinks {
+		if s.appsOnly && e.appID == "" {
+			continue
+		}
+		s.enqueue(e, x.Reporter)
+	}
 }
 
 var ipAddressRegex, _ = regexp.Compile(`^(([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9]{2}|2[0-4][0-9]|25[0-5])(:[0-9]{1,5}){1}$`)
@@ -132,16 +197,3 @@ var hostnameRegex, _ = regexp.Compile(`^(([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9\-]*[
 func isValidUrl(url string) bool {
 	return ipAddressRegex.MatchString(url) || hostnameRegex.MatchString(url)
 }
-
-func configureWriters(a *FileAndLoggregatorAccessLogger, ws []io.Writer) {
-	var multiws []io.Writer
-	for _, w := range ws {
-		if w != nil {
-			multiws = append(multiws, w)
-			a.writerCount++
-		}
-	}
-	if len(multiws) > 0 {
-		a.writer = io.MultiWriter(multiws...)
-	}
-}
@@ -40,7 +40,7 @@ var _ = Describe("AccessLog", func() {
 
 				fakeLogSender := fake.NewFakeLogSender()
 				logs.Initialize(fakeLogSender)
-				accessLogger := NewFileAndLoggregatorAccessLogger(logger, "42")
+				accessLogger := NewFileAndLoggregatorAccessLogger(logger, nil, "42")
 				go accessLogger.Run()
 
 				accessLogger.Log(*CreateAccessLogRecord())
@@ -60,7 +60,7 @@ var _ = Describe("AccessLog", func() {
 				fakeLogSender := fake.NewFakeLogSender()
 				logs.Initialize(fakeLogSender)
 
-				accessLogger := NewFileAndLoggregatorAccessLogger(logger, "43")
+				accessLogger := NewFileAndLoggregatorAccessLogger(logger, nil, "43")
 
 				routeEndpoint := route.NewEndpoint("", "127.0.0.1", 4567, "", "", nil, -1, "", models.ModificationTag{})
 
@@ -84,7 +84,7 @@ var _ = Describe("AccessLog", func() {
 				tempStdout, _ := os.Create(fname)
 				defer tempStdout.Close()
 				os.Stdout = tempStdout
-				accessLogger := NewFileAndLoggregatorAccessLogger(logger, "", fakeAccessFile, os.Stdout)
+				accessLogger := NewFileAndLoggregatorAccessLogger(logger, nil, "", fakeAccessFile, os.Stdout)
 
 				go accessLogger.Run()
 				accessLogger.Log(*CreateAccessLogRecord())
@@ -108,6 +108,30 @@ var _ = Describe("AccessLog", func() {
 			})
 		})
 
+		Context("created with a JSON formatter", func() {
+			It("writes JSON lines to the log file and dropsonde", func() {
+				fakeLogSender := fake.NewFakeLogSender()
+				logs.Initialize(fakeLogSender)
+				var fakeAccessFile = new(test_util.FakeFile)
+				accessLogger := NewFileAndLoggregatorAccessLogger(logger, schema.JSONFormatter{}, "42", fakeAccessFile)
+
+				go accessLogger.Run()
+				accessLogger.Log(*CreateAccessLogRecord())
+
+				var payload []byte
+				Eventually(func() int {
+					n, _ := fakeAccessFile.Read(&payload)
+					return n
+				}).ShouldNot(Equal(0))
+				Expect(string(payload)).To(MatchRegexp(`^\{"timestamp":".*"host":"foo.bar".*\}\n$`))
+
+				Eventually(fakeLogSender.GetLogs).Should(HaveLen(1))
+				Expect(fakeLogSender.GetLogs()[0].Message).To(Equal(string(payload)))
+
+				accessLogger.Stop()
+			})
+		})
+
 		Measure("Log write speed", func(b Benchmarker) {
 			w := nullWriter{}
 
@@ -134,14 +158,14 @@ var _ = Describe("AccessLog", func() {
 		})
 
 		It("creates null access loger if no access log and loggregator is disabled", func() {
-			Expect(CreateRunningAccessLogger(logger, cfg)).To(BeAssignableToTypeOf(&NullAccessLogger{}))
+			Expect(CreateRunningAccessLogger(logger, cfg, nil)).To(BeAssignableToTypeOf(&NullAccessLogger{}))
 		})
 
 		It("creates an access log when loggegrator is enabled", func() {
 			cfg.Logging.LoggregatorEnabled = true
 			cfg.AccessLog.File = ""
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).To(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).WriterCount()).To(Equal(0))
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).To(Equal("0"))
@@ -150,7 +174,7 @@ var _ = Describe("AccessLog", func() {
 		It("creates an access log if an access log is specified", func() {
 			cfg.AccessLog.File = "/dev/null"
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).ToNot(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).To(BeEmpty())
 		})
@@ -159,7 +183,7 @@ var _ = Describe("AccessLog", func() {
 			cfg.Logging.LoggregatorEnabled = true
 			cfg.AccessLog.File = "/dev/null"
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).ToNot(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).WriterCount()).To(Equal(1))
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).ToNot(BeEmpty())
@@ -170,7 +194,7 @@ var _ = Describe("AccessLog", func() {
 			cfg.AccessLog.File = "/dev/null"
 			cfg.AccessLog.EnableStreaming = true
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).ToNot(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).WriterCount()).To(Equal(2))
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).ToNot(BeEmpty())
@@ -181,7 +205,7 @@ var _ = Describe("AccessLog", func() {
 			cfg.AccessLog.File = "/dev/null"
 			cfg.AccessLog.EnableStreaming = false
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).ToNot(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).WriterCount()).To(Equal(1))
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).ToNot(BeEmpty())
@@ -192,16 +216,25 @@ var _ = Describe("AccessLog", func() {
 			cfg.AccessLog.File = ""
 			cfg.AccessLog.EnableStreaming = true
 
-			accessLogger, _ := CreateRunningAccessLogger(logger, cfg)
+			accessLogger, _ := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).FileWriter()).ToNot(BeNil())
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).WriterCount()).To(Equal(1))
 			Expect(accessLogger.(*FileAndLoggregatorAccessLogger).DropsondeSourceInstance()).ToNot(BeEmpty())
 		})
 
+		It("reports an error if the access log format is invalid", func() {
+			cfg.AccessLog.File = "/dev/null"
+			cfg.AccessLog.Format = "xml"
+
+			a, err := CreateRunningAccessLogger(logger, cfg, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(a).To(BeNil())
+		})
+
 		It("reports an error if the access log location is invalid", func() {
 			cfg.AccessLog.File = "/this\\is/illegal"
 
-			a, err := CreateRunningAccessLogger(logger, cfg)
+			a, err := CreateRunningAccessLogger(logger, cfg, nil)
 			Expect(err).To(HaveOccurred())
 			Expect(a).To(BeNil())
 		})
@@ -0,0 +1,106 @@
+package access_log
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+type filterRule struct {
+	matcher     route.Matcher
+	statusClass int
+	minLatency  time.Duration
+	userAgent   string
+	appID       string
+	action      string
+	sampleRate  float64
+}
+
+// Filter decides which access log records are logged, using the action of
+// the first rule matching the record. Records matching no rule, server
+// errors and requests slower than the keep threshold are always logged.
+type Filter struct {
+	rules          []filterRule
+	keepSlowerThan time.Duration
+}
+
+func NewFilter(c config.AccessLog) (*Filter, error) {
+	f := &Filter{
+		keepSlowerThan: c.KeepSlowerThan,
+	}
+
+	for i, rc := range c.Rules {
+		r := filterRule{
+			matcher:    route.NewMatcher(rc.Host, rc.PathPrefix),
+			minLatency: rc.MinLatency,
+			userAgent:  rc.UserAgent,
+			appID:      rc.AppID,
+			action:     rc.Action,
+			sampleRate: rc.SampleRate,
+		}
+
+		if rc.StatusClass != "" {
+			class := strings.ToLower(rc.StatusClass)
+			if len(class) != 3 || class[0] < '1' || class[0] > '5' || class[1:] != "xx" {
+				return nil, fmt.Errorf("access log rule %d: invalid status class %s", i, rc.StatusClass)
+			}
+			r.statusClass = int(class[0] - '0')
+		}
+
+		f.rules = append(f.rules, r)
+	}
+
+	return f, nil
+}
+
+// Keep reports whether the record should be logged. It is called once the
+// response has finished.
+func (f *Filter) Keep(r *schema.AccessLogRecord) bool {
+	if f == nil || len(f.rules) == 0 {
+		return true
+	}
+
+	latency := r.FinishedAt.Sub(r.StartedAt)
+	if r.StatusCode >= 500 || (f.keepSlowerThan > 0 && latency >= f.keepSlowerThan) {
+		return true
+	}
+
+	for _, rule := range f.rules {
+		if !rule.match(r, latency) {
+			continue
+		}
+		switch rule.action {
+		case config.ACCESS_LOG_RULE_NEVER:
+			return false
+		case config.ACCESS_LOG_RULE_SAMPLE:
+			return rand.Float64() < rule.sampleRate
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+func (rule *filterRule) match(r *schema.AccessLogRecord, latency time.Duration) bool {
+	if !rule.matcher.Match(r.Request.Host, r.Request.URL.Path) {
+		return false
+	}
+	if rule.statusClass != 0 && r.StatusCode/100 != rule.statusClass {
+		return false
+	}
+	if latency < rule.minLatency {
+		return false
+	}
+	if rule.userAgent != "" && !strings.Contains(r.Request.UserAgent(), rule.userAgent) {
+		return false
+	}
+	if rule.appID != "" && r.ApplicationID() != rule.appID {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package access_log_test
+
+import (
+	"time"
+
+	. "code.cloudfoundry.org/gorouter/access_log"
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	var (
+		c      config.AccessLog
+		filter *Filter
+		record *schema.AccessLogRecord
+	)
+
+	BeforeEach(func() {
+		c = config.AccessLog{KeepSlowerThan: time.Second}
+		record = CreateAccessLogRecord()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		filter, err = NewFilter(c)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("without rules", func() {
+		It("keeps every record", func() {
+			Expect(filter.Keep(record)).To(BeTrue())
+		})
+	})
+
+	Context("when a nil filter is used", func() {
+		It("keeps every record", func() {
+			var nilFilter *Filter
+			Expect(nilFilter.Keep(record)).To(BeTrue())
+		})
+	})
+
+	Context("with a never rule", func() {
+		BeforeEach(func() {
+			c.Rules = []config.AccessLogRule{{Action: config.ACCESS_LOG_RULE_NEVER}}
+		})
+
+		Context("matching on host and path prefix", func() {
+			BeforeEach(func() {
+				c.Rules[0].Host = "*.bar"
+				c.Rules[0].PathPrefix = "/quz"
+			})
+
+			It("drops matching records", func() {
+				Expect(filter.Keep(record)).To(BeFalse())
+			})
+
+			It("keeps records on other paths", func() {
+				record.Request.URL.Path = "/quzz"
+				Expect(filter.Keep(record)).To(BeTrue())
+			})
+		})
+
+		Context("matching on status class", func() {
+			BeforeEach(func() {
+				c.Rules[0].StatusClass = "2xx"
+			})
+
+			It("drops records in the class", func() {
+				Expect(filter.Keep(record)).To(BeFalse())
+			})
+
+			It("keeps records in other classes", func() {
+				record.StatusCode = 404
+				Expect(filter.Keep(record)).To(BeTrue())
+			})
+		})
+
+		Context("matching on latency", func() {
+			BeforeEach(func() {
+				c.Rules[0].MinLatency = 100 * time.Millisecond
+			})
+
+			It("drops records that took at least as long", func() {
+				Expect(filter.Keep(record)).To(BeFalse())
+			})
+
+			It("keeps faster records", func() {
+				record.FinishedAt = record.StartedAt.Add(50 * time.Millisecond)
+				Expect(filter.Keep(record)).To(BeTrue())
+			})
+		})
+
+		Context("matching on user agent and app ID", func() {
+			BeforeEach(func() {
+				c.Rules[0].UserAgent = "agent"
+				c.Rules[0].AppID = "my_awesome_id"
+			})
+
+			It("drops records matching both", func() {
+				Expect(filter.Keep(record)).To(BeFalse())
+			})
+
+			It("keeps records of other apps", func() {
+				record.RouteEndpoint = nil
+				Expect(filter.Keep(record)).To(BeTrue())
+			})
+
+			It("keeps records from other user agents", func() {
+				record.Request.Header.Set("User-Agent", "curl/7.0")
+				Expect(filter.Keep(record)).To(BeTrue())
+			})
+		})
+
+		It("always keeps server errors", func() {
+			record.StatusCode = 502
+			Expect(filter.Keep(record)).To(BeTrue())
+		})
+
+		It("always keeps slow requests", func() {
+			record.FinishedAt = record.StartedAt.Add(time.Second)
+			Expect(filter.Keep(record)).To(BeTrue())
+		})
+	})
+
+	Context("with several rules", func() {
+		BeforeEach(func() {
+			c.Rules = []config.AccessLogRule{
+				{Host: "foo.bar", PathPrefix: "/quz", Action: config.ACCESS_LOG_RULE_ALWAYS},
+				{Host: "foo.bar", Action: config.ACCESS_LOG_RULE_NEVER},
+			}
+		})
+
+		It("uses the first matching rule", func() {
+			Expect(filter.Keep(record)).To(BeTrue())
+
+			record.Request.URL.Path = "/other"
+			Expect(filter.Keep(record)).To(BeFalse())
+		})
+	})
+
+	Context("with a sample rule", func() {
+		BeforeEach(func() {
+			c.Rules = []config.AccessLogRule{
+				{Host: "foo.bar", Action: config.ACCESS_LOG_RULE_SAMPLE, SampleRate: 0.25},
+			}
+		})
+
+		It("keeps about the sampled fraction of records", func() {
+			kept := 0
+			for i := 0; i < 10000; i++ {
+				if filter.Keep(record) {
+					kept++
+				}
+			}
+			Expect(kept).To(BeNumerically("~", 2500, 300))
+		})
+
+		Context("at a rate of 0", func() {
+			BeforeEach(func() {
+				c.Rules[0].SampleRate = 0
+			})
+
+			It("drops every record", func() {
+				for i := 0; i < 100; i++ {
+					Expect(filter.Keep(record)).To(BeFalse())
+				}
+			})
+		})
+	})
+
+	Context("with an invalid status class", func() {
+		It("returns an error", func() {
+			_, err := NewFilter(config.AccessLog{Rules: []config.AccessLogRule{{StatusClass: "200", Action: config.ACCESS_LOG_RULE_NEVER}}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
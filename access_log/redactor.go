@@ -0,0 +1,143 @@
+package access_log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+)
+
+const redactedValue = "REDACTED"
+
+// Redactor replaces sensitive values in access log records before they are
+// formatted, so that they reach none of the outputs. Values are masked, or
+// hashed so that requests carrying the same value can still be correlated.
+type Redactor struct {
+	hash        bool
+	queryParams map[string]bool
+	headers     []string
+	uriPatterns []*regexp.Regexp
+}
+
+func NewRedactor(c config.AccessLogRedaction) (*Redactor, error) {
+	x := &Redactor{
+		hash:        c.Mode == config.ACCESS_LOG_REDACT_HASH,
+		queryParams: make(map[string]bool),
+	}
+
+	for _, name := range c.QueryParams {
+		x.queryParams[strings.ToLower(name)] = true
+	}
+	for _, name := range c.Headers {
+		x.headers = append(x.headers, http.CanonicalHeaderKey(name))
+	}
+	for _, pattern := range c.URIPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("access log redaction uri pattern %s: %s", pattern, err)
+		}
+		x.uriPatterns = append(x.uriPatterns, re)
+	}
+
+	return x, nil
+}
+
+// Redact replaces the sensitive values in r. The request is copied before
+// its headers are replaced, so the request being served is left alone.
+func (x *Redactor) Redact(r *schema.AccessLogRecord) {
+	if x == nil || r.Request == nil {
+		return
+	}
+
+	if len(x.queryParams) > 0 || len(x.uriPatterns) > 0 {
+		uri := r.Request.URL.RequestURI()
+		if redacted := x.redactURI(uri); redacted != uri {
+			r.RedactedURI = redacted
+		}
+	}
+
+	if h, ok := x.redactHeader(r.Request.Header); ok {
+		request := *r.Request
+		request.Header = h
+		r.Request = &request
+	}
+	if h, ok := x.redactHeader(r.ResponseHeader); ok {
+		r.ResponseHeader = h
+	}
+}
+
+func (x *Redactor) redactURI(uri string) string {
+	if i := strings.IndexByte(uri, '?'); i >= 0 && len(x.queryParams) > 0 {
+		uri = uri[:i+1] + x.redactQuery(uri[i+1:])
+	}
+	for _, re := range x.uriPatterns {
+		uri = re.ReplaceAllStringFunc(uri, x.replace)
+	}
+	return uri
+}
+
+// redactQuery replaces the values of the named parameters and keeps the
+// rest of the query as it was sent.
+func (x *Redactor) redactQuery(query string) string {
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		eq := strings.IndexByte(param, '=')
+		if eq < 0 || eq == len(param)-1 {
+			continue
+		}
+		name, err := url.QueryUnescape(param[:eq])
+		if err != nil {
+			name = param[:eq]
+		}
+		if x.queryParams[strings.ToLower(name)] {
+			params[i] = param[:eq+1] + x.replace(param[eq+1:])
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// redactHeader returns a copy of h with the named headers replaced, or h and
+// false when it has none of them.
+func (x *Redactor) redactHeader(h http.Header) (http.Header, bool) {
+	found := false
+	for _, name := range x.headers {
+		if _, ok := h[name]; ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return h, false
+	}
+
+	redacted := make(http.Header, len(h))
+	for name, values := range h {
+		redacted[name] = values
+	}
+	for _, name := range x.headers {
+		values, ok := h[name]
+		if !ok {
+			continue
+		}
+		replaced := make([]string, len(values))
+		for i, v := range values {
+			replaced[i] = x.replace(v)
+		}
+		redacted[name] = replaced
+	}
+	return redacted, true
+}
+
+func (x *Redactor) replace(v string) string {
+	if !x.hash {
+		return redactedValue
+	}
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,165 @@
+package access_log_test
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	. "code.cloudfoundry.org/gorouter/access_log"
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry/dropsonde/log_sender/fake"
+	"github.com/cloudfoundry/dropsonde/logs"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redactor", func() {
+	var (
+		c        config.AccessLogRedaction
+		redactor *Redactor
+		record   *schema.AccessLogRecord
+	)
+
+	BeforeEach(func() {
+		c = config.AccessLogRedaction{
+			Mode:        config.ACCESS_LOG_REDACT_MASK,
+			QueryParams: []string{"access_token"},
+			Headers:     []string{"authorization"},
+			URIPatterns: []string{`[0-9]{4,}`},
+		}
+
+		record = CreateAccessLogRecord()
+		var err error
+		record.Request.URL, err = url.Parse("http://foo.bar/users/1234/items?access_token=s%3Dcret&page=2&Access_Token=again&access_token")
+		Expect(err).ToNot(HaveOccurred())
+		record.Request.Header.Set("Authorization", "Bearer secret")
+		record.ExtraHeadersToLog = &[]string{"Authorization"}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		redactor, err = NewRedactor(c)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("masks query parameters and uri patterns", func() {
+		redactor.Redact(record)
+		Expect(record.RedactedURI).To(Equal("/users/REDACTED/items?access_token=REDACTED&page=2&Access_Token=REDACTED&access_token"))
+	})
+
+	It("masks headers without changing the request being served", func() {
+		original := record.Request
+
+		redactor.Redact(record)
+		Expect(record.Request.Header.Get("Authorization")).To(Equal("REDACTED"))
+		Expect(record.Request.Header.Get("User-Agent")).To(Equal("user-agent"))
+		Expect(original.Header.Get("Authorization")).To(Equal("Bearer secret"))
+	})
+
+	It("masks response headers", func() {
+		record.ResponseHeader = map[string][]string{"Authorization": {"Basic secret"}}
+
+		redactor.Redact(record)
+		Expect(record.ResponseHeader.Get("Authorization")).To(Equal("REDACTED"))
+	})
+
+	It("writes the redacted values in every format", func() {
+		redactor.Redact(record)
+
+		for _, formatter := range []schema.Formatter{schema.TextFormatter{}, schema.JSONFormatter{}} {
+			line := string(formatter.Format(record))
+			Expect(line).To(ContainSubstring("/users/REDACTED/items?access_token=REDACTED"))
+			Expect(line).ToNot(ContainSubstring("secret"))
+			Expect(line).ToNot(ContainSubstring("1234"))
+		}
+	})
+
+	It("leaves records without sensitive values alone", func() {
+		record = CreateAccessLogRecord()
+		request := record.Request
+
+		redactor.Redact(record)
+		Expect(record.RedactedURI).To(BeEmpty())
+		Expect(record.Request).To(BeIdenticalTo(request))
+	})
+
+	Context("when hashing", func() {
+		BeforeEach(func() {
+			c.Mode = config.ACCESS_LOG_REDACT_HASH
+		})
+
+		It("replaces values with the same hash for the same value", func() {
+			redactor.Redact(record)
+			Expect(record.RedactedURI).To(MatchRegexp(`^/users/sha256:[0-9a-f]{16}/items\?access_token=sha256:[0-9a-f]{16}&page=2&`))
+			Expect(record.Request.Header.Get("Authorization")).To(MatchRegexp(`^sha256:[0-9a-f]{16}$`))
+
+			other := CreateAccessLogRecord()
+			other.Request.Header.Set("Authorization", "Bearer secret")
+			redactor.Redact(other)
+			Expect(other.Request.Header.Get("Authorization")).To(Equal(record.Request.Header.Get("Authorization")))
+		})
+	})
+
+	Context("with an invalid uri pattern", func() {
+		It("returns an error", func() {
+			_, err := NewRedactor(config.AccessLogRedaction{URIPatterns: []string{"("}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("when a nil redactor is used", func() {
+		It("leaves the record alone", func() {
+			var nilRedactor *Redactor
+			nilRedactor.Redact(record)
+			Expect(record.RedactedURI).To(BeEmpty())
+		})
+	})
+
+	Describe("the access logger", func() {
+		var (
+			dir           string
+			cfg           *config.Config
+			fakeLogSender *fake.FakeLogSender
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "redactor")
+			Expect(err).ToNot(HaveOccurred())
+
+			fakeLogSender = fake.NewFakeLogSender()
+			logs.Initialize(fakeLogSender)
+
+			cfg = config.DefaultConfig()
+			cfg.AccessLog.File = filepath.Join(dir, "access.log")
+			cfg.AccessLog.Redaction = c
+			cfg.Logging.LoggregatorEnabled = true
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("redacts the records written to the file and Loggregator", func() {
+			accessLogger, err := CreateRunningAccessLogger(lagertest.NewTestLogger("test"), cfg, nil)
+			Expect(err).ToNot(HaveOccurred())
+			defer accessLogger.Stop()
+
+			accessLogger.Log(*record)
+
+			Eventually(func() string {
+				b, _ := ioutil.ReadFile(cfg.AccessLog.File)
+				return string(b)
+			}).Should(ContainSubstring("access_token=REDACTED"))
+			Expect(ioutil.ReadFile(cfg.AccessLog.File)).ToNot(ContainSubstring("secret"))
+
+			Eventually(fakeLogSender.GetLogs).Should(HaveLen(1))
+			Expect(fakeLogSender.GetLogs()[0].Message).To(ContainSubstring("access_token=REDACTED"))
+			Expect(fakeLogSender.GetLogs()[0].Message).ToNot(ContainSubstring("secret"))
+		})
+	})
+})
@@ -0,0 +1,283 @@
+package access_log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager"
+)
+
+const backupTimeFormat = "20060102T150405.000000000"
+
+// RotatingFile is an access log file that is rotated when it grows past a
+// size or gets older than an interval, and reopened on SIGUSR2 for external
+// rotation. Writes are serialized with rotation, so every line ends up whole
+// in exactly one file.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	compress   bool
+	logger     lager.Logger
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// cleanupMu serializes compressing and removing backups, which happens
+	// outside of mu so that writes are not held up.
+	cleanupMu sync.Mutex
+
+	stopCh chan struct{}
+}
+
+func NewRotatingFile(path string, c config.AccessLogRotation, logger lager.Logger) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(c.MaxSizeMB) * 1024 * 1024,
+		interval:   c.Interval,
+		maxBackups: c.MaxBackups,
+		compress:   c.Compress,
+		logger:     logger,
+		stopCh:     make(chan struct{}),
+	}
+
+	file, size, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	f.file = file
+	f.size = size
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		err := f.rotate()
+		if err != nil {
+			f.logger.Error("access-log-rotation-failed", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the file to a timestamped backup and starts a new one, unless
+// the file is empty.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size == 0 {
+		return nil
+	}
+	return f.rotate()
+}
+
+// Reopen opens the path of the file again, for when it was moved by an
+// external rotation. Lines keep being written to the old file when the path
+// cannot be opened.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, size, err := f.open()
+	if err != nil {
+		return err
+	}
+	f.swap(file, size)
+	return nil
+}
+
+// Run rotates the file every interval and reopens it on SIGUSR2 until it is
+// stopped.
+func (f *RotatingFile) Run() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	defer signal.Stop(signals)
+
+	var tick <-chan time.Time
+	if f.interval > 0 {
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			err := f.Rotate()
+			if err != nil {
+				f.logger.Error("access-log-rotation-failed", err)
+			}
+		case <-signals:
+			f.logger.Info("access-log-reopening", lager.Data{"file": f.path})
+			err := f.Reopen()
+			if err != nil {
+				f.logger.Error("access-log-reopen-failed", err)
+			}
+		case <-f.stopCh:
+			return
+		}
+	}
+}
+
+func (f *RotatingFile) Stop() {
+	close(f.stopCh)
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+func (f *RotatingFile) open() (*os.File, int64, error) {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// swap replaces the file with a newly opened one and closes the old one. It
+// must be called with mu held.
+func (f *RotatingFile) swap(file *os.File, size int64) {
+	old := f.file
+	f.file = file
+	f.size = size
+
+	err := old.Close()
+	if err != nil {
+		f.logger.Error("access-log-close-failed", err)
+	}
+}
+
+// rotate must be called with mu held. The current file is only closed once
+// the new one is open, so that lines keep being written when it cannot be.
+func (f *RotatingFile) rotate() error {
+	backup := f.path + "." + time.Now().UTC().Format(backupTimeFormat)
+	renameErr := os.Rename(f.path, backup)
+
+	// The file is reopened even if it could not be moved.
+	file, size, err := f.open()
+	if err != nil {
+		if renameErr == nil {
+			// Move the file back rather than writing on to a backup that
+			// would be compressed and removed.
+			if err := os.Rename(backup, f.path); err != nil {
+				f.logger.Error("access-log-restoring-file-failed", err, lager.Data{"file": backup})
+			}
+		}
+		return err
+	}
+	f.swap(file, size)
+	if renameErr != nil {
+		return renameErr
+	}
+
+	go f.cleanup(backup)
+	return nil
+}
+
+func (f *RotatingFile) cleanup(backup string) {
+	f.cleanupMu.Lock()
+	defer f.cleanupMu.Unlock()
+
+	if f.compress {
+		err := compressFile(backup)
+		if err != nil {
+			f.logger.Error("access-log-compression-failed", err, lager.Data{"file": backup})
+		}
+	}
+
+	if f.maxBackups <= 0 {
+		return
+	}
+
+	backups, err := f.backups()
+	if err != nil {
+		f.logger.Error("access-log-listing-backups-failed", err)
+		return
+	}
+	for len(backups) > f.maxBackups {
+		err = os.Remove(backups[0])
+		if err != nil {
+			f.logger.Error("access-log-removing-backup-failed", err, lager.Data{"file": backups[0]})
+		}
+		backups = backups[1:]
+	}
+}
+
+// backups returns the backups of the file from oldest to newest.
+func (f *RotatingFile) backups() ([]string, error) {
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, f.path+"."), ".gz")
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	w := gzip.NewWriter(dst)
+	_, err = io.Copy(w, src)
+	if err == nil {
+		err = w.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = os.Rename(tmp, path+".gz")
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
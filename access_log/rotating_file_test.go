@@ -0,0 +1,224 @@
+package access_log_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	. "code.cloudfoundry.org/gorouter/access_log"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RotatingFile", func() {
+	var (
+		dir      string
+		path     string
+		rotation config.AccessLogRotation
+		file     *RotatingFile
+	)
+
+	backups := func() []string {
+		matches, err := filepath.Glob(path + ".*")
+		Expect(err).ToNot(HaveOccurred())
+		sort.Strings(matches)
+		return matches
+	}
+
+	readAll := func(name string) string {
+		f, err := os.Open(name)
+		Expect(err).ToNot(HaveOccurred())
+		defer f.Close()
+
+		if !strings.HasSuffix(name, ".gz") {
+			b, err := ioutil.ReadAll(f)
+			Expect(err).ToNot(HaveOccurred())
+			return string(b)
+		}
+		r, err := gzip.NewReader(f)
+		Expect(err).ToNot(HaveOccurred())
+		b, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		return string(b)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rotating-file")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "access.log")
+		rotation = config.AccessLogRotation{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		file, err = NewRotatingFile(path, rotation, lagertest.NewTestLogger("test"))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		file.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("appends to the file", func() {
+		Expect(ioutil.WriteFile(path, []byte("old\n"), 0666)).To(Succeed())
+		file.Reopen()
+
+		file.Write([]byte("new\n"))
+		Expect(readAll(path)).To(Equal("old\nnew\n"))
+	})
+
+	Context("with a maximum size", func() {
+		var line []byte
+
+		BeforeEach(func() {
+			rotation.MaxSizeMB = 1
+			line = append(bytes.Repeat([]byte("a"), 512*1024-1), '\n')
+		})
+
+		It("rotates before a line would exceed it", func() {
+			file.Write(line)
+			file.Write(line)
+			Expect(backups()).To(BeEmpty())
+
+			file.Write([]byte("next\n"))
+			Expect(backups()).To(HaveLen(1))
+			Expect(readAll(backups()[0])).To(Equal(string(line) + string(line)))
+			Expect(readAll(path)).To(Equal("next\n"))
+		})
+
+		It("keeps every line whole while writers race", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					for j := 0; j < 5; j++ {
+						file.Write([]byte(fmt.Sprintf("%d-%d %s", i, j, line)))
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			var lines []string
+			for _, name := range append(backups(), path) {
+				lines = append(lines, strings.SplitAfter(readAll(name), "\n")...)
+			}
+			var whole int
+			for _, l := range lines {
+				if l == "" {
+					continue
+				}
+				Expect(l).To(HaveSuffix(string(line)))
+				whole++
+			}
+			Expect(whole).To(Equal(20))
+		})
+
+		Context("with compression and a retention count", func() {
+			BeforeEach(func() {
+				rotation.Compress = true
+				rotation.MaxBackups = 2
+			})
+
+			It("compresses backups and removes the oldest", func() {
+				for i := 0; i < 4; i++ {
+					file.Write(line)
+					file.Write(line)
+					file.Write([]byte(fmt.Sprintf("%d\n", i)))
+				}
+
+				Eventually(backups).Should(HaveLen(2))
+				Eventually(func() []string {
+					names := backups()
+					for i := range names {
+						names[i] = filepath.Ext(names[i])
+					}
+					return names
+				}).Should(Equal([]string{".gz", ".gz"}))
+				Expect(readAll(backups()[1])).ToNot(BeEmpty())
+			})
+		})
+	})
+
+	Context("with an interval", func() {
+		BeforeEach(func() {
+			rotation.Interval = 50 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			go file.Run()
+		})
+
+		AfterEach(func() {
+			file.Stop()
+		})
+
+		It("rotates the file when it is not empty", func() {
+			Consistently(backups, 150*time.Millisecond).Should(BeEmpty())
+
+			file.Write([]byte("line\n"))
+			Eventually(backups).Should(HaveLen(1))
+			Expect(readAll(backups()[0])).To(Equal("line\n"))
+		})
+	})
+
+	Context("when the path cannot be opened again", func() {
+		It("keeps writing to the old file", func() {
+			file.Write([]byte("before\n"))
+			Expect(os.Rename(path, path+".moved")).To(Succeed())
+			Expect(os.Mkdir(path, 0777)).To(Succeed())
+
+			Expect(file.Reopen()).NotTo(Succeed())
+
+			_, err := file.Write([]byte("after\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readAll(path + ".moved")).To(Equal("before\nafter\n"))
+		})
+	})
+
+	Context("when it receives SIGUSR2", func() {
+		JustBeforeEach(func() {
+			go file.Run()
+		})
+
+		AfterEach(func() {
+			file.Stop()
+		})
+
+		It("reopens the file", func() {
+			// The test subscribes to the signal too, so that it does not stop
+			// the process before Run has subscribed to it.
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGUSR2)
+			defer signal.Stop(signals)
+
+			file.Write([]byte("before\n"))
+			Expect(os.Rename(path, path+".moved")).To(Succeed())
+
+			Eventually(func() bool {
+				syscall.Kill(os.Getpid(), syscall.SIGUSR2)
+				_, err := os.Stat(path)
+				return err == nil
+			}).Should(BeTrue())
+
+			file.Write([]byte("after\n"))
+			Expect(readAll(path + ".moved")).To(Equal("before\n"))
+			Expect(readAll(path)).To(Equal("after\n"))
+		})
+	})
+})
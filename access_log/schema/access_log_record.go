@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	router_http "code.cloudfoundry.org/gorouter/common/http"
 	"code.cloudfoundry.org/gorouter/route"
 )
 
@@ -80,6 +81,7 @@ func (b *recordBuffer) WriteDashOrStringValue(s string) {
 type AccessLogRecord struct {
 	Request              *http.Request
 	StatusCode           int
+	ResponseHeader       http.Header
 	RouteEndpoint        *route.Endpoint
 	StartedAt            time.Time
 	FirstByteAt          time.Time
@@ -87,21 +89,49 @@ type AccessLogRecord struct {
 	BodyBytesSent        int
 	RequestBytesReceived int
 	ExtraHeadersToLog    *[]string
+	TraceId              string
+	SpanId               string
+	B3Headers            bool
+	Formatter            Formatter
 	record               []byte
+
+	// RedactedURI is written instead of the request URI when it is set
+	RedactedURI string
+
+	// LookupTime is the time spent finding the route in the registry, and
+	// UpstreamAttempts are the tries at reaching its endpoints in order
+	LookupTime       time.Duration
+	UpstreamAttempts []UpstreamAttempt
+
+	// TLS describes the connection the request was received over, or is nil
+	// for plain HTTP
+	TLS *TLSDetails
 }
 
 func (r *AccessLogRecord) formatStartedAt() string {
 	return r.StartedAt.Format("02/01/2006:15:04:05.000 -0700")
 }
 
+func (r *AccessLogRecord) requestURI() string {
+	if r.RedactedURI != "" {
+		return r.RedactedURI
+	}
+	return r.Request.URL.RequestURI()
+}
+
 func (r *AccessLogRecord) responseTime() float64 {
 	return float64(r.FinishedAt.UnixNano()-r.StartedAt.UnixNano()) / float64(time.Second)
 }
 
-// getRecord memoizes makeRecord()
+// getRecord memoizes the record made by the Formatter, or by makeRecord()
+// when there is none
 func (r *AccessLogRecord) getRecord() []byte {
 	if len(r.record) == 0 {
-		r.record = r.makeRecord()
+		if r.Formatter != nil {
+			r.record = r.Formatter.Format(r)
+		} else {
+			r.record = r.makeRecord()
+		}
 	}
 
 	return r.record
@@ -123,7 +153,7 @@ func (r *AccessLogRecord) makeRecord() []byte {
 	b.WriteString(`[` + r.formatStartedAt() + `] `)
 
 	b.AppendSpaces(true)
-	b.WriteStringValues(r.Request.Method, r.Request.URL.RequestURI(), r.Request.Proto)
+	b.WriteStringValues(r.Request.Method, r.requestURI(), r.Request.Proto)
 	b.WriteDashOrIntValue(r.StatusCode)
 	b.WriteIntValue(r.RequestBytesReceived)
 	b.WriteIntValue(r.BodyBytesSent)
@@ -139,7 +169,7 @@ func (r *AccessLogRecord) makeRecord() []byte {
 	b.WriteDashOrStringValue(r.Request.Header.Get("X-Forwarded-Proto"))
 
 	b.WriteString(`vcap_request_id:`)
-	b.WriteDashOrStringValue(r.Request.Header.Get("X-Vcap-Request-Id"))
+	b.WriteDashOrStringValue(router_http.RequestId(r.Request))
 
 	b.WriteString(`response_time:`)
 	b.WriteDashOrFloatValue(r.responseTime())
@@ -151,7 +181,11 @@ func (r *AccessLogRecord) makeRecord() []byte {
 	b.WriteString(`app_index:`)
 	b.WriteDashOrStringValue(appIndex)
 
+	r.addTraceIds(b)
+	r.addUpstream(b)
+	r.addTLS(b)
 	r.addExtraHeaders(b)
+	r.addB3Headers(b)
 
 	b.WriteByte('\n')
 
@@ -164,6 +198,11 @@ func (r *AccessLogRecord) WriteTo(w io.Writer) (int64, error) {
 	return int64(bytesWritten), err
 }
 
+// Bytes returns the access log line of the record
+func (r *AccessLogRecord) Bytes() []byte {
+	return r.getRecord()
+}
+
 // ApplicationID returns the application ID that corresponds with the access log
 func (r *AccessLogRecord) ApplicationID() string {
 	if r.RouteEndpoint == nil {
@@ -182,18 +221,64 @@ func (r *AccessLogRecord) LogMessage() string {
 	return string(r.getRecord())
 }
 
+func (r *AccessLogRecord) addTraceIds(b *recordBuffer) {
+	if r.TraceId == "" {
+		return
+	}
+
+	b.WriteByte(' ')
+	b.AppendSpaces(true)
+	b.WriteString(`trace_id:`)
+	b.WriteDashOrStringValue(r.TraceId)
+	b.AppendSpaces(false)
+	b.WriteString(`span_id:`)
+	b.WriteDashOrStringValue(r.SpanId)
+}
+
+// addB3Headers logs the X-B3-* request headers like extra headers when they
+// carry the trace context, unless they are extra headers already.
+func (r *AccessLogRecord) addB3Headers(b *recordBuffer) {
+	if r.TraceId == "" || !r.B3Headers {
+		return
+	}
+
+	var headers []string
+	for _, header := range []string{router_http.B3TraceIdHeader, router_http.B3SpanIdHeader, router_http.B3ParentSpanIdHeader} {
+		if !r.logsExtraHeader(header) {
+			headers = append(headers, header)
+		}
+	}
+	r.addHeaders(b, headers)
+}
+
+func (r *AccessLogRecord) logsExtraHeader(header string) bool {
+	if r.ExtraHeadersToLog == nil {
+		return false
+	}
+	for _, h := range *r.ExtraHeadersToLog {
+		if strings.EqualFold(h, header) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *AccessLogRecord) addExtraHeaders(b *recordBuffer) {
 	if r.ExtraHeadersToLog == nil {
 		return
 	}
-	numExtraHeaders := len(*r.ExtraHeadersToLog)
+	r.addHeaders(b, *r.ExtraHeadersToLog)
+}
+
+func (r *AccessLogRecord) addHeaders(b *recordBuffer, headers []string) {
+	numExtraHeaders := len(headers)
 	if numExtraHeaders == 0 {
 		return
 	}
 
 	b.WriteByte(' ')
 	b.AppendSpaces(true)
-	for i, header := range *r.ExtraHeadersToLog {
+	for i, header := range headers {
 		// X-Something-Cool -> x_something_cool
 		headerName := strings.Replace(strings.ToLower(header), "-", "_", -1)
 		b.WriteString(headerName)
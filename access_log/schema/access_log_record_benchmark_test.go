@@ -0,0 +1,69 @@
+package schema_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+func benchmarkRecord(formatter schema.Formatter) schema.AccessLogRecord {
+	return schema.AccessLogRecord{
+		Request: &http.Request{
+			Host:   "app.example.com",
+			Method: "GET",
+			Proto:  "HTTP/1.1",
+			URL:    &url.URL{Path: "/some/path", RawQuery: "q=1"},
+			Header: http.Header{
+				"Referer":                       []string{"https://example.com/"},
+				"User-Agent":                    []string{"Mozilla/5.0 (X11; Linux x86_64)"},
+				"X-Forwarded-For":               []string{"10.0.0.1, 10.0.0.2"},
+				"X-Forwarded-Proto":             []string{"https"},
+				"Cache-Control":                 []string{"no-cache"},
+				router_http.VcapRequestIdHeader: []string{"e1b1c2f4-4a5b-4c6d-7e8f-9a0b1c2d3e4f"},
+			},
+			RemoteAddr: "10.0.0.2:51234",
+		},
+		StatusCode:           200,
+		RouteEndpoint:        route.NewEndpoint("app-guid", "10.0.1.1", 61001, "", "2", nil, -1, "", models.ModificationTag{}),
+		StartedAt:            time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+		FirstByteAt:          time.Date(2000, time.January, 1, 0, 0, 0, 1000000, time.UTC),
+		FinishedAt:           time.Date(2000, time.January, 1, 0, 0, 0, 2000000, time.UTC),
+		BodyBytesSent:        1024,
+		RequestBytesReceived: 64,
+		ExtraHeadersToLog:    &[]string{"Cache-Control"},
+		TraceId:              "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanId:               "00f067aa0ba902b7",
+		Formatter:            formatter,
+	}
+}
+
+func benchmarkWriteTo(formatter schema.Formatter, b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		r := benchmarkRecord(formatter)
+		r.WriteTo(ioutil.Discard)
+	}
+}
+
+func BenchmarkTextRecord(b *testing.B) {
+	benchmarkWriteTo(nil, b)
+}
+
+func BenchmarkJSONRecord(b *testing.B) {
+	benchmarkWriteTo(schema.JSONFormatter{}, b)
+}
+
+func BenchmarkTemplateRecord(b *testing.B) {
+	f, err := schema.NewTemplateFormatter(`$remote_addr - - [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkWriteTo(f, b)
+}
@@ -72,6 +72,11 @@ var _ = Describe("AccessLogRecord", func() {
 			Expect(record.LogMessage()).To(Equal(recordString))
 		})
 
+		It("logs the request id chosen by the router", func() {
+			record.Request = router_http.WithRequestId(record.Request, "custom-request-id")
+			Expect(record.LogMessage()).To(ContainSubstring(`vcap_request_id:"custom-request-id" `))
+		})
+
 		Context("with values missing", func() {
 			BeforeEach(func() {
 				record.Request.Header = http.Header{}
@@ -116,6 +121,107 @@ var _ = Describe("AccessLogRecord", func() {
 			})
 		})
 
+		Context("with trace ids", func() {
+			BeforeEach(func() {
+				record.TraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+				record.SpanId = "00f067aa0ba902b7"
+				record.ExtraHeadersToLog = &[]string{"Cache-Control"}
+			})
+			It("appends the trace and span ids before extra headers", func() {
+				Expect(record.LogMessage()).To(HaveSuffix(`app_index:"3" ` +
+					`trace_id:"4bf92f3577b34da6a3ce929d0e0e4736" ` +
+					`span_id:"00f067aa0ba902b7" ` +
+					`cache_control:"-"` +
+					"\n"))
+			})
+		})
+
+		Context("with upstream attempts", func() {
+			BeforeEach(func() {
+				record.LookupTime = 50 * time.Microsecond
+				record.UpstreamAttempts = []schema.UpstreamAttempt{
+					{
+						Addr:        "1.2.3.5:1234",
+						StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 124000000, time.UTC),
+						ConnectTime: 2 * time.Millisecond,
+						Error:       "dial tcp 1.2.3.5:1234: connect: connection refused",
+					},
+					{
+						Addr:        "1.2.3.4:1234",
+						StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 126000000, time.UTC),
+						ConnectTime: time.Millisecond,
+						HeaderTime:  500 * time.Millisecond,
+					},
+				}
+				record.ExtraHeadersToLog = &[]string{"Cache-Control"}
+			})
+			It("appends the lookup time and every attempt before extra headers", func() {
+				Expect(record.LogMessage()).To(HaveSuffix(`app_index:"3" ` +
+					`lookup_time:0.00005 ` +
+					`upstream_attempts:2 ` +
+					`upstream_addr:"1.2.3.5:1234, 1.2.3.4:1234" ` +
+					`upstream_connect_time:"0.002, 0.001" ` +
+					`upstream_header_time:"-, 0.5" ` +
+					`upstream_error:"dial tcp 1.2.3.5:1234: connect: connection refused, -" ` +
+					`cache_control:"-"` +
+					"\n"))
+			})
+
+			Context("when the request was not proxied", func() {
+				BeforeEach(func() {
+					record.UpstreamAttempts = nil
+				})
+
+				It("appends the lookup time", func() {
+					Expect(record.LogMessage()).To(HaveSuffix(`app_index:"3" ` +
+						`lookup_time:0.00005 ` +
+						`cache_control:"-"` +
+						"\n"))
+				})
+			})
+		})
+
+		Context("with TLS details", func() {
+			BeforeEach(func() {
+				record.TLS = &schema.TLSDetails{
+					Version:     "TLSv1.2",
+					CipherSuite: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+					ServerName:  "example.com",
+				}
+				record.ExtraHeadersToLog = &[]string{"Cache-Control"}
+			})
+			It("appends them before extra headers", func() {
+				Expect(record.LogMessage()).To(HaveSuffix(`app_index:"3" ` +
+					`tls_version:"TLSv1.2" ` +
+					`tls_cipher:"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256" ` +
+					`tls_server_name:"example.com" ` +
+					`tls_client_cert:"-" ` +
+					`cache_control:"-"` +
+					"\n"))
+			})
+		})
+
+		Context("with trace ids propagated in B3 headers", func() {
+			BeforeEach(func() {
+				record.TraceId = "a3ce929d0e0e4736"
+				record.SpanId = "00f067aa0ba902b7"
+				record.B3Headers = true
+				record.Request.Header.Set(router_http.B3TraceIdHeader, "a3ce929d0e0e4736")
+				record.Request.Header.Set(router_http.B3SpanIdHeader, "00f067aa0ba902b7")
+				record.ExtraHeadersToLog = &[]string{"Cache-Control", router_http.B3SpanIdHeader}
+			})
+			It("also logs the B3 headers after the extra headers", func() {
+				Expect(record.LogMessage()).To(HaveSuffix(`app_index:"3" ` +
+					`trace_id:"a3ce929d0e0e4736" ` +
+					`span_id:"00f067aa0ba902b7" ` +
+					`cache_control:"-" ` +
+					`x_b3_spanid:"00f067aa0ba902b7" ` +
+					`x_b3_traceid:"a3ce929d0e0e4736" ` +
+					`x_b3_parentspanid:"-"` +
+					"\n"))
+			})
+		})
+
 		Context("with extra headers", func() {
 			BeforeEach(func() {
 				record.Request.Header.Set("Cache-Control", "no-cache")
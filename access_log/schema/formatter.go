@@ -0,0 +1,41 @@
+package schema
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/gorouter/config"
+)
+
+// Formatter makes the access log line of a record, including its trailing
+// newline
+type Formatter interface {
+	Format(r *AccessLogRecord) []byte
+}
+
+// TextFormatter makes the default space separated access log lines
+type TextFormatter struct{}
+
+func (TextFormatter) Format(r *AccessLogRecord) []byte {
+	return r.makeRecord()
+}
+
+// JSONFormatter makes one JSON object per access log line
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(r *AccessLogRecord) []byte {
+	return r.makeJSONRecord()
+}
+
+// NewFormatter returns the Formatter of the configured access log format
+func NewFormatter(c config.AccessLog) (Formatter, error) {
+	switch c.Format {
+	case "", config.ACCESS_LOG_FORMAT_TEXT:
+		return TextFormatter{}, nil
+	case config.ACCESS_LOG_FORMAT_JSON:
+		return JSONFormatter{}, nil
+	case config.ACCESS_LOG_FORMAT_TEMPLATE:
+		return NewTemplateFormatter(c.Template)
+	default:
+		return nil, fmt.Errorf("unknown access log format %s", c.Format)
+	}
+}
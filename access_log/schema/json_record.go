@@ -0,0 +1,191 @@
+package schema
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// jsonBuffer writes the fields of a JSON object to the buffer without
+// reflection. Empty values are left out of the object.
+type jsonBuffer struct {
+	bytes.Buffer
+	fields  int
+	scratch [64]byte
+}
+
+// key writes the separator and the quoted name of the next field
+func (b *jsonBuffer) key(k string) {
+	if b.fields > 0 {
+		_ = b.WriteByte(',')
+	}
+	b.fields++
+	b.writeQuoted(k)
+	_ = b.WriteByte(':')
+}
+
+// WriteStringField writes a string field unless v is empty
+func (b *jsonBuffer) WriteStringField(k, v string) {
+	if v == "" {
+		return
+	}
+	b.key(k)
+	b.writeQuoted(v)
+}
+
+// WriteIntField writes an int field
+func (b *jsonBuffer) WriteIntField(k string, v int) {
+	b.key(k)
+	_, _ = b.Write(strconv.AppendInt(b.scratch[:0], int64(v), 10))
+}
+
+// WriteFloatField writes a float field
+func (b *jsonBuffer) WriteFloatField(k string, v float64) {
+	b.key(k)
+	_, _ = b.Write(strconv.AppendFloat(b.scratch[:0], v, 'f', -1, 64))
+}
+
+// WriteTimeField writes a time field in RFC3339 with nanoseconds unless t is
+// the zero time
+func (b *jsonBuffer) WriteTimeField(k string, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	b.key(k)
+	_ = b.WriteByte('"')
+	_, _ = b.Write(t.AppendFormat(b.scratch[:0], time.RFC3339Nano))
+	_ = b.WriteByte('"')
+}
+
+// writeQuoted writes s as a JSON string, replacing invalid UTF-8 with U+FFFD
+func (b *jsonBuffer) writeQuoted(s string) {
+	_ = b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			_, _ = b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				_ = b.WriteByte('\\')
+				_ = b.WriteByte(c)
+			case '\n':
+				_, _ = b.WriteString(`\n`)
+			case '\r':
+				_, _ = b.WriteString(`\r`)
+			case '\t':
+				_, _ = b.WriteString(`\t`)
+			default:
+				_, _ = b.WriteString(`\u00`)
+				_ = b.WriteByte(hexDigits[c>>4])
+				_ = b.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			_, _ = b.WriteString(s[start:i])
+			_, _ = b.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	_, _ = b.WriteString(s[start:])
+	_ = b.WriteByte('"')
+}
+
+func (r *AccessLogRecord) makeJSONRecord() []byte {
+	var appID, destIPandPort, appIndex string
+
+	if r.RouteEndpoint != nil {
+		appID = r.RouteEndpoint.ApplicationId
+		appIndex = r.RouteEndpoint.PrivateInstanceIndex
+		destIPandPort = r.RouteEndpoint.CanonicalAddr()
+	}
+
+	b := new(jsonBuffer)
+	b.Grow(512)
+
+	_ = b.WriteByte('{')
+	b.WriteTimeField("timestamp", r.StartedAt)
+	b.WriteStringField("host", r.Request.Host)
+	b.WriteStringField("method", r.Request.Method)
+	b.WriteStringField("uri", r.requestURI())
+	b.WriteStringField("protocol", r.Request.Proto)
+	if r.StatusCode != 0 {
+		b.WriteIntField("status", r.StatusCode)
+	}
+	b.WriteIntField("request_bytes_received", r.RequestBytesReceived)
+	b.WriteIntField("body_bytes_sent", r.BodyBytesSent)
+	b.WriteStringField("referer", r.Request.Header.Get("Referer"))
+	b.WriteStringField("user_agent", r.Request.Header.Get("User-Agent"))
+	b.WriteStringField("remote_addr", r.Request.RemoteAddr)
+	b.WriteStringField("backend_addr", destIPandPort)
+	b.WriteStringField("x_forwarded_for", r.Request.Header.Get("X-Forwarded-For"))
+	b.WriteStringField("x_forwarded_proto", r.Request.Header.Get("X-Forwarded-Proto"))
+	b.WriteStringField("vcap_request_id", router_http.RequestId(r.Request))
+
+	r.addJSONTimings(b)
+	r.addJSONUpstream(b)
+
+	b.WriteStringField("app_id", appID)
+	if index, err := strconv.Atoi(appIndex); err == nil {
+		b.WriteIntField("app_index", index)
+	}
+	b.WriteStringField("trace_id", r.TraceId)
+	b.WriteStringField("span_id", r.SpanId)
+
+	r.addJSONTLS(b)
+
+	r.addJSONExtraHeaders(b)
+
+	_, _ = b.WriteString("}\n")
+
+	return b.Bytes()
+}
+
+// addJSONTimings writes the times a request was answered at and the seconds
+// it took
+func (r *AccessLogRecord) addJSONTimings(b *jsonBuffer) {
+	b.WriteTimeField("first_byte_at", r.FirstByteAt)
+	b.WriteTimeField("finished_at", r.FinishedAt)
+
+	if !r.FinishedAt.IsZero() {
+		b.WriteFloatField("response_time", r.responseTime())
+	}
+	if !r.FirstByteAt.IsZero() {
+		b.WriteFloatField("time_to_first_byte", r.FirstByteAt.Sub(r.StartedAt).Seconds())
+	}
+}
+
+func (r *AccessLogRecord) addJSONExtraHeaders(b *jsonBuffer) {
+	if r.ExtraHeadersToLog == nil || len(*r.ExtraHeadersToLog) == 0 {
+		return
+	}
+
+	b.key("extra_headers")
+	_ = b.WriteByte('{')
+	fields := b.fields
+	b.fields = 0
+	for _, header := range *r.ExtraHeadersToLog {
+		// X-Something-Cool -> x_something_cool
+		headerName := strings.Replace(strings.ToLower(header), "-", "_", -1)
+		b.WriteStringField(headerName, r.Request.Header.Get(header))
+	}
+	b.fields = fields
+	_ = b.WriteByte('}')
+}
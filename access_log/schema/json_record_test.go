@@ -0,0 +1,195 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONFormatter", func() {
+	var (
+		record *schema.AccessLogRecord
+	)
+
+	BeforeEach(func() {
+		endpoint := route.NewEndpoint("FakeApplicationId", "1.2.3.4", 1234, "", "3", nil, 0, "", models.ModificationTag{})
+		record = &schema.AccessLogRecord{
+			Request: &http.Request{
+				Host:   "FakeRequestHost",
+				Method: "GET",
+				Proto:  "HTTP/1.1",
+				URL: &url.URL{
+					Opaque: "http://example.com/request",
+				},
+				Header: http.Header{
+					"Referer":                       []string{"FakeReferer"},
+					"User-Agent":                    []string{"FakeUserAgent"},
+					"X-Forwarded-For":               []string{"FakeProxy1, FakeProxy2"},
+					"X-Forwarded-Proto":             []string{"https"},
+					router_http.VcapRequestIdHeader: []string{"abc-123-xyz-pdq"},
+				},
+				RemoteAddr: "FakeRemoteAddr",
+			},
+			BodyBytesSent:        23,
+			StatusCode:           200,
+			RouteEndpoint:        endpoint,
+			StartedAt:            time.Date(2000, time.January, 1, 0, 0, 0, 123456789, time.UTC),
+			FirstByteAt:          time.Date(2000, time.January, 1, 0, 0, 0, 623456789, time.UTC),
+			FinishedAt:           time.Date(2000, time.January, 1, 0, 0, 1, 123456789, time.UTC),
+			RequestBytesReceived: 30,
+			Formatter:            schema.JSONFormatter{},
+		}
+	})
+
+	It("makes a JSON line with typed values", func() {
+		Expect(record.LogMessage()).To(Equal(`{` +
+			`"timestamp":"2000-01-01T00:00:00.123456789Z",` +
+			`"host":"FakeRequestHost",` +
+			`"method":"GET",` +
+			`"uri":"http://example.com/request",` +
+			`"protocol":"HTTP/1.1",` +
+			`"status":200,` +
+			`"request_bytes_received":30,` +
+			`"body_bytes_sent":23,` +
+			`"referer":"FakeReferer",` +
+			`"user_agent":"FakeUserAgent",` +
+			`"remote_addr":"FakeRemoteAddr",` +
+			`"backend_addr":"1.2.3.4:1234",` +
+			`"x_forwarded_for":"FakeProxy1, FakeProxy2",` +
+			`"x_forwarded_proto":"https",` +
+			`"vcap_request_id":"abc-123-xyz-pdq",` +
+			`"first_byte_at":"2000-01-01T00:00:00.623456789Z",` +
+			`"finished_at":"2000-01-01T00:00:01.123456789Z",` +
+			`"response_time":1,` +
+			`"time_to_first_byte":0.5,` +
+			`"app_id":"FakeApplicationId",` +
+			`"app_index":3` +
+			"}\n"))
+	})
+
+	Context("with values missing", func() {
+		BeforeEach(func() {
+			record.Request.Header = http.Header{}
+			record.RouteEndpoint = &route.Endpoint{ApplicationId: "FakeApplicationId"}
+			record.StatusCode = 0
+			record.FirstByteAt = time.Time{}
+			record.FinishedAt = time.Time{}
+		})
+
+		It("leaves them out", func() {
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(record.LogMessage()), &fields)).To(Succeed())
+
+			Expect(fields).ToNot(HaveKey("status"))
+			Expect(fields).ToNot(HaveKey("referer"))
+			Expect(fields).ToNot(HaveKey("response_time"))
+			Expect(fields).ToNot(HaveKey("first_byte_at"))
+			Expect(fields).ToNot(HaveKey("app_index"))
+			Expect(fields).To(HaveKeyWithValue("request_bytes_received", BeNumerically("==", 30)))
+		})
+	})
+
+	Context("with trace ids and extra headers", func() {
+		BeforeEach(func() {
+			record.TraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+			record.SpanId = "00f067aa0ba902b7"
+			record.Request.Header.Set("Cache-Control", "no-cache")
+			record.ExtraHeadersToLog = &[]string{"Cache-Control", "Doesnt-Exist"}
+		})
+
+		It("appends them", func() {
+			Expect(record.LogMessage()).To(HaveSuffix(`"app_index":3,` +
+				`"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736",` +
+				`"span_id":"00f067aa0ba902b7",` +
+				`"extra_headers":{"cache_control":"no-cache"}` +
+				"}\n"))
+		})
+	})
+
+	Context("with upstream attempts", func() {
+		BeforeEach(func() {
+			record.LookupTime = 50 * time.Microsecond
+			record.UpstreamAttempts = []schema.UpstreamAttempt{
+				{
+					Addr:        "1.2.3.5:1234",
+					StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 124000000, time.UTC),
+					ConnectTime: 2 * time.Millisecond,
+					Error:       "dial tcp 1.2.3.5:1234: connect: connection refused",
+				},
+				{
+					Addr:        "1.2.3.4:1234",
+					StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 126000000, time.UTC),
+					ConnectTime: time.Millisecond,
+					HeaderTime:  500 * time.Millisecond,
+				},
+			}
+		})
+
+		It("writes the lookup time and every attempt", func() {
+			Expect(record.LogMessage()).To(ContainSubstring(`"time_to_first_byte":0.5,` +
+				`"lookup_time":0.00005,` +
+				`"upstream_attempts":[` +
+				`{"addr":"1.2.3.5:1234","started_at":"2000-01-01T00:00:00.124Z","connect_time":0.002,` +
+				`"error":"dial tcp 1.2.3.5:1234: connect: connection refused"},` +
+				`{"addr":"1.2.3.4:1234","started_at":"2000-01-01T00:00:00.126Z","connect_time":0.001,"time_to_first_byte":0.5}` +
+				`],"app_id":"FakeApplicationId"`))
+
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(record.LogMessage()), &fields)).To(Succeed())
+		})
+
+		It("marks route service attempts", func() {
+			record.UpstreamAttempts[0].RouteService = true
+			Expect(record.LogMessage()).To(ContainSubstring(`{"addr":"1.2.3.5:1234","route_service":true,`))
+		})
+	})
+
+	Context("with TLS details", func() {
+		BeforeEach(func() {
+			record.TLS = &schema.TLSDetails{
+				Version:           "TLSv1.3",
+				CipherSuite:       "TLS_AES_128_GCM_SHA256",
+				ClientCertSubject: "CN=client,O=Acme",
+			}
+		})
+
+		It("writes them after the trace ids", func() {
+			Expect(record.LogMessage()).To(HaveSuffix(`"app_index":3,` +
+				`"tls_version":"TLSv1.3",` +
+				`"tls_cipher":"TLS_AES_128_GCM_SHA256",` +
+				`"tls_client_cert":"CN=client,O=Acme"` +
+				"}\n"))
+		})
+	})
+
+	It("escapes strings", func() {
+		record.Request.Header.Set("User-Agent", "quote\" backslash\\ tab\t bell\a bad\xff é")
+
+		var fields map[string]interface{}
+		Expect(json.Unmarshal([]byte(record.LogMessage()), &fields)).To(Succeed())
+		Expect(fields).To(HaveKeyWithValue("user_agent", "quote\" backslash\\ tab\t bell\a bad\ufffd é"))
+		Expect(record.LogMessage()).To(ContainSubstring(`bell\u0007`))
+	})
+})
+
+var _ = Describe("NewFormatter", func() {
+	It("returns the formatter of the format", func() {
+		Expect(schema.NewFormatter(config.AccessLog{Format: config.ACCESS_LOG_FORMAT_TEXT})).To(Equal(schema.TextFormatter{}))
+		Expect(schema.NewFormatter(config.AccessLog{Format: config.ACCESS_LOG_FORMAT_JSON})).To(Equal(schema.JSONFormatter{}))
+	})
+
+	It("fails on an unknown format", func() {
+		_, err := schema.NewFormatter(config.AccessLog{Format: "xml"})
+		Expect(err).To(HaveOccurred())
+	})
+})
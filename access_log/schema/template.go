@@ -0,0 +1,227 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+)
+
+const (
+	requestHeaderPrefix  = "http_"
+	responseHeaderPrefix = "sent_http_"
+)
+
+// templateValue returns the value of a template variable, or "" when the
+// record has none
+type templateValue func(r *AccessLogRecord) string
+
+// templateSegment is a literal followed by an optional variable
+type templateSegment struct {
+	literal string
+	value   templateValue
+}
+
+// TemplateFormatter makes access log lines from a template of $variables,
+// in the way of nginx log_format. Variables without a value are written as
+// "-". The template is compiled by NewTemplateFormatter.
+type TemplateFormatter struct {
+	segments []templateSegment
+}
+
+var templateValues = map[string]templateValue{
+	"host":        func(r *AccessLogRecord) string { return r.Request.Host },
+	"remote_addr": func(r *AccessLogRecord) string { return r.Request.RemoteAddr },
+	"request": func(r *AccessLogRecord) string {
+		return r.Request.Method + " " + r.requestURI() + " " + r.Request.Proto
+	},
+	"request_method":    func(r *AccessLogRecord) string { return r.Request.Method },
+	"request_uri":       func(r *AccessLogRecord) string { return r.requestURI() },
+	"server_protocol":   func(r *AccessLogRecord) string { return r.Request.Proto },
+	"status":            func(r *AccessLogRecord) string { return formatNonZero(r.StatusCode) },
+	"request_length":    func(r *AccessLogRecord) string { return strconv.Itoa(r.RequestBytesReceived) },
+	"body_bytes_sent":   func(r *AccessLogRecord) string { return strconv.Itoa(r.BodyBytesSent) },
+	"time_local":        func(r *AccessLogRecord) string { return r.StartedAt.Format("02/Jan/2006:15:04:05 -0700") },
+	"time_iso8601":      func(r *AccessLogRecord) string { return r.StartedAt.Format(time.RFC3339) },
+	"time_rfc3339_nano": func(r *AccessLogRecord) string { return r.StartedAt.Format(time.RFC3339Nano) },
+	"msec": func(r *AccessLogRecord) string {
+		return strconv.FormatFloat(float64(r.StartedAt.UnixNano()/int64(time.Millisecond))/1000, 'f', 3, 64)
+	},
+	"request_time": func(r *AccessLogRecord) string {
+		if r.FinishedAt.IsZero() {
+			return ""
+		}
+		return strconv.FormatFloat(r.responseTime(), 'f', -1, 64)
+	},
+	"time_to_first_byte": func(r *AccessLogRecord) string {
+		if r.FirstByteAt.IsZero() {
+			return ""
+		}
+		return strconv.FormatFloat(r.FirstByteAt.Sub(r.StartedAt).Seconds(), 'f', -1, 64)
+	},
+	"upstream_addr":         func(r *AccessLogRecord) string { return r.upstreamAddrs() },
+	"upstream_attempts":     func(r *AccessLogRecord) string { return formatNonZero(len(r.UpstreamAttempts)) },
+	"upstream_connect_time": func(r *AccessLogRecord) string { return r.upstreamConnectTimes() },
+	"upstream_header_time":  func(r *AccessLogRecord) string { return r.upstreamHeaderTimes() },
+	"upstream_error":        func(r *AccessLogRecord) string { return r.upstreamErrors() },
+	"lookup_time": func(r *AccessLogRecord) string {
+		if r.LookupTime == 0 {
+			return ""
+		}
+		return formatSeconds(r.LookupTime)
+	},
+	"app_id": func(r *AccessLogRecord) string { return r.ApplicationID() },
+	"app_index": func(r *AccessLogRecord) string {
+		if r.RouteEndpoint == nil {
+			return ""
+		}
+		return r.RouteEndpoint.PrivateInstanceIndex
+	},
+	"vcap_request_id": func(r *AccessLogRecord) string { return router_http.RequestId(r.Request) },
+	"trace_id":        func(r *AccessLogRecord) string { return r.TraceId },
+	"span_id":         func(r *AccessLogRecord) string { return r.SpanId },
+	"ssl_protocol":    tlsValue(func(d *TLSDetails) string { return d.Version }),
+	"ssl_cipher":      tlsValue(func(d *TLSDetails) string { return d.CipherSuite }),
+	"ssl_server_name": tlsValue(func(d *TLSDetails) string { return d.ServerName }),
+	"ssl_client_s_dn": tlsValue(func(d *TLSDetails) string { return d.ClientCertSubject }),
+}
+
+// NewTemplateFormatter compiles a template such as
+//
+//	$remote_addr - - [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"
+//
+// Variables are written as $name or ${name}, and $$ is a literal $.
+// $http_name and $sent_http_name are request and response headers, with
+// underscores in name standing for dashes.
+func NewTemplateFormatter(template string) (*TemplateFormatter, error) {
+	f := &TemplateFormatter{}
+
+	var literal bytes.Buffer
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if c != '$' {
+			literal.WriteByte(c)
+			continue
+		}
+		if i+1 < len(template) && template[i+1] == '$' {
+			literal.WriteByte('$')
+			i++
+			continue
+		}
+
+		var name string
+		if i+1 < len(template) && template[i+1] == '{' {
+			end := strings.IndexByte(template[i+2:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("access log template: unclosed ${ at offset %d", i)
+			}
+			name = template[i+2 : i+2+end]
+			i += end + 2
+		} else {
+			end := i + 1
+			for end < len(template) && isVariableByte(template[end]) {
+				end++
+			}
+			name = template[i+1 : end]
+			i = end - 1
+		}
+
+		value, err := compileVariable(name)
+		if err != nil {
+			return nil, err
+		}
+		f.segments = append(f.segments, templateSegment{literal: literal.String(), value: value})
+		literal.Reset()
+	}
+	if literal.Len() > 0 {
+		f.segments = append(f.segments, templateSegment{literal: literal.String()})
+	}
+
+	return f, nil
+}
+
+func (f *TemplateFormatter) Format(r *AccessLogRecord) []byte {
+	b := new(bytes.Buffer)
+	b.Grow(256)
+
+	for _, s := range f.segments {
+		b.WriteString(s.literal)
+		if s.value == nil {
+			continue
+		}
+		if v := s.value(r); v != "" {
+			writeEscaped(b, v)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	b.WriteByte('\n')
+
+	return b.Bytes()
+}
+
+func compileVariable(name string) (templateValue, error) {
+	if name == "" {
+		return nil, fmt.Errorf("access log template: empty variable name")
+	}
+	if value, ok := templateValues[name]; ok {
+		return value, nil
+	}
+
+	// The header names are canonicalized once so that lines are made with
+	// plain map lookups.
+	switch {
+	case strings.HasPrefix(name, responseHeaderPrefix) && len(name) > len(responseHeaderPrefix):
+		key := headerKey(name[len(responseHeaderPrefix):])
+		return func(r *AccessLogRecord) string { return firstHeaderValue(r.ResponseHeader, key) }, nil
+	case strings.HasPrefix(name, requestHeaderPrefix) && len(name) > len(requestHeaderPrefix):
+		key := headerKey(name[len(requestHeaderPrefix):])
+		return func(r *AccessLogRecord) string { return firstHeaderValue(r.Request.Header, key) }, nil
+	}
+
+	return nil, fmt.Errorf("access log template: unknown variable $%s", name)
+}
+
+func isVariableByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func headerKey(name string) string {
+	return http.CanonicalHeaderKey(strings.Replace(name, "_", "-", -1))
+}
+
+func firstHeaderValue(h http.Header, key string) string {
+	if v := h[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func formatNonZero(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+// writeEscaped writes v with quotes, backslashes and control characters
+// escaped as \xHH, so values cannot break out of quoted fields or lines
+func writeEscaped(b *bytes.Buffer, v string) {
+	start := 0
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c >= 0x20 && c != 0x7f && c != '"' && c != '\\' {
+			continue
+		}
+		b.WriteString(v[start:i])
+		b.WriteString(`\x`)
+		b.WriteByte(hexDigits[c>>4])
+		b.WriteByte(hexDigits[c&0xf])
+		start = i + 1
+	}
+	b.WriteString(v[start:])
+}
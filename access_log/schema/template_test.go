@@ -0,0 +1,139 @@
+package schema_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TemplateFormatter", func() {
+	var (
+		record *schema.AccessLogRecord
+	)
+
+	format := func(template string) string {
+		f, err := schema.NewTemplateFormatter(template)
+		Expect(err).ToNot(HaveOccurred())
+		record.Formatter = f
+		return record.LogMessage()
+	}
+
+	BeforeEach(func() {
+		endpoint := route.NewEndpoint("FakeApplicationId", "1.2.3.4", 1234, "", "3", nil, 0, "", models.ModificationTag{})
+		record = &schema.AccessLogRecord{
+			Request: &http.Request{
+				Host:   "FakeRequestHost",
+				Method: "GET",
+				Proto:  "HTTP/1.1",
+				URL: &url.URL{
+					Path:     "/request",
+					RawQuery: "a=b",
+				},
+				Header: http.Header{
+					"Referer":                       []string{"FakeReferer"},
+					"User-Agent":                    []string{"FakeUserAgent"},
+					router_http.VcapRequestIdHeader: []string{"abc-123-xyz-pdq"},
+				},
+				RemoteAddr: "10.0.0.1",
+			},
+			ResponseHeader: http.Header{
+				"Content-Type": []string{"text/plain"},
+			},
+			BodyBytesSent:        23,
+			StatusCode:           200,
+			RouteEndpoint:        endpoint,
+			StartedAt:            time.Date(2000, time.January, 1, 0, 0, 0, 123456789, time.UTC),
+			FirstByteAt:          time.Date(2000, time.January, 1, 0, 0, 0, 623456789, time.UTC),
+			FinishedAt:           time.Date(2000, time.January, 1, 0, 0, 1, 123456789, time.UTC),
+			RequestBytesReceived: 30,
+		}
+	})
+
+	It("makes the Apache combined format", func() {
+		Expect(format(`$remote_addr - - [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`)).
+			To(Equal(`10.0.0.1 - - [01/Jan/2000:00:00:00 +0000] "GET /request?a=b HTTP/1.1" 200 23 "FakeReferer" "FakeUserAgent"` + "\n"))
+	})
+
+	It("writes the fields of the record", func() {
+		Expect(format(`$host $request_method $request_uri $server_protocol $request_length $upstream_addr ` +
+			`$app_id $app_index $vcap_request_id $request_time $time_to_first_byte $time_iso8601 $time_rfc3339_nano $msec`)).
+			To(Equal("FakeRequestHost GET /request?a=b HTTP/1.1 30 1.2.3.4:1234 " +
+				"FakeApplicationId 3 abc-123-xyz-pdq 1 0.5 2000-01-01T00:00:00Z 2000-01-01T00:00:00.123456789Z 946684800.123\n"))
+	})
+
+	It("writes request and response headers", func() {
+		Expect(format(`${http_user_agent}|$sent_http_content_type`)).To(Equal("FakeUserAgent|text/plain\n"))
+	})
+
+	It("writes TLS details", func() {
+		record.TLS = schema.NewTLSDetails(&tls.ConnectionState{
+			Version:     tls.VersionTLS12,
+			CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			ServerName:  "example.com",
+		})
+		Expect(format(`$ssl_protocol $ssl_cipher $ssl_server_name $ssl_client_s_dn`)).
+			To(Equal("TLSv1.2 TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 example.com -\n"))
+	})
+
+	It("writes upstream timings for every attempt", func() {
+		record.LookupTime = 50 * time.Microsecond
+		record.UpstreamAttempts = []schema.UpstreamAttempt{
+			{
+				Addr:        "1.2.3.5:1234",
+				StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 124000000, time.UTC),
+				ConnectTime: 2 * time.Millisecond,
+				Error:       "dial tcp 1.2.3.5:1234: connect: connection refused",
+			},
+			{
+				Addr:        "1.2.3.4:1234",
+				StartedAt:   time.Date(2000, time.January, 1, 0, 0, 0, 126000000, time.UTC),
+				ConnectTime: time.Millisecond,
+				HeaderTime:  500 * time.Millisecond,
+			},
+		}
+		Expect(format(`$lookup_time $upstream_attempts "$upstream_connect_time" "$upstream_header_time" "$upstream_error"`)).
+			To(Equal(`0.00005 2 "0.002, 0.001" "-, 0.5" "dial tcp 1.2.3.5:1234: connect: connection refused, -"` + "\n"))
+	})
+
+	It("writes a dash for missing values", func() {
+		record.StatusCode = 0
+		Expect(format(`$status $trace_id $http_x_missing $ssl_protocol $lookup_time $upstream_attempts $upstream_connect_time`)).To(Equal("- - - - - - -\n"))
+	})
+
+	It("escapes values", func() {
+		record.Request.Header.Set("User-Agent", "quote\" backslash\\ newline\n")
+		Expect(format(`"$http_user_agent"`)).To(Equal(`"quote\x22 backslash\x5c newline\x0a"` + "\n"))
+	})
+
+	It("writes $$ as $", func() {
+		Expect(format(`$$status`)).To(Equal("$status\n"))
+	})
+
+	It("fails on unknown variables", func() {
+		_, err := schema.NewTemplateFormatter(`$status $bogus`)
+		Expect(err).To(MatchError(ContainSubstring("$bogus")))
+
+		_, err = schema.NewTemplateFormatter(`${status`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = schema.NewTemplateFormatter(`cost $`)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("is made by NewFormatter", func() {
+		f, err := schema.NewFormatter(config.AccessLog{Format: config.ACCESS_LOG_FORMAT_TEMPLATE, Template: "$status"})
+		Expect(err).ToNot(HaveOccurred())
+		record.Formatter = f
+		Expect(record.LogMessage()).To(Equal("200\n"))
+	})
+})
@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// TLSDetails describes the TLS connection a request was received over
+type TLSDetails struct {
+	Version     string
+	CipherSuite string
+	ServerName  string
+
+	// ClientCertSubject is the distinguished name of the client certificate,
+	// or empty when the client did not present one
+	ClientCertSubject string
+}
+
+// NewTLSDetails returns the details of state, or nil for a request that was
+// not received over TLS
+func NewTLSDetails(state *tls.ConnectionState) *TLSDetails {
+	if state == nil {
+		return nil
+	}
+
+	d := &TLSDetails{
+		Version:     tlsVersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		ServerName:  state.ServerName,
+	}
+	if len(state.PeerCertificates) > 0 {
+		d.ClientCertSubject = state.PeerCertificates[0].Subject.String()
+	}
+	return d
+}
+
+// addTLS writes the TLS details when the request was received over TLS
+func (r *AccessLogRecord) addTLS(b *recordBuffer) {
+	if r.TLS == nil {
+		return
+	}
+
+	b.WriteByte(' ')
+	b.AppendSpaces(true)
+	b.WriteString(`tls_version:`)
+	b.WriteDashOrStringValue(r.TLS.Version)
+	b.WriteString(`tls_cipher:`)
+	b.WriteDashOrStringValue(r.TLS.CipherSuite)
+	b.WriteString(`tls_server_name:`)
+	b.WriteDashOrStringValue(r.TLS.ServerName)
+	b.AppendSpaces(false)
+	b.WriteString(`tls_client_cert:`)
+	b.WriteDashOrStringValue(r.TLS.ClientCertSubject)
+}
+
+func (r *AccessLogRecord) addJSONTLS(b *jsonBuffer) {
+	if r.TLS == nil {
+		return
+	}
+
+	b.WriteStringField("tls_version", r.TLS.Version)
+	b.WriteStringField("tls_cipher", r.TLS.CipherSuite)
+	b.WriteStringField("tls_server_name", r.TLS.ServerName)
+	b.WriteStringField("tls_client_cert", r.TLS.ClientCertSubject)
+}
+
+// tlsValue returns a template variable that reads a field of the TLS details
+func tlsValue(field func(d *TLSDetails) string) templateValue {
+	return func(r *AccessLogRecord) string {
+		if r.TLS == nil {
+			return ""
+		}
+		return field(r.TLS)
+	}
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLSv1"
+	case tls.VersionTLS11:
+		return "TLSv1.1"
+	case tls.VersionTLS12:
+		return "TLSv1.2"
+	case tls.VersionTLS13:
+		return "TLSv1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
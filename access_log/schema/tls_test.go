@@ -0,0 +1,46 @@
+package schema_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewTLSDetails", func() {
+	It("returns nil without a TLS connection", func() {
+		Expect(schema.NewTLSDetails(nil)).To(BeNil())
+	})
+
+	It("names the protocol version and cipher suite", func() {
+		details := schema.NewTLSDetails(&tls.ConnectionState{
+			Version:     tls.VersionTLS13,
+			CipherSuite: tls.TLS_AES_256_GCM_SHA384,
+			ServerName:  "example.com",
+		})
+		Expect(details).To(Equal(&schema.TLSDetails{
+			Version:     "TLSv1.3",
+			CipherSuite: "TLS_AES_256_GCM_SHA384",
+			ServerName:  "example.com",
+		}))
+	})
+
+	It("writes unknown protocol versions in hex", func() {
+		details := schema.NewTLSDetails(&tls.ConnectionState{Version: 0x0300})
+		Expect(details.Version).To(Equal("0x0300"))
+	})
+
+	It("uses the subject of the client certificate", func() {
+		details := schema.NewTLSDetails(&tls.ConnectionState{
+			Version: tls.VersionTLS12,
+			PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "client", Organization: []string{"Acme"}}},
+			},
+		})
+		Expect(details.ClientCertSubject).To(Equal("CN=client,O=Acme"))
+	})
+})
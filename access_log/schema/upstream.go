@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UpstreamAttempt is one try at sending a request to a backend or a route
+// service
+type UpstreamAttempt struct {
+	Addr         string
+	RouteService bool
+	StartedAt    time.Time
+
+	// ConnectTime is the time spent getting a connection, including the dial
+	// and the TLS handshake. It is zero when an idle connection was reused.
+	ConnectTime time.Duration
+
+	// HeaderTime is the time until the response headers were received, or
+	// zero when the attempt failed
+	HeaderTime time.Duration
+
+	Error string
+}
+
+// addUpstream writes the registry lookup time when the route was looked up,
+// and the number of upstream attempts with the address, connect time, time to
+// first byte and error of each when the request was proxied
+func (r *AccessLogRecord) addUpstream(b *recordBuffer) {
+	if r.LookupTime <= 0 && len(r.UpstreamAttempts) == 0 {
+		return
+	}
+
+	b.WriteByte(' ')
+	b.AppendSpaces(len(r.UpstreamAttempts) > 0)
+	b.WriteString(`lookup_time:`)
+	b.WriteDashOrFloatValue(r.LookupTime.Seconds())
+	if len(r.UpstreamAttempts) == 0 {
+		return
+	}
+
+	b.WriteString(`upstream_attempts:`)
+	b.WriteIntValue(len(r.UpstreamAttempts))
+	b.WriteString(`upstream_addr:`)
+	b.WriteDashOrStringValue(r.upstreamAddrs())
+	b.WriteString(`upstream_connect_time:`)
+	b.WriteDashOrStringValue(r.upstreamConnectTimes())
+	b.WriteString(`upstream_header_time:`)
+	b.WriteDashOrStringValue(r.upstreamHeaderTimes())
+	b.AppendSpaces(false)
+	b.WriteString(`upstream_error:`)
+	b.WriteDashOrStringValue(r.upstreamErrors())
+}
+
+func (r *AccessLogRecord) addJSONUpstream(b *jsonBuffer) {
+	if r.LookupTime > 0 {
+		b.WriteFloatField("lookup_time", r.LookupTime.Seconds())
+	}
+	if len(r.UpstreamAttempts) == 0 {
+		return
+	}
+
+	b.key("upstream_attempts")
+	_ = b.WriteByte('[')
+	fields := b.fields
+	for i, a := range r.UpstreamAttempts {
+		if i > 0 {
+			_ = b.WriteByte(',')
+		}
+		_ = b.WriteByte('{')
+		b.fields = 0
+		b.WriteStringField("addr", a.Addr)
+		if a.RouteService {
+			b.key("route_service")
+			_, _ = b.WriteString("true")
+		}
+		b.WriteTimeField("started_at", a.StartedAt)
+		b.WriteFloatField("connect_time", a.ConnectTime.Seconds())
+		if a.HeaderTime > 0 {
+			b.WriteFloatField("time_to_first_byte", a.HeaderTime.Seconds())
+		}
+		b.WriteStringField("error", a.Error)
+		_ = b.WriteByte('}')
+	}
+	b.fields = fields
+	_ = b.WriteByte(']')
+}
+
+// joinAttempts joins a value of every upstream attempt in the way of nginx
+// $upstream_ variables, with "-" for attempts without one
+func (r *AccessLogRecord) joinAttempts(value func(a *UpstreamAttempt) string) string {
+	if len(r.UpstreamAttempts) == 0 {
+		return ""
+	}
+
+	values := make([]string, len(r.UpstreamAttempts))
+	for i := range r.UpstreamAttempts {
+		values[i] = value(&r.UpstreamAttempts[i])
+		if values[i] == "" {
+			values[i] = "-"
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// upstreamAddrs returns the address of every attempt, or the address of the
+// endpoint for requests that were not proxied by attempts, such as websockets
+func (r *AccessLogRecord) upstreamAddrs() string {
+	if len(r.UpstreamAttempts) == 0 {
+		if r.RouteEndpoint == nil {
+			return ""
+		}
+		return r.RouteEndpoint.CanonicalAddr()
+	}
+	return r.joinAttempts(func(a *UpstreamAttempt) string { return a.Addr })
+}
+
+func (r *AccessLogRecord) upstreamConnectTimes() string {
+	return r.joinAttempts(func(a *UpstreamAttempt) string { return formatSeconds(a.ConnectTime) })
+}
+
+func (r *AccessLogRecord) upstreamHeaderTimes() string {
+	return r.joinAttempts(func(a *UpstreamAttempt) string {
+		if a.HeaderTime == 0 {
+			return ""
+		}
+		return formatSeconds(a.HeaderTime)
+	})
+}
+
+func (r *AccessLogRecord) upstreamErrors() string {
+	return r.joinAttempts(func(a *UpstreamAttempt) string { return a.Error })
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,92 @@
+package access_log
+
+import (
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+)
+
+var defaultSink = config.AccessLogSink{
+	BufferSize:     1024,
+	OverflowPolicy: config.ACCESS_LOG_OVERFLOW_BLOCK,
+}
+
+// accessLogEntry is a formatted record on its way to the sinks
+type accessLogEntry struct {
+	appID string
+	line  []byte
+}
+
+// sink writes entries to one output from its own goroutine, so that a slow
+// output only fills its own buffer.
+type sink struct {
+	name    string
+	policy  string
+	entries chan accessLogEntry
+	write   func(e accessLogEntry)
+
+	// appsOnly skips entries without an application ID
+	appsOnly bool
+}
+
+func newSink(name string, c config.AccessLogSink, write func(e accessLogEntry)) *sink {
+	return &sink{
+		name:    name,
+		policy:  c.OverflowPolicy,
+		entries: make(chan accessLogEntry, c.BufferSize),
+		write:   write,
+	}
+}
+
+// enqueue buffers e, applying the overflow policy when the buffer is full.
+// Dropped and delayed entries are captured by r unless it is nil.
+func (s *sink) enqueue(e accessLogEntry, r reporter.AccessLogReporter) {
+	select {
+	case s.entries <- e:
+		return
+	default:
+	}
+
+	switch s.policy {
+	case config.ACCESS_LOG_OVERFLOW_DROP_NEWEST:
+		s.captureDropped(r)
+	case config.ACCESS_LOG_OVERFLOW_DROP_OLDEST:
+		for {
+			select {
+			case <-s.entries:
+				s.captureDropped(r)
+			default:
+			}
+			select {
+			case s.entries <- e:
+				return
+			default:
+			}
+		}
+	default:
+		s.captureDelayed(r)
+		s.entries <- e
+	}
+}
+
+func (s *sink) run(stopCh <-chan struct{}) {
+	for {
+		select {
+		case e := <-s.entries:
+			s.write(e)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (s *sink) captureDropped(r reporter.AccessLogReporter) {
+	if r != nil {
+		r.CaptureAccessLogDropped(s.name)
+	}
+}
+
+func (s *sink) captureDelayed(r reporter.AccessLogReporter) {
+	if r != nil {
+		r.CaptureAccessLogDelayed(s.name)
+	}
+}
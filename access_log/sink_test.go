@@ -0,0 +1,147 @@
+package access_log_test
+
+import (
+	"fmt"
+	"sync"
+
+	. "code.cloudfoundry.org/gorouter/access_log"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// stalledWriter blocks every write until it is released
+type stalledWriter struct {
+	release chan struct{}
+
+	mu    sync.Mutex
+	lines []string
+}
+
+func newStalledWriter() *stalledWriter {
+	return &stalledWriter{release: make(chan struct{})}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+func (w *stalledWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.lines...)
+}
+
+var _ = Describe("Access log sinks", func() {
+	var (
+		accessLogger *FileAndLoggregatorAccessLogger
+		reporter     *fakes.FakeAccessLogReporter
+		stalled      *stalledWriter
+		healthy      *stalledWriter
+	)
+
+	logHost := func(i int) {
+		record := CreateAccessLogRecord()
+		record.Request.Host = fmt.Sprintf("host-%d", i)
+		accessLogger.Log(*record)
+	}
+
+	hosts := func(lines []string) []string {
+		var hosts []string
+		for _, line := range lines {
+			var host string
+			fmt.Sscanf(line, "%s", &host)
+			hosts = append(hosts, host)
+		}
+		return hosts
+	}
+
+	BeforeEach(func() {
+		reporter = new(fakes.FakeAccessLogReporter)
+		stalled = newStalledWriter()
+		healthy = newStalledWriter()
+		close(healthy.release)
+
+		accessLogger = NewFileAndLoggregatorAccessLogger(lagertest.NewTestLogger("test"), nil, "")
+		accessLogger.Reporter = reporter
+		accessLogger.AddWriter("healthy", config.AccessLogSink{BufferSize: 100, OverflowPolicy: config.ACCESS_LOG_OVERFLOW_BLOCK}, healthy)
+	})
+
+	AfterEach(func() {
+		accessLogger.Stop()
+	})
+
+	Context("when a sink drops the newest records", func() {
+		BeforeEach(func() {
+			accessLogger.AddWriter("stalled", config.AccessLogSink{BufferSize: 2, OverflowPolicy: config.ACCESS_LOG_OVERFLOW_DROP_NEWEST}, stalled)
+			go accessLogger.Run()
+		})
+
+		It("does not hold up the other sinks", func() {
+			for i := 0; i < 20; i++ {
+				logHost(i)
+			}
+
+			Eventually(healthy.Lines).Should(HaveLen(20))
+			Expect(reporter.CaptureAccessLogDroppedCallCount()).To(BeNumerically(">=", 17))
+			Expect(reporter.CaptureAccessLogDroppedArgsForCall(0)).To(Equal("stalled"))
+			Expect(reporter.CaptureAccessLogDelayedCallCount()).To(Equal(0))
+
+			close(stalled.release)
+			Eventually(stalled.Lines).Should(HaveLen(20 - reporter.CaptureAccessLogDroppedCallCount()))
+			Expect(hosts(stalled.Lines())).To(ContainElement("host-1"))
+			Expect(hosts(stalled.Lines())).ToNot(ContainElement("host-19"))
+		})
+	})
+
+	Context("when a sink drops the oldest records", func() {
+		BeforeEach(func() {
+			accessLogger.AddWriter("stalled", config.AccessLogSink{BufferSize: 2, OverflowPolicy: config.ACCESS_LOG_OVERFLOW_DROP_OLDEST}, stalled)
+		})
+
+		It("keeps the newest records", func() {
+			for i := 0; i < 5; i++ {
+				logHost(i)
+			}
+			Expect(reporter.CaptureAccessLogDroppedCallCount()).To(Equal(3))
+
+			close(stalled.release)
+			go accessLogger.Run()
+			Eventually(stalled.Lines).Should(HaveLen(2))
+			Expect(hosts(stalled.Lines())).To(Equal([]string{"host-3", "host-4"}))
+		})
+	})
+
+	Context("when a sink blocks", func() {
+		BeforeEach(func() {
+			accessLogger.AddWriter("stalled", config.AccessLogSink{BufferSize: 1, OverflowPolicy: config.ACCESS_LOG_OVERFLOW_BLOCK}, stalled)
+		})
+
+		It("waits for room in the buffer and counts the delay", func() {
+			logHost(0)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				logHost(1)
+			}()
+			Eventually(reporter.CaptureAccessLogDelayedCallCount).Should(Equal(1))
+			Expect(reporter.CaptureAccessLogDelayedArgsForCall(0)).To(Equal("stalled"))
+			Consistently(done).ShouldNot(BeClosed())
+
+			close(stalled.release)
+			go accessLogger.Run()
+			Eventually(done).Should(BeClosed())
+			Eventually(stalled.Lines).Should(HaveLen(2))
+			Expect(reporter.CaptureAccessLogDroppedCallCount()).To(Equal(0))
+		})
+	})
+})
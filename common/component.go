@@ -88,6 +88,7 @@ type VcapComponent struct {
 	Healthz    *health.Healthz `json:"-"`
 	Health     negroni.Handler
 	InfoRoutes map[string]json.Marshaler `json:"-"`
+	Handlers   map[string]http.Handler   `json:"-"`
 	Logger     lager.Logger              `json:"-"`
 
 	listener net.Listener
@@ -243,6 +244,10 @@ func (c *VcapComponent) ListenAndServe() {
 		})
 	}
 
+	for path, handler := range c.Handlers {
+		hs.Handle(path, handler)
+	}
+
 	f := func(user, password string) bool {
 		return user == c.Varz.Credentials[0] && password == c.Varz.Credentials[1]
 	}
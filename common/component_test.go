@@ -119,6 +119,26 @@ var _ = Describe("Component", func() {
 		Expect(body).To(Equal(`{"key":"value"}` + "\n"))
 	})
 
+	It("serves the additional handlers", func() {
+		component.Handlers = map[string]http.Handler{
+			"/metrics": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("metric 1\n"))
+			}),
+		}
+		serveComponent(component)
+
+		req := buildGetRequest(component, "/metrics")
+		code, _, _ := doGetRequest(req)
+		Expect(code).To(Equal(401))
+
+		req.SetBasicAuth("username", "password")
+		code, header, body := doGetRequest(req)
+		Expect(code).To(Equal(200))
+		Expect(header.Get("Content-Type")).To(Equal("text/plain"))
+		Expect(body).To(Equal("metric 1\n"))
+	})
+
 	It("updates the uptime statistic", func() {
 		stringMap := make(map[string]interface{})
 		path := "/varz"
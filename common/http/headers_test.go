@@ -18,56 +18,6 @@ const uuid_regex = `^[[:xdigit:]]{8}(-[[:xdigit:]]{4}){3}-[[:xdigit:]]{12}$`
 const b3_id_regex = `^[[:xdigit:]]{16}$`
 
 var _ = Describe("Headers", func() {
-	Describe("SetVcapRequestIdHeader", func() {
-		var (
-			logger lager.Logger
-			req    *http.Request
-		)
-		BeforeEach(func() {
-			logger = lagertest.NewTestLogger("headers-test")
-			var err error
-			req, err = http.NewRequest("GET", "test.endpoint", nil)
-			Expect(err).ToNot(HaveOccurred())
-		})
-		JustBeforeEach(func() {
-			commonhttp.SetVcapRequestIdHeader(req, logger)
-		})
-
-		Context("when X-Vcap-Request-Id is not set", func() {
-			It("sets the X-Vcap-Request-Id header", func() {
-				reqID := req.Header.Get(commonhttp.VcapRequestIdHeader)
-				Expect(reqID).ToNot(BeEmpty())
-				Expect(reqID).To(MatchRegexp(uuid_regex))
-			})
-
-			It("logs the header", func() {
-				reqID := req.Header.Get(commonhttp.VcapRequestIdHeader)
-				Expect(logger).To(gbytes.Say("vcap-request-id-header-set"))
-				Expect(logger).To(gbytes.Say(reqID))
-			})
-
-		})
-
-		Context("when X-Vcap-Request-Id is set", func() {
-			BeforeEach(func() {
-				req.Header.Set(commonhttp.VcapRequestIdHeader, "BOGUS-HEADER")
-			})
-
-			It("overwrites the X-Vcap-Request-Id header", func() {
-				reqID := req.Header.Get(commonhttp.VcapRequestIdHeader)
-				Expect(reqID).ToNot(BeEmpty())
-				Expect(reqID).ToNot(Equal("BOGUS-HEADER"))
-				Expect(reqID).To(MatchRegexp(uuid_regex))
-			})
-
-			It("logs the header", func() {
-				reqID := req.Header.Get(commonhttp.VcapRequestIdHeader)
-				Expect(logger).To(gbytes.Say("vcap-request-id-header-set"))
-				Expect(logger).To(gbytes.Say(reqID))
-			})
-		})
-	})
-
 	Describe("SetTraceHeaders", func() {
 		var respWriter http.ResponseWriter
 
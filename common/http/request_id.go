@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/common/uuid"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	requestIdSeparator = "::"
+	maxRequestIdLength = 128
+)
+
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:=+/@-]{0,127}$`)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of the request that carries the request ID
+// chosen by the router.
+func WithRequestId(request *http.Request, id string) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), requestIdKey{}, id))
+}
+
+// RequestId returns the request ID chosen by the router, or the
+// X-Vcap-Request-Id header of requests that did not pass through
+// WithRequestId.
+func RequestId(request *http.Request) string {
+	if id, ok := request.Context().Value(requestIdKey{}).(string); ok {
+		return id
+	}
+	return request.Header.Get(VcapRequestIdHeader)
+}
+
+// ValidRequestId reports whether an incoming request ID is well-formed: at
+// most 128 characters of letters, digits and ._:=+/@- punctuation.
+func ValidRequestId(id string) bool {
+	return validRequestId.MatchString(id)
+}
+
+// RequestIdPolicy decides which request ID is passed to backends in Header.
+// Mode is one of the config.REQUEST_ID_* modes. In append mode only the first
+// ID of the incoming request ID is kept, so that a request passing through
+// several routers carries the original ID and the ID of the last router.
+type RequestIdPolicy struct {
+	Header string
+	Mode   string
+}
+
+// HeaderName returns the request ID header, X-Vcap-Request-Id by default.
+func (p RequestIdPolicy) HeaderName() string {
+	if p.Header == "" {
+		return VcapRequestIdHeader
+	}
+	return http.CanonicalHeaderKey(p.Header)
+}
+
+// SetRequestIdHeader sets the request ID header according to the policy and
+// returns the ID. X-Vcap-Request-Id is always set to a UUID generated by the
+// router when the policy uses another header.
+func (p RequestIdPolicy) SetRequestIdHeader(request *http.Request, logger lager.Logger) string {
+	header := p.HeaderName()
+
+	guid, err := uuid.GenerateUUID()
+	if err != nil {
+		if logger != nil {
+			logger.Error("failed-to-generate-request-id", err)
+		}
+		return request.Header.Get(header)
+	}
+
+	id := guid
+	incoming := request.Header.Get(header)
+	if ValidRequestId(incoming) {
+		switch p.Mode {
+		case config.REQUEST_ID_PRESERVE:
+			id = incoming
+		case config.REQUEST_ID_APPEND:
+			id = appendRequestId(incoming, guid)
+		}
+	}
+
+	request.Header.Set(header, id)
+	if header != VcapRequestIdHeader {
+		request.Header.Set(VcapRequestIdHeader, guid)
+	}
+	if logger != nil {
+		logger.Debug("vcap-request-id-header-set", lager.Data{header: id})
+	}
+	return id
+}
+
+// appendRequestId appends guid to the first ID of incoming. The original ID is
+// kept on its own when the result would not be a valid request ID.
+func appendRequestId(incoming, guid string) string {
+	original := incoming
+	if i := strings.Index(incoming, requestIdSeparator); i > 0 {
+		original = incoming[:i]
+	}
+	if len(original)+len(requestIdSeparator)+len(guid) > maxRequestIdLength {
+		return original
+	}
+	return original + requestIdSeparator + guid
+}
@@ -0,0 +1,146 @@
+package http_test
+
+import (
+	"net/http"
+	"strings"
+
+	commonhttp "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("RequestIdPolicy", func() {
+	var (
+		logger lager.Logger
+		req    *http.Request
+		policy commonhttp.RequestIdPolicy
+		id     string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("request-id-test")
+		var err error
+		req, err = http.NewRequest("GET", "test.endpoint", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set(commonhttp.VcapRequestIdHeader, "lb-1234")
+		policy = commonhttp.RequestIdPolicy{}
+	})
+
+	JustBeforeEach(func() {
+		id = policy.SetRequestIdHeader(req, logger)
+	})
+
+	Context("when the mode is generate", func() {
+		BeforeEach(func() {
+			policy.Mode = config.REQUEST_ID_GENERATE
+		})
+
+		It("replaces the incoming id", func() {
+			Expect(id).To(MatchRegexp(uuid_regex))
+			Expect(req.Header.Get(commonhttp.VcapRequestIdHeader)).To(Equal(id))
+			Expect(logger).To(gbytes.Say("vcap-request-id-header-set"))
+		})
+	})
+
+	Context("when the mode is preserve", func() {
+		BeforeEach(func() {
+			policy.Mode = config.REQUEST_ID_PRESERVE
+		})
+
+		It("keeps a well-formed incoming id", func() {
+			Expect(id).To(Equal("lb-1234"))
+			Expect(req.Header.Get(commonhttp.VcapRequestIdHeader)).To(Equal("lb-1234"))
+		})
+
+		Context("when the incoming id is malformed", func() {
+			BeforeEach(func() {
+				req.Header.Set(commonhttp.VcapRequestIdHeader, "<script>")
+			})
+
+			It("generates a new id", func() {
+				Expect(id).To(MatchRegexp(uuid_regex))
+			})
+		})
+
+		Context("when there is no incoming id", func() {
+			BeforeEach(func() {
+				req.Header.Del(commonhttp.VcapRequestIdHeader)
+			})
+
+			It("generates a new id", func() {
+				Expect(id).To(MatchRegexp(uuid_regex))
+			})
+		})
+	})
+
+	Context("when the mode is append", func() {
+		BeforeEach(func() {
+			policy.Mode = config.REQUEST_ID_APPEND
+		})
+
+		It("appends the router id to the incoming id", func() {
+			parts := strings.Split(id, "::")
+			Expect(parts).To(HaveLen(2))
+			Expect(parts[0]).To(Equal("lb-1234"))
+			Expect(parts[1]).To(MatchRegexp(uuid_regex))
+			Expect(req.Header.Get(commonhttp.VcapRequestIdHeader)).To(Equal(id))
+		})
+
+		It("keeps the original id and the latest router id across hops", func() {
+			for hop := 0; hop < 5; hop++ {
+				req.Header.Set(commonhttp.VcapRequestIdHeader, id)
+				id = policy.SetRequestIdHeader(req, logger)
+
+				parts := strings.Split(id, "::")
+				Expect(parts).To(HaveLen(2))
+				Expect(parts[0]).To(Equal("lb-1234"))
+				Expect(parts[1]).To(MatchRegexp(uuid_regex))
+				Expect(commonhttp.ValidRequestId(id)).To(BeTrue())
+			}
+		})
+
+		Context("when the incoming id leaves no room for the router id", func() {
+			BeforeEach(func() {
+				req.Header.Set(commonhttp.VcapRequestIdHeader, strings.Repeat("a", 100))
+			})
+
+			It("keeps the incoming id", func() {
+				Expect(id).To(Equal(strings.Repeat("a", 100)))
+			})
+		})
+	})
+
+	Context("when another header is configured", func() {
+		BeforeEach(func() {
+			policy.Mode = config.REQUEST_ID_PRESERVE
+			policy.Header = "x-request-id"
+			req.Header.Set("X-Request-Id", "abc.123")
+		})
+
+		It("uses that header and still sets X-Vcap-Request-Id", func() {
+			Expect(policy.HeaderName()).To(Equal("X-Request-Id"))
+			Expect(id).To(Equal("abc.123"))
+			Expect(req.Header.Get("X-Request-Id")).To(Equal("abc.123"))
+			Expect(req.Header.Get(commonhttp.VcapRequestIdHeader)).To(MatchRegexp(uuid_regex))
+		})
+	})
+
+	It("keeps the chosen id on the request", func() {
+		Expect(commonhttp.RequestId(req)).To(Equal(id))
+
+		req.Header.Set("X-Request-Id", "abc.123")
+		req = commonhttp.WithRequestId(req, "abc.123")
+		Expect(commonhttp.RequestId(req)).To(Equal("abc.123"))
+	})
+
+	It("only accepts well-formed ids", func() {
+		Expect(commonhttp.ValidRequestId("Root=1-5759e988-bd862e3fe1be46a994272793")).To(BeTrue())
+		Expect(commonhttp.ValidRequestId("")).To(BeFalse())
+		Expect(commonhttp.ValidRequestId("a b")).To(BeFalse())
+		Expect(commonhttp.ValidRequestId(strings.Repeat("a", 129))).To(BeFalse())
+	})
+})
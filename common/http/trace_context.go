@@ -0,0 +1,303 @@
+package http
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/common/secure"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	B3Header             = "B3"
+	B3SampledHeader      = "X-B3-Sampled"
+	B3FlagsHeader        = "X-B3-Flags"
+	W3CTraceParentHeader = "Traceparent"
+	W3CTraceStateHeader  = "Tracestate"
+
+	// TraceFormatB3 propagates trace context in the X-B3-* headers.
+	TraceFormatB3 = "b3"
+	// TraceFormatB3Single propagates trace context in the b3 header.
+	TraceFormatB3Single = "b3-single"
+	// TraceFormatW3C propagates trace context in the traceparent header.
+	TraceFormatW3C = "w3c"
+	// TraceFormatBoth propagates trace context in the X-B3-* and the
+	// traceparent headers.
+	TraceFormatBoth = "both"
+
+	w3cVersion = "00"
+)
+
+var TraceFormats = []string{TraceFormatB3, TraceFormatB3Single, TraceFormatW3C, TraceFormatBoth}
+
+// TraceContext identifies the router's span within a trace. Sampled is "1"
+// or "0" when a sampling decision was propagated, and empty otherwise.
+type TraceContext struct {
+	TraceId      string
+	SpanId       string
+	ParentSpanId string
+	Sampled      string
+}
+
+// SetTraceContextHeaders starts a router span for the request and writes it
+// to the headers of the given format. The trace is continued when the
+// request carries trace context in any supported format, so a trace started
+// with B3 headers continues with traceparent and vice versa.
+func SetTraceContextHeaders(request *http.Request, format string, logger lager.Logger) (TraceContext, error) {
+	tc, err := NewTraceContext(request.Header, format, logger)
+	if err != nil {
+		return tc, err
+	}
+
+	WriteTraceContextHeaders(request.Header, format, tc)
+	return tc, nil
+}
+
+// NewTraceContext returns a new router span continuing the trace context
+// found in header, or starting a new trace.
+func NewTraceContext(header http.Header, format string, logger lager.Logger) (TraceContext, error) {
+	parent, ok := extractTraceContext(header, format)
+
+	spanId, err := randomHexId(8)
+	if err != nil {
+		return TraceContext{}, err
+	}
+
+	tc := TraceContext{SpanId: spanId, Sampled: parent.Sampled}
+	if ok {
+		tc.TraceId = parent.TraceId
+		tc.ParentSpanId = parent.SpanId
+		if logger != nil {
+			logger.Debug("b3-trace-id-header-exists", lager.Data{B3TraceIdHeader: parent.TraceId})
+		}
+		return tc, nil
+	}
+
+	// W3C trace ids are 128 bit; B3 keeps the 64 bit ids it always used,
+	// with the root span id equal to the trace id.
+	if format == TraceFormatB3 || format == TraceFormatB3Single {
+		tc.TraceId = spanId
+		return tc, nil
+	}
+
+	tc.TraceId, err = randomHexId(16)
+	if err != nil {
+		return TraceContext{}, err
+	}
+	return tc, nil
+}
+
+// WriteTraceContextHeaders writes the span to the headers of the given
+// format.
+func WriteTraceContextHeaders(header http.Header, format string, tc TraceContext) {
+	switch format {
+	case TraceFormatB3Single:
+		writeB3Single(header, tc)
+	case TraceFormatW3C:
+		writeW3C(header, tc)
+	case TraceFormatBoth:
+		writeB3(header, tc)
+		writeW3C(header, tc)
+	default:
+		writeB3(header, tc)
+	}
+}
+
+// WritesB3Headers reports whether the format propagates trace context in the
+// X-B3-* headers.
+func WritesB3Headers(format string) bool {
+	return format != TraceFormatB3Single && format != TraceFormatW3C
+}
+
+// NewSpanId returns a random 64 bit span id.
+func NewSpanId() (string, error) {
+	return randomHexId(8)
+}
+
+func extractTraceContext(header http.Header, format string) (TraceContext, bool) {
+	var extractors []func(http.Header) (TraceContext, bool)
+
+	// Legacy B3 mode continues traces with any non-empty X-B3 ids; every
+	// other mode needs ids that can be translated to another format.
+	b3 := func(h http.Header) (TraceContext, bool) { return parseB3(h, format != TraceFormatB3) }
+
+	switch format {
+	case TraceFormatW3C:
+		extractors = append(extractors, parseW3C, b3, parseB3Single)
+	case TraceFormatB3Single:
+		extractors = append(extractors, parseB3Single, b3, parseW3C)
+	default:
+		extractors = append(extractors, b3, parseB3Single, parseW3C)
+	}
+
+	var sampled string
+	for _, extract := range extractors {
+		tc, ok := extract(header)
+		if ok {
+			return tc, true
+		}
+		if sampled == "" {
+			sampled = tc.Sampled
+		}
+	}
+	return TraceContext{Sampled: sampled}, false
+}
+
+func parseB3(header http.Header, strict bool) (TraceContext, bool) {
+	tc := TraceContext{
+		TraceId:      header.Get(B3TraceIdHeader),
+		SpanId:       header.Get(B3SpanIdHeader),
+		ParentSpanId: header.Get(B3ParentSpanIdHeader),
+	}
+
+	switch strings.ToLower(header.Get(B3SampledHeader)) {
+	case "1", "true":
+		tc.Sampled = "1"
+	case "0", "false":
+		tc.Sampled = "0"
+	}
+	if header.Get(B3FlagsHeader) == "1" {
+		tc.Sampled = "1"
+	}
+
+	if tc.TraceId == "" || tc.SpanId == "" {
+		return tc, false
+	}
+	if strict && (!validTraceId(tc.TraceId) || !validHexId(tc.SpanId, 16)) {
+		return tc, false
+	}
+	return tc, true
+}
+
+// parseB3Single parses {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId},
+// where the last two fields are optional, or a bare sampling state.
+func parseB3Single(header http.Header) (TraceContext, bool) {
+	value := header.Get(B3Header)
+	if value == "" {
+		return TraceContext{}, false
+	}
+
+	fields := strings.Split(value, "-")
+	if len(fields) == 1 {
+		return TraceContext{Sampled: b3SamplingState(fields[0])}, false
+	}
+	if len(fields) > 4 || !validTraceId(fields[0]) || !validHexId(fields[1], 16) {
+		return TraceContext{}, false
+	}
+
+	tc := TraceContext{TraceId: fields[0], SpanId: fields[1]}
+	if len(fields) > 2 {
+		tc.Sampled = b3SamplingState(fields[2])
+	}
+	if len(fields) > 3 && validHexId(fields[3], 16) {
+		tc.ParentSpanId = fields[3]
+	}
+	return tc, true
+}
+
+func b3SamplingState(state string) string {
+	switch state {
+	case "1", "d":
+		return "1"
+	case "0":
+		return "0"
+	}
+	return ""
+}
+
+// parseW3C parses a traceparent header of the form
+// {version}-{trace-id}-{parent-id}-{trace-flags}.
+func parseW3C(header http.Header) (TraceContext, bool) {
+	value := strings.TrimSpace(header.Get(W3CTraceParentHeader))
+	fields := strings.Split(value, "-")
+	if len(fields) < 4 {
+		return TraceContext{}, false
+	}
+
+	version := fields[0]
+	if !validHexId(version, 2) || version == "ff" || (version == w3cVersion && len(fields) != 4) {
+		return TraceContext{}, false
+	}
+	if !validHexId(fields[1], 32) || !validHexId(fields[2], 16) || !validHexId(fields[3], 2) {
+		return TraceContext{}, false
+	}
+
+	flags, _ := hex.DecodeString(fields[3])
+	tc := TraceContext{TraceId: fields[1], SpanId: fields[2], Sampled: "0"}
+	if flags[0]&1 == 1 {
+		tc.Sampled = "1"
+	}
+	return tc, true
+}
+
+func writeB3(header http.Header, tc TraceContext) {
+	header.Set(B3TraceIdHeader, tc.TraceId)
+	header.Set(B3SpanIdHeader, tc.SpanId)
+	if tc.ParentSpanId != "" {
+		header.Set(B3ParentSpanIdHeader, tc.ParentSpanId)
+	}
+	if tc.Sampled != "" {
+		header.Set(B3SampledHeader, tc.Sampled)
+	}
+}
+
+func writeB3Single(header http.Header, tc TraceContext) {
+	value := tc.TraceId + "-" + tc.SpanId
+	if tc.Sampled != "" {
+		value += "-" + tc.Sampled
+		if tc.ParentSpanId != "" {
+			value += "-" + tc.ParentSpanId
+		}
+	}
+	header.Set(B3Header, value)
+}
+
+// writeW3C writes traceparent, widening 64 bit B3 trace ids to 128 bit.
+// Requests without a sampling decision are marked as sampled. tracestate is
+// passed on untouched.
+func writeW3C(header http.Header, tc TraceContext) {
+	flags := "01"
+	if tc.Sampled == "0" {
+		flags = "00"
+	}
+
+	traceId := tc.TraceId
+	if len(traceId) == 16 {
+		traceId = strings.Repeat("0", 16) + traceId
+	}
+	header.Set(W3CTraceParentHeader, fmt.Sprintf("%s-%s-%s-%s", w3cVersion, traceId, tc.SpanId, flags))
+}
+
+func validTraceId(id string) bool {
+	return validHexId(id, 16) || validHexId(id, 32)
+}
+
+// validHexId reports whether id is lower case hex of the given length and,
+// for trace and span ids, not all zeros.
+func validHexId(id string, length int) bool {
+	if len(id) != length {
+		return false
+	}
+
+	zero := true
+	for _, c := range id {
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f':
+			zero = false
+		default:
+			return false
+		}
+	}
+	return length == 2 || !zero
+}
+
+func randomHexId(size uint) (string, error) {
+	b, err := secure.RandomBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
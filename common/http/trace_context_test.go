@@ -0,0 +1,98 @@
+package http_test
+
+import (
+	"net/http"
+
+	commonhttp "code.cloudfoundry.org/gorouter/common/http"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SetTraceContextHeaders", func() {
+	var req *http.Request
+
+	BeforeEach(func() {
+		var err error
+		req, err = http.NewRequest("GET", "test.endpoint", nil)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Context("with the w3c format", func() {
+		It("starts a sampled trace with a 128 bit trace id", func() {
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatW3C, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tc.TraceId).To(MatchRegexp(`^[[:xdigit:]]{32}$`))
+			Expect(tc.SpanId).To(MatchRegexp(b3_id_regex))
+			Expect(tc.ParentSpanId).To(BeEmpty())
+			Expect(req.Header.Get("traceparent")).To(Equal("00-" + tc.TraceId + "-" + tc.SpanId + "-01"))
+		})
+
+		It("ignores invalid traceparent headers", func() {
+			for _, tp := range []string{
+				"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+				"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+				"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+				"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			} {
+				req.Header.Set("traceparent", tp)
+				tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatW3C, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tc.TraceId).NotTo(Equal("4bf92f3577b34da6a3ce929d0e0e4736"), tp)
+			}
+		})
+
+		It("accepts future versions with extra fields", func() {
+			req.Header.Set("traceparent", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatW3C, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tc.TraceId).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(tc.ParentSpanId).To(Equal("00f067aa0ba902b7"))
+			Expect(req.Header.Get("traceparent")).To(HavePrefix("00-"))
+		})
+
+		It("does not translate malformed B3 ids", func() {
+			req.Header.Set(commonhttp.B3TraceIdHeader, "Bogus Value")
+			req.Header.Set(commonhttp.B3SpanIdHeader, "Span Value")
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatW3C, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tc.TraceId).To(MatchRegexp(`^[[:xdigit:]]{32}$`))
+		})
+	})
+
+	Context("with both formats", func() {
+		It("propagates a B3 sampling decision to traceparent", func() {
+			req.Header.Set(commonhttp.B3TraceIdHeader, "4bf92f3577b34da6a3ce929d0e0e4736")
+			req.Header.Set(commonhttp.B3SpanIdHeader, "00f067aa0ba902b7")
+			req.Header.Set(commonhttp.B3SampledHeader, "0")
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatBoth, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tc.Sampled).To(Equal("0"))
+			Expect(req.Header.Get(commonhttp.B3ParentSpanIdHeader)).To(Equal("00f067aa0ba902b7"))
+			Expect(req.Header.Get("traceparent")).To(Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-" + tc.SpanId + "-00"))
+		})
+	})
+
+	Context("with the b3 single header format", func() {
+		It("translates traceparent", func() {
+			req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatB3Single, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(req.Header.Get("b3")).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736-" + tc.SpanId + "-1-00f067aa0ba902b7"))
+		})
+
+		It("omits the parent span id without a sampling decision", func() {
+			req.Header.Set("b3", "4bf92f3577b34da6-00f067aa0ba902b7")
+			tc, err := commonhttp.SetTraceContextHeaders(req, commonhttp.TraceFormatB3Single, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tc.ParentSpanId).To(Equal("00f067aa0ba902b7"))
+			Expect(req.Header.Get("b3")).To(Equal("4bf92f3577b34da6-" + tc.SpanId))
+		})
+	})
+})
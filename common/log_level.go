@@ -0,0 +1,255 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+var logLevelNames = map[lager.LogLevel]string{
+	lager.DEBUG: "debug",
+	lager.INFO:  "info",
+	lager.ERROR: "error",
+	lager.FATAL: "fatal",
+}
+
+// ParseLogLevel returns the lager level of a name such as "info"
+func ParseLogLevel(name string) (lager.LogLevel, error) {
+	for level, n := range logLevelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return lager.DEBUG, fmt.Errorf("unknown log level: %s", name)
+}
+
+// LogLevel is a lager sink whose minimum level can be changed at runtime
+// through its handler. A change can revert to the default level after a TTL,
+// and can let the debug logs of requests to one host or with one request ID
+// through, matched against the Host and RequestId data of request loggers.
+// More sinks with their own default level can be added with AddSink; a change
+// applies to all of them.
+type LogLevel struct {
+	defaultLevel lager.LogLevel
+	sinks        []leveledSink
+	logger       lager.Logger
+
+	mu        sync.RWMutex
+	level     lager.LogLevel
+	changed   bool
+	host      *regexp.Regexp
+	requestID *regexp.Regexp
+	revertAt  time.Time
+	timer     *time.Timer
+	changes   uint64
+}
+
+// leveledSink is a sink with the level it logs at while the level is not
+// changed
+type leveledSink struct {
+	sink         lager.Sink
+	defaultLevel func() lager.LogLevel
+}
+
+// logLevelState is the JSON read from and written by the handler
+type logLevelState struct {
+	Level          string     `json:"level"`
+	DefaultLevel   string     `json:"default_level,omitempty"`
+	TTL            string     `json:"ttl,omitempty"`
+	RevertAt       *time.Time `json:"revert_at,omitempty"`
+	DebugHost      string     `json:"debug_host,omitempty"`
+	DebugRequestID string     `json:"debug_request_id,omitempty"`
+}
+
+// NewLogLevel returns a LogLevel that logs to sink at level by default. The
+// sink may be nil when every sink is added with AddSink.
+func NewLogLevel(sink lager.Sink, level lager.LogLevel, logger lager.Logger) *LogLevel {
+	l := &LogLevel{
+		defaultLevel: level,
+		level:        level,
+		logger:       logger,
+	}
+	if sink != nil {
+		l.AddSink(sink, func() lager.LogLevel { return level })
+	}
+	return l
+}
+
+// AddSink passes logs to sink too, at the level returned by defaultLevel
+// until the level is changed. The debug patterns apply to it as well. It must
+// be called before the LogLevel is registered and its handler is served.
+func (l *LogLevel) AddSink(sink lager.Sink, defaultLevel func() lager.LogLevel) {
+	l.sinks = append(l.sinks, leveledSink{sink: sink, defaultLevel: defaultLevel})
+}
+
+func (l *LogLevel) Log(log lager.LogFormat) {
+	l.mu.RLock()
+	level, changed := l.level, l.changed
+	matches := l.matches(log.Data)
+	l.mu.RUnlock()
+
+	for _, s := range l.sinks {
+		if !changed {
+			level = s.defaultLevel()
+		}
+		if log.LogLevel >= level || matches {
+			s.sink.Log(log)
+		}
+	}
+}
+
+func (l *LogLevel) matches(data lager.Data) bool {
+	if l.host != nil {
+		if host, ok := data["Host"].(string); ok && l.host.MatchString(host) {
+			return true
+		}
+	}
+	if l.requestID != nil {
+		if id, ok := data["RequestId"].(string); ok && l.requestID.MatchString(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP returns the current level on GET, changes it on PUT and reverts
+// to the default level on DELETE.
+func (l *LogLevel) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "GET":
+	case "PUT":
+		var state logLevelState
+		if err := json.NewDecoder(req.Body).Decode(&state); err != nil {
+			http.Error(w, fmt.Sprintf("invalid log level: %s", err), http.StatusBadRequest)
+			return
+		}
+		if err := l.set(state); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case "DELETE":
+		l.revert(0)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(l.state())
+}
+
+func (l *LogLevel) set(state logLevelState) error {
+	level := l.defaultLevel
+	if state.Level != "" {
+		var err error
+		level, err = ParseLogLevel(state.Level)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ttl time.Duration
+	if state.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(state.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl: %s", state.TTL)
+		}
+	}
+
+	host, err := compileLogPattern("debug_host", state.DebugHost)
+	if err != nil {
+		return err
+	}
+	requestID, err := compileLogPattern("debug_request_id", state.DebugRequestID)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.level = level
+	l.changed = state.Level != ""
+	l.host = host
+	l.requestID = requestID
+	l.stopTimer()
+	l.changes++
+	if ttl > 0 {
+		change := l.changes
+		l.revertAt = time.Now().Add(ttl)
+		l.timer = time.AfterFunc(ttl, func() { l.revert(change) })
+	}
+	l.mu.Unlock()
+
+	l.logger.Info("log-level-changed", lager.Data{
+		"level":            logLevelNames[level],
+		"ttl":              ttl.String(),
+		"debug_host":       state.DebugHost,
+		"debug_request_id": state.DebugRequestID,
+	})
+	return nil
+}
+
+// revert goes back to the default level. A TTL reverts only the change it was
+// set with, while 0 reverts any change.
+func (l *LogLevel) revert(change uint64) {
+	l.mu.Lock()
+	if change != 0 && change != l.changes {
+		l.mu.Unlock()
+		return
+	}
+	l.level = l.defaultLevel
+	l.changed = false
+	l.host = nil
+	l.requestID = nil
+	l.stopTimer()
+	l.mu.Unlock()
+
+	l.logger.Info("log-level-reverted", lager.Data{"level": logLevelNames[l.defaultLevel]})
+}
+
+// stopTimer must be called with the lock held
+func (l *LogLevel) stopTimer() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	l.revertAt = time.Time{}
+}
+
+func (l *LogLevel) state() logLevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	state := logLevelState{
+		Level:        logLevelNames[l.level],
+		DefaultLevel: logLevelNames[l.defaultLevel],
+	}
+	if !l.revertAt.IsZero() {
+		revertAt := l.revertAt
+		state.RevertAt = &revertAt
+	}
+	if l.host != nil {
+		state.DebugHost = l.host.String()
+	}
+	if l.requestID != nil {
+		state.DebugRequestID = l.requestID.String()
+	}
+	return state
+}
+
+func compileLogPattern(name, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", name, err)
+	}
+	return re, nil
+}
@@ -0,0 +1,206 @@
+package common_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "code.cloudfoundry.org/gorouter/common"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogLevel", func() {
+	var (
+		sink     *lagertest.TestSink
+		logLevel *LogLevel
+		logger   lager.Logger
+	)
+
+	request := func(method, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, "http://status/log_level", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		logLevel.ServeHTTP(w, req)
+
+		var state map[string]interface{}
+		if w.Code == http.StatusOK {
+			Expect(json.Unmarshal(w.Body.Bytes(), &state)).To(Succeed())
+		}
+		return w.Code, state
+	}
+
+	messages := func() []string {
+		var m []string
+		for _, log := range sink.Logs() {
+			m = append(m, log.Message)
+		}
+		return m
+	}
+
+	BeforeEach(func() {
+		sink = lagertest.NewTestSink()
+		logger = lager.NewLogger("test")
+		logLevel = NewLogLevel(sink, lager.INFO, lagertest.NewTestLogger("log-level"))
+		logger.RegisterSink(logLevel)
+	})
+
+	It("logs at the default level", func() {
+		logger.Debug("debug")
+		logger.Info("info")
+		Expect(messages()).To(Equal([]string{"test.info"}))
+	})
+
+	It("returns the current level", func() {
+		code, state := request("GET", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(state).To(Equal(map[string]interface{}{"level": "info", "default_level": "info"}))
+	})
+
+	It("changes the level", func() {
+		code, state := request("PUT", `{"level":"debug"}`)
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(state["level"]).To(Equal("debug"))
+
+		logger.Debug("debug")
+		Expect(messages()).To(Equal([]string{"test.debug"}))
+	})
+
+	It("reverts to the default level", func() {
+		request("PUT", `{"level":"debug"}`)
+		code, state := request("DELETE", "")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(state["level"]).To(Equal("info"))
+
+		logger.Debug("debug")
+		Expect(sink.Logs()).To(BeEmpty())
+	})
+
+	Context("with a ttl", func() {
+		It("reverts after the ttl", func() {
+			code, state := request("PUT", `{"level":"error","ttl":"100ms"}`)
+			Expect(code).To(Equal(http.StatusOK))
+			Expect(state["revert_at"]).ToNot(BeEmpty())
+
+			logger.Info("info")
+			Expect(sink.Logs()).To(BeEmpty())
+
+			Eventually(func() interface{} {
+				_, state := request("GET", "")
+				return state["level"]
+			}).Should(Equal("info"))
+			logger.Info("info")
+			Expect(messages()).To(Equal([]string{"test.info"}))
+		})
+
+		It("does not revert a later change", func() {
+			request("PUT", `{"level":"error","ttl":"50ms"}`)
+			request("PUT", `{"level":"debug"}`)
+
+			Consistently(func() interface{} {
+				_, state := request("GET", "")
+				return state["level"]
+			}, 200*time.Millisecond).Should(Equal("debug"))
+		})
+	})
+
+	Context("with another sink", func() {
+		var other *lagertest.TestSink
+
+		otherMessages := func() []string {
+			var m []string
+			for _, log := range other.Logs() {
+				m = append(m, log.Message)
+			}
+			return m
+		}
+
+		BeforeEach(func() {
+			other = lagertest.NewTestSink()
+			logLevel = NewLogLevel(sink, lager.INFO, lagertest.NewTestLogger("log-level"))
+			logLevel.AddSink(other, func() lager.LogLevel { return lager.ERROR })
+			logger = lager.NewLogger("test")
+			logger.RegisterSink(logLevel)
+		})
+
+		It("logs at its own default level", func() {
+			logger.Info("info")
+			logger.Error("error", nil)
+			Expect(messages()).To(Equal([]string{"test.info", "test.error"}))
+			Expect(otherMessages()).To(Equal([]string{"test.error"}))
+		})
+
+		It("changes its level and reverts it to its own level", func() {
+			request("PUT", `{"level":"debug"}`)
+			logger.Debug("debug")
+			Expect(otherMessages()).To(Equal([]string{"test.debug"}))
+
+			request("DELETE", "")
+			logger.Info("info")
+			Expect(otherMessages()).To(Equal([]string{"test.debug"}))
+		})
+
+		It("logs debug messages of matching requests", func() {
+			request("PUT", `{"debug_host":"^api\\.example\\.com$"}`)
+			logger.Session("request-handler", lager.Data{"Host": "api.example.com"}).Debug("host")
+			logger.Info("info")
+
+			Expect(messages()).To(Equal([]string{"test.request-handler.host", "test.info"}))
+			Expect(otherMessages()).To(Equal([]string{"test.request-handler.host"}))
+		})
+	})
+
+	Context("with debug patterns", func() {
+		BeforeEach(func() {
+			code, _ := request("PUT", `{"debug_host":"^api\\.example\\.com$","debug_request_id":"^abc-"}`)
+			Expect(code).To(Equal(http.StatusOK))
+		})
+
+		It("logs debug messages of matching requests only", func() {
+			logger.Session("request-handler", lager.Data{"Host": "api.example.com", "RequestId": "xyz"}).Debug("host")
+			logger.Session("request-handler", lager.Data{"Host": "other.example.com", "RequestId": "abc-123"}).Debug("request-id")
+			logger.Session("request-handler", lager.Data{"Host": "other.example.com", "RequestId": "xyz"}).Debug("other")
+			logger.Debug("no-request")
+
+			Expect(messages()).To(Equal([]string{"test.request-handler.host", "test.request-handler.request-id"}))
+		})
+
+		It("returns the patterns", func() {
+			_, state := request("GET", "")
+			Expect(state["level"]).To(Equal("info"))
+			Expect(state["debug_host"]).To(Equal(`^api\.example\.com$`))
+			Expect(state["debug_request_id"]).To(Equal("^abc-"))
+		})
+	})
+
+	It("rejects invalid changes", func() {
+		for _, body := range []string{`{"level":"verbose"}`, `{"ttl":"soon"}`, `{"ttl":"-1m"}`, `{"debug_host":"("}`, `not json`} {
+			code, _ := request("PUT", body)
+			Expect(code).To(Equal(http.StatusBadRequest), body)
+		}
+
+		_, state := request("GET", "")
+		Expect(state["level"]).To(Equal("info"))
+	})
+
+	It("rejects other methods", func() {
+		code, _ := request("POST", `{"level":"debug"}`)
+		Expect(code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+var _ = Describe("ParseLogLevel", func() {
+	It("parses level names", func() {
+		Expect(ParseLogLevel("debug")).To(Equal(lager.DEBUG))
+		Expect(ParseLogLevel("fatal")).To(Equal(lager.FATAL))
+	})
+
+	It("fails on unknown names", func() {
+		_, err := ParseLogLevel("verbose")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -6,8 +6,10 @@ import (
 	"net/url"
 
 	"io/ioutil"
+	"regexp"
 	"runtime"
 	"strings"
+	"text/template"
 	"time"
 
 	"code.cloudfoundry.org/localip"
@@ -19,6 +21,12 @@ const LOAD_BALANCE_LC string = "least-connection"
 
 var LoadBalancingStrategies = []string{LOAD_BALANCE_RR, LOAD_BALANCE_LC}
 
+const REQUEST_ID_GENERATE string = "generate"
+const REQUEST_ID_PRESERVE string = "preserve"
+const REQUEST_ID_APPEND string = "append"
+
+var RequestIdModes = []string{REQUEST_ID_GENERATE, REQUEST_ID_PRESERVE, REQUEST_ID_APPEND}
+
 type StatusConfig struct {
 	Host string `yaml:"host"`
 	Port uint16 `yaml:"port"`
@@ -74,12 +82,329 @@ type LoggingConfig struct {
 }
 
 type AccessLog struct {
-	File            string `yaml:"file"`
-	EnableStreaming bool   `yaml:"enable_streaming"`
+	File            string             `yaml:"file"`
+	EnableStreaming bool               `yaml:"enable_streaming"`
+	Format          string             `yaml:"format"`
+	Template        string             `yaml:"template"`
+	Rotation        AccessLogRotation  `yaml:"rotation"`
+	Sinks           AccessLogSinks     `yaml:"sinks"`
+	Rules           []AccessLogRule    `yaml:"rules"`
+	KeepSlowerThan  time.Duration      `yaml:"keep_slower_than"`
+	Redaction       AccessLogRedaction `yaml:"redaction"`
+}
+
+const ACCESS_LOG_REDACT_MASK string = "mask"
+const ACCESS_LOG_REDACT_HASH string = "hash"
+
+var AccessLogRedactModes = []string{ACCESS_LOG_REDACT_MASK, ACCESS_LOG_REDACT_HASH}
+
+// AccessLogRedaction replaces the values of the named query parameters and
+// headers, and the matches of URIPatterns in the request URI, before access
+// log records are written. Mode decides whether they are masked or hashed.
+type AccessLogRedaction struct {
+	Mode        string   `yaml:"mode"`
+	QueryParams []string `yaml:"query_params"`
+	Headers     []string `yaml:"headers"`
+	URIPatterns []string `yaml:"uri_patterns"`
+}
+
+const ACCESS_LOG_RULE_ALWAYS string = "always"
+const ACCESS_LOG_RULE_NEVER string = "never"
+const ACCESS_LOG_RULE_SAMPLE string = "sample"
+
+var AccessLogRuleActions = []string{ACCESS_LOG_RULE_ALWAYS, ACCESS_LOG_RULE_NEVER, ACCESS_LOG_RULE_SAMPLE}
+
+var accessLogStatusClassPattern = regexp.MustCompile(`^[1-5]xx$`)
+
+// AccessLogRule decides whether the requests matching all of its non-empty
+// conditions are logged. StatusClass is written as 2xx, UserAgent matches a
+// substring of the User-Agent header and MinLatency matches requests that
+// took at least that long. SampleRate is the fraction of requests logged by
+// the sample action.
+type AccessLogRule struct {
+	Host        string        `yaml:"host"`
+	PathPrefix  string        `yaml:"path_prefix"`
+	StatusClass string        `yaml:"status_class"`
+	MinLatency  time.Duration `yaml:"min_latency"`
+	UserAgent   string        `yaml:"user_agent"`
+	AppID       string        `yaml:"app_id"`
+	Action      string        `yaml:"action"`
+	SampleRate  float64       `yaml:"sample_rate"`
+}
+
+const ACCESS_LOG_OVERFLOW_BLOCK string = "block"
+const ACCESS_LOG_OVERFLOW_DROP_NEWEST string = "drop_newest"
+const ACCESS_LOG_OVERFLOW_DROP_OLDEST string = "drop_oldest"
+
+var AccessLogOverflowPolicies = []string{ACCESS_LOG_OVERFLOW_BLOCK, ACCESS_LOG_OVERFLOW_DROP_NEWEST, ACCESS_LOG_OVERFLOW_DROP_OLDEST}
+
+// AccessLogSink buffers the records of one access log output. OverflowPolicy
+// decides what happens to a record when the buffer is full.
+type AccessLogSink struct {
+	BufferSize     int    `yaml:"buffer_size"`
+	OverflowPolicy string `yaml:"overflow_policy"`
+}
+
+type AccessLogSinks struct {
+	File        AccessLogSink `yaml:"file"`
+	Syslog      AccessLogSink `yaml:"syslog"`
+	Loggregator AccessLogSink `yaml:"loggregator"`
+}
+
+var defaultAccessLogSink = AccessLogSink{
+	BufferSize:     1024,
+	OverflowPolicy: ACCESS_LOG_OVERFLOW_BLOCK,
+}
+
+// AccessLogRotation rotates the access log file when it grows past
+// MaxSizeMB or every Interval. Zero values disable either trigger.
+type AccessLogRotation struct {
+	MaxSizeMB  int           `yaml:"max_size_mb"`
+	Interval   time.Duration `yaml:"interval"`
+	MaxBackups int           `yaml:"max_backups"`
+	Compress   bool          `yaml:"compress"`
+}
+
+const ACCESS_LOG_FORMAT_TEXT string = "text"
+const ACCESS_LOG_FORMAT_JSON string = "json"
+const ACCESS_LOG_FORMAT_TEMPLATE string = "template"
+
+var AccessLogFormats = []string{ACCESS_LOG_FORMAT_TEXT, ACCESS_LOG_FORMAT_JSON, ACCESS_LOG_FORMAT_TEMPLATE}
+
+var defaultAccessLogConfig = AccessLog{
+	Format:         ACCESS_LOG_FORMAT_TEXT,
+	KeepSlowerThan: 1 * time.Second,
+	Redaction:      AccessLogRedaction{Mode: ACCESS_LOG_REDACT_MASK},
+	Sinks: AccessLogSinks{
+		File:        defaultAccessLogSink,
+		Syslog:      defaultAccessLogSink,
+		Loggregator: defaultAccessLogSink,
+	},
+}
+
+const TRACE_FORMAT_B3 string = "b3"
+const TRACE_FORMAT_B3_SINGLE string = "b3-single"
+const TRACE_FORMAT_W3C string = "w3c"
+const TRACE_FORMAT_BOTH string = "both"
+
+var TraceFormats = []string{TRACE_FORMAT_B3, TRACE_FORMAT_B3_SINGLE, TRACE_FORMAT_W3C, TRACE_FORMAT_BOTH}
+
+const TRACE_EXPORTER_ZIPKIN string = "zipkin"
+const TRACE_EXPORTER_OTLP string = "otlp"
+
+var TraceExporters = []string{TRACE_EXPORTER_ZIPKIN, TRACE_EXPORTER_OTLP}
+
+// TraceExporterConfig configures where router spans are sent. Type is
+// "zipkin" for the Zipkin v2 JSON API or "otlp" for OTLP/HTTP with JSON
+// encoding; no spans are recorded when it is empty.
+type TraceExporterConfig struct {
+	Type          string        `yaml:"type"`
+	Endpoint      string        `yaml:"endpoint"`
+	Timeout       time.Duration `yaml:"timeout"`
+	BufferSize    int           `yaml:"buffer_size"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
 }
 
 type Tracing struct {
-	EnableZipkin bool `yaml:"enable_zipkin"`
+	EnableZipkin bool                `yaml:"enable_zipkin"`
+	Format       string              `yaml:"format"`
+	ServiceName  string              `yaml:"service_name"`
+	SampleRate   float64             `yaml:"sample_rate"`
+	Exporter     TraceExporterConfig `yaml:"exporter"`
+}
+
+var defaultTracingConfig = Tracing{
+	Format:      TRACE_FORMAT_B3,
+	ServiceName: "gorouter",
+	SampleRate:  1.0,
+	Exporter: TraceExporterConfig{
+		Timeout:       5 * time.Second,
+		BufferSize:    1000,
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	},
+}
+
+type ErrorPagesConfig struct {
+	Directory      string        `yaml:"directory"`
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+var defaultErrorPagesConfig = ErrorPagesConfig{
+	ReloadInterval: 10 * time.Second,
+}
+
+type HeaderAction struct {
+	Action string `yaml:"action"`
+	Name   string `yaml:"name"`
+	Value  string `yaml:"value"`
+}
+
+type HeaderRule struct {
+	Host     string         `yaml:"host"`
+	Path     string         `yaml:"path"`
+	Request  []HeaderAction `yaml:"request"`
+	Response []HeaderAction `yaml:"response"`
+}
+
+// PathRewrite changes the path sent to the backends of a single route, e.g.
+// "example.com/api/orders". The route's context path is stripped or replaced
+// with ReplacePrefix before Regex is applied.
+type PathRewrite struct {
+	Route         string `yaml:"route"`
+	StripPrefix   bool   `yaml:"strip_prefix"`
+	ReplacePrefix string `yaml:"replace_prefix"`
+	Regex         string `yaml:"regex"`
+	Replacement   string `yaml:"replacement"`
+}
+
+// RedirectRoute is a route answered by the router with a redirect. Target is
+// a template, e.g. "https://new.example.com{{.RequestURI}}".
+type RedirectRoute struct {
+	Route      string `yaml:"route"`
+	StatusCode int    `yaml:"status_code"`
+	Target     string `yaml:"target"`
+}
+
+// HTTPSRedirectConfig redirects plain HTTP requests for the listed domains
+// to HTTPS. Domains may be wildcards such as "*.example.com".
+type HTTPSRedirectConfig struct {
+	Domains    []string `yaml:"domains"`
+	StatusCode int      `yaml:"status_code"`
+}
+
+var defaultHTTPSRedirectConfig = HTTPSRedirectConfig{
+	StatusCode: 301,
+}
+
+// IPRule limits which client addresses may reach routes matching Host and
+// Path. Deny is checked first; a non-empty Allow denies everything else.
+type IPRule struct {
+	Host  string   `yaml:"host"`
+	Path  string   `yaml:"path"`
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+type JWTKeySetConfig struct {
+	Name           string   `yaml:"name"`
+	JWKSFiles      []string `yaml:"jwks_files"`
+	PublicKeyFiles []string `yaml:"public_key_files"`
+}
+
+// JWTPolicyConfig requires a bearer token signed by one of the keys of
+// KeySet for routes matching Host and Path. ClaimHeaders maps claim names
+// to the request headers they are passed to the backend in. Tokens without
+// an exp claim are rejected unless RequireExp is set to false.
+type JWTPolicyConfig struct {
+	Host         string            `yaml:"host"`
+	Path         string            `yaml:"path"`
+	KeySet       string            `yaml:"key_set"`
+	Issuer       string            `yaml:"issuer"`
+	Audiences    []string          `yaml:"audiences"`
+	ClaimHeaders map[string]string `yaml:"claim_headers"`
+	RequireExp   *bool             `yaml:"require_exp"`
+}
+
+// ExpRequired reports whether tokens must have an exp claim, which is the
+// default.
+func (c JWTPolicyConfig) ExpRequired() bool {
+	return c.RequireExp == nil || *c.RequireExp
+}
+
+type JWTConfig struct {
+	KeySets   []JWTKeySetConfig `yaml:"key_sets"`
+	Policies  []JWTPolicyConfig `yaml:"policies"`
+	ClockSkew time.Duration     `yaml:"clock_skew"`
+}
+
+var defaultJWTConfig = JWTConfig{
+	ClockSkew: 30 * time.Second,
+}
+
+// CORSPolicy lets the router answer CORS preflight requests and set the CORS
+// response headers for routes matching Host and Path. Origins may contain
+// "*" wildcards, e.g. "https://*.example.com", and "*" allows every origin.
+type CORSPolicy struct {
+	Host             string        `yaml:"host"`
+	Path             string        `yaml:"path"`
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+// RequestIdConfig selects the header carrying the request ID and whether an
+// incoming ID is replaced (generate), kept when well-formed (preserve) or
+// kept with the router's own ID appended (append).
+type RequestIdConfig struct {
+	Header string `yaml:"header"`
+	Mode   string `yaml:"mode"`
+}
+
+var defaultRequestIdConfig = RequestIdConfig{
+	Header: "X-Vcap-Request-Id",
+	Mode:   REQUEST_ID_GENERATE,
+}
+
+// PrometheusConfig enables the Prometheus /metrics endpoint on the status
+// server.
+type PrometheusConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// StatsDConfig enables a StatsD reporter sending to Address. With DogStatsD
+// set, metrics are tagged with the component and app ID instead of carrying
+// them in the metric name.
+type StatsDConfig struct {
+	Address       string        `yaml:"address"`
+	Prefix        string        `yaml:"prefix"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	SampleRate    float64       `yaml:"sample_rate"`
+	DogStatsD     bool          `yaml:"dogstatsd"`
+	Tags          []string      `yaml:"tags"`
+}
+
+var defaultStatsDConfig = StatsDConfig{
+	Prefix:        "gorouter.",
+	FlushInterval: time.Second,
+	SampleRate:    1.0,
+}
+
+// VarzBreakdownConfig enables per application and per route metrics in
+// /varz. At most MaxEntries applications and routes are tracked each; the
+// least recently used ones are evicted.
+type VarzBreakdownConfig struct {
+	ByApp      bool `yaml:"by_app"`
+	ByRoute    bool `yaml:"by_route"`
+	MaxEntries int  `yaml:"max_entries"`
+}
+
+var defaultVarzBreakdownConfig = VarzBreakdownConfig{
+	MaxEntries: 100,
+}
+
+// RegistrySnapshotConfig writes the route registry to File every Interval and
+// when the router stops. At startup a snapshot younger than MaxAge is loaded,
+// and its endpoints are pruned after StaleThreshold unless they register
+// again. The start response delay is only skipped when the snapshot is
+// younger than WarmStartMaxAge.
+type RegistrySnapshotConfig struct {
+	File            string        `yaml:"file"`
+	Interval        time.Duration `yaml:"interval"`
+	MaxAge          time.Duration `yaml:"max_age"`
+	StaleThreshold  time.Duration `yaml:"stale_threshold"`
+	WarmStartMaxAge time.Duration `yaml:"warm_start_max_age"`
+}
+
+var defaultRegistrySnapshotConfig = RegistrySnapshotConfig{
+	Interval:        30 * time.Second,
+	MaxAge:          120 * time.Second,
+	StaleThreshold:  60 * time.Second,
+	WarmStartMaxAge: 30 * time.Second,
 }
 
 var defaultLoggingConfig = LoggingConfig{
@@ -128,6 +453,8 @@ type Config struct {
 
 	HealthCheckUserAgent string `yaml:"healthcheck_user_agent,omitempty"`
 
+	ErrorPages ErrorPagesConfig `yaml:"error_pages"`
+
 	OAuth                      OAuthConfig      `yaml:"oauth"`
 	RoutingApi                 RoutingApiConfig `yaml:"routing_api"`
 	RouteServiceSecret         string           `yaml:"route_services_secret"`
@@ -138,7 +465,28 @@ type Config struct {
 	RouteServiceEnabled    bool          `yaml:"-"`
 	NatsClientPingInterval time.Duration `yaml:"-"`
 
-	ExtraHeadersToLog []string `yaml:"extra_headers_to_log"`
+	ExtraHeadersToLog []string      `yaml:"extra_headers_to_log"`
+	HeaderRules       []HeaderRule  `yaml:"header_rules"`
+	PathRewrites      []PathRewrite `yaml:"path_rewrites"`
+
+	RedirectRoutes []RedirectRoute     `yaml:"redirect_routes"`
+	HTTPSRedirect  HTTPSRedirectConfig `yaml:"https_redirect"`
+
+	IPRules        []IPRule `yaml:"ip_rules"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+
+	JWT JWTConfig `yaml:"jwt"`
+
+	CORSPolicies []CORSPolicy `yaml:"cors_policies"`
+
+	RequestId RequestIdConfig `yaml:"request_id"`
+
+	Prometheus PrometheusConfig `yaml:"prometheus"`
+	StatsD     StatsDConfig     `yaml:"statsd"`
+
+	VarzBreakdown VarzBreakdownConfig `yaml:"varz_breakdown"`
+
+	RegistrySnapshot RegistrySnapshotConfig `yaml:"registry_snapshot"`
 
 	TokenFetcherMaxRetries                    uint32        `yaml:"token_fetcher_max_retries"`
 	TokenFetcherRetryInterval                 time.Duration `yaml:"token_fetcher_retry_interval"`
@@ -174,6 +522,20 @@ var defaultConfig = Config{
 
 	HealthCheckUserAgent: "HTTP-Monitor/1.1",
 	LoadBalance:          LOAD_BALANCE_RR,
+
+	Tracing: defaultTracingConfig,
+
+	AccessLog: defaultAccessLogConfig,
+
+	ErrorPages: defaultErrorPagesConfig,
+
+	HTTPSRedirect: defaultHTTPSRedirectConfig,
+	JWT:           defaultJWTConfig,
+	RequestId:     defaultRequestIdConfig,
+	StatsD:        defaultStatsDConfig,
+	VarzBreakdown: defaultVarzBreakdownConfig,
+
+	RegistrySnapshot: defaultRegistrySnapshotConfig,
 }
 
 func DefaultConfig() *Config {
@@ -236,6 +598,183 @@ func (c *Config) Process() {
 		errMsg := fmt.Sprintf("Invalid load balancing algorithm %s. Allowed values are %s", c.LoadBalance, LoadBalancingStrategies)
 		panic(errMsg)
 	}
+
+	validTraceFormat := false
+	for _, format := range TraceFormats {
+		if c.Tracing.Format == format {
+			validTraceFormat = true
+			break
+		}
+	}
+	if !validTraceFormat {
+		panic(fmt.Sprintf("Invalid tracing format %s. Allowed values are %s", c.Tracing.Format, TraceFormats))
+	}
+
+	if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+		panic(fmt.Sprintf("Invalid tracing sample rate %v. Must be between 0 and 1", c.Tracing.SampleRate))
+	}
+	if c.Tracing.Exporter.Type != "" {
+		validTraceExporter := false
+		for _, exporter := range TraceExporters {
+			if c.Tracing.Exporter.Type == exporter {
+				validTraceExporter = true
+				break
+			}
+		}
+		if !validTraceExporter {
+			panic(fmt.Sprintf("Invalid tracing exporter %s. Allowed values are %s", c.Tracing.Exporter.Type, TraceExporters))
+		}
+		if c.Tracing.Exporter.Endpoint == "" {
+			panic("tracing exporter must specify an endpoint")
+		}
+		if !c.Tracing.EnableZipkin {
+			panic("tracing exporter requires enable_zipkin")
+		}
+		if c.Tracing.Exporter.BufferSize <= 0 || c.Tracing.Exporter.BatchSize <= 0 || c.Tracing.Exporter.FlushInterval <= 0 {
+			panic("tracing exporter buffer_size, batch_size and flush_interval must be positive")
+		}
+	}
+
+	validAccessLogFormat := false
+	for _, format := range AccessLogFormats {
+		if c.AccessLog.Format == format {
+			validAccessLogFormat = true
+			break
+		}
+	}
+	if !validAccessLogFormat {
+		panic(fmt.Sprintf("Invalid access log format %s. Allowed values are %s", c.AccessLog.Format, AccessLogFormats))
+	}
+	if c.AccessLog.Format == ACCESS_LOG_FORMAT_TEMPLATE && c.AccessLog.Template == "" {
+		panic("access log template must not be empty")
+	}
+	if c.AccessLog.Rotation.MaxSizeMB < 0 || c.AccessLog.Rotation.Interval < 0 || c.AccessLog.Rotation.MaxBackups < 0 {
+		panic("access log rotation max_size_mb, interval and max_backups must not be negative")
+	}
+	for name, sink := range map[string]AccessLogSink{"file": c.AccessLog.Sinks.File, "syslog": c.AccessLog.Sinks.Syslog, "loggregator": c.AccessLog.Sinks.Loggregator} {
+		if sink.BufferSize <= 0 {
+			panic(fmt.Sprintf("access log %s sink buffer_size must be positive", name))
+		}
+		validPolicy := false
+		for _, policy := range AccessLogOverflowPolicies {
+			if sink.OverflowPolicy == policy {
+				validPolicy = true
+				break
+			}
+		}
+		if !validPolicy {
+			panic(fmt.Sprintf("Invalid access log %s sink overflow policy %s. Allowed values are %s", name, sink.OverflowPolicy, AccessLogOverflowPolicies))
+		}
+	}
+	if c.AccessLog.KeepSlowerThan < 0 {
+		panic("access log keep_slower_than must not be negative")
+	}
+	for i, rule := range c.AccessLog.Rules {
+		validAction := false
+		for _, action := range AccessLogRuleActions {
+			if rule.Action == action {
+				validAction = true
+				break
+			}
+		}
+		if !validAction {
+			panic(fmt.Sprintf("Invalid action %s for access log rule %d. Allowed values are %s", rule.Action, i, AccessLogRuleActions))
+		}
+		if rule.Action == ACCESS_LOG_RULE_SAMPLE && (rule.SampleRate < 0 || rule.SampleRate > 1) {
+			panic(fmt.Sprintf("access log rule %d sample_rate must be between 0 and 1", i))
+		}
+		if rule.StatusClass != "" && !accessLogStatusClassPattern.MatchString(rule.StatusClass) {
+			panic(fmt.Sprintf("Invalid status class %s for access log rule %d. Expected a class such as 2xx", rule.StatusClass, i))
+		}
+		if rule.MinLatency < 0 {
+			panic(fmt.Sprintf("access log rule %d min_latency must not be negative", i))
+		}
+	}
+	validRedactMode := false
+	for _, mode := range AccessLogRedactModes {
+		if c.AccessLog.Redaction.Mode == mode {
+			validRedactMode = true
+			break
+		}
+	}
+	if !validRedactMode {
+		panic(fmt.Sprintf("Invalid access log redaction mode %s. Allowed values are %s", c.AccessLog.Redaction.Mode, AccessLogRedactModes))
+	}
+	for _, pattern := range c.AccessLog.Redaction.URIPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			panic(fmt.Sprintf("invalid access log redaction uri pattern %s: %s", pattern, err))
+		}
+	}
+
+	validRequestIdMode := false
+	for _, mode := range RequestIdModes {
+		if c.RequestId.Mode == mode {
+			validRequestIdMode = true
+			break
+		}
+	}
+	if !validRequestIdMode {
+		panic(fmt.Sprintf("Invalid request id mode %s. Allowed values are %s", c.RequestId.Mode, RequestIdModes))
+	}
+	if c.RequestId.Header == "" {
+		panic("request id header must not be empty")
+	}
+
+	if c.StatsD.Address != "" {
+		if c.StatsD.SampleRate <= 0 || c.StatsD.SampleRate > 1 {
+			panic(fmt.Sprintf("Invalid statsd sample rate %v. Must be greater than 0 and at most 1", c.StatsD.SampleRate))
+		}
+		if c.StatsD.FlushInterval <= 0 {
+			panic("statsd flush_interval must be positive")
+		}
+	}
+
+	if (c.VarzBreakdown.ByApp || c.VarzBreakdown.ByRoute) && c.VarzBreakdown.MaxEntries <= 0 {
+		panic("varz_breakdown max_entries must be positive")
+	}
+
+	if c.RegistrySnapshot.File != "" {
+		if c.RegistrySnapshot.Interval <= 0 || c.RegistrySnapshot.MaxAge <= 0 ||
+			c.RegistrySnapshot.StaleThreshold <= 0 || c.RegistrySnapshot.WarmStartMaxAge <= 0 {
+			panic("registry_snapshot interval, max_age, stale_threshold and warm_start_max_age must be positive")
+		}
+	}
+
+	for _, rw := range c.PathRewrites {
+		if rw.Route == "" {
+			panic("path rewrite must specify a route")
+		}
+		if rw.Regex != "" {
+			if _, err := regexp.Compile(rw.Regex); err != nil {
+				panic(fmt.Sprintf("invalid path rewrite regex for route %s: %s", rw.Route, err))
+			}
+		}
+	}
+
+	for _, rd := range c.RedirectRoutes {
+		if rd.Route == "" || rd.Target == "" {
+			panic("redirect route must specify a route and a target")
+		}
+		if !ValidRedirectStatusCode(rd.StatusCode) {
+			panic(fmt.Sprintf("invalid redirect status code %d for route %s", rd.StatusCode, rd.Route))
+		}
+		if _, err := template.New(rd.Route).Parse(rd.Target); err != nil {
+			panic(fmt.Sprintf("invalid redirect target for route %s: %s", rd.Route, err))
+		}
+	}
+	if !ValidRedirectStatusCode(c.HTTPSRedirect.StatusCode) {
+		panic(fmt.Sprintf("invalid https redirect status code %d", c.HTTPSRedirect.StatusCode))
+	}
+}
+
+// ValidRedirectStatusCode returns true for the status codes a redirect can be
+// answered with. 0 stands for the default, 301.
+func ValidRedirectStatusCode(statusCode int) bool {
+	switch statusCode {
+	case 0, 301, 302, 307, 308:
+		return true
+	}
+	return false
 }
 
 func (c *Config) processCipherSuites() []uint16 {
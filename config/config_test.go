@@ -118,6 +118,198 @@ suspend_pruning_if_nats_unavailable: true
 			// access entries not present in config
 			Expect(config.AccessLog.File).To(Equal(""))
 			Expect(config.AccessLog.EnableStreaming).To(BeFalse())
+			Expect(config.AccessLog.Format).To(Equal(ACCESS_LOG_FORMAT_TEXT))
+			Expect(config.AccessLog.Sinks.File).To(Equal(AccessLogSink{BufferSize: 1024, OverflowPolicy: ACCESS_LOG_OVERFLOW_BLOCK}))
+			Expect(config.AccessLog.Sinks.Syslog).To(Equal(AccessLogSink{BufferSize: 1024, OverflowPolicy: ACCESS_LOG_OVERFLOW_BLOCK}))
+			Expect(config.AccessLog.Sinks.Loggregator).To(Equal(AccessLogSink{BufferSize: 1024, OverflowPolicy: ACCESS_LOG_OVERFLOW_BLOCK}))
+		})
+
+		It("sets default error pages config", func() {
+			Expect(config.ErrorPages.Directory).To(Equal(""))
+			Expect(config.ErrorPages.ReloadInterval).To(Equal(10 * time.Second))
+		})
+
+		It("sets the error pages config", func() {
+			var b = []byte(`
+error_pages:
+  directory: /some/dir
+  reload_interval: 1m
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.ErrorPages.Directory).To(Equal("/some/dir"))
+			Expect(config.ErrorPages.ReloadInterval).To(Equal(time.Minute))
+		})
+
+		It("sets header rules", func() {
+			var b = []byte(`
+header_rules:
+- host: "*.example.com"
+  path: /api
+  request:
+  - action: set
+    name: X-Forwarded-Prefix
+    value: /api
+  response:
+  - action: remove
+    name: Server
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.HeaderRules).To(HaveLen(1))
+			rule := config.HeaderRules[0]
+			Expect(rule.Host).To(Equal("*.example.com"))
+			Expect(rule.Path).To(Equal("/api"))
+			Expect(rule.Request).To(Equal([]HeaderAction{{Action: "set", Name: "X-Forwarded-Prefix", Value: "/api"}}))
+			Expect(rule.Response).To(Equal([]HeaderAction{{Action: "remove", Name: "Server"}}))
+		})
+
+		It("sets path rewrites", func() {
+			var b = []byte(`
+path_rewrites:
+- route: example.com/api/orders
+  strip_prefix: true
+  regex: ^/v2/(.*)$
+  replacement: /$1
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.PathRewrites).To(Equal([]PathRewrite{
+				{Route: "example.com/api/orders", StripPrefix: true, Regex: "^/v2/(.*)$", Replacement: "/$1"},
+			}))
+		})
+
+		It("sets redirect routes and https redirects", func() {
+			var b = []byte(`
+redirect_routes:
+- route: old.example.com
+  status_code: 308
+  target: https://new.example.com{{.RequestURI}}
+https_redirect:
+  domains:
+  - "*.example.com"
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.RedirectRoutes).To(Equal([]RedirectRoute{
+				{Route: "old.example.com", StatusCode: 308, Target: "https://new.example.com{{.RequestURI}}"},
+			}))
+			Expect(config.HTTPSRedirect.Domains).To(Equal([]string{"*.example.com"}))
+			Expect(config.HTTPSRedirect.StatusCode).To(Equal(301))
+		})
+
+		It("sets ip rules and trusted proxies", func() {
+			var b = []byte(`
+ip_rules:
+- host: admin.example.com
+  allow: ["10.0.0.0/8"]
+  deny: ["10.1.0.0/16"]
+trusted_proxies: ["192.168.0.1"]
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.IPRules).To(Equal([]IPRule{
+				{Host: "admin.example.com", Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.1.0.0/16"}},
+			}))
+			Expect(config.TrustedProxies).To(Equal([]string{"192.168.0.1"}))
+		})
+
+		It("sets the request id policy", func() {
+			var b = []byte(`
+request_id:
+  header: X-Request-Id
+  mode: append
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.RequestId).To(Equal(RequestIdConfig{Header: "X-Request-Id", Mode: "append"}))
+		})
+
+		It("defaults the request id policy", func() {
+			Expect(config.RequestId).To(Equal(RequestIdConfig{Header: "X-Vcap-Request-Id", Mode: "generate"}))
+		})
+
+		It("sets cors policies", func() {
+			var b = []byte(`
+cors_policies:
+- host: api.example.com
+  path: /v1
+  allowed_origins: ["https://*.example.com"]
+  allowed_methods: [GET, PUT]
+  allowed_headers: [Authorization]
+  exposed_headers: [X-Total-Count]
+  allow_credentials: true
+  max_age: 10m
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.CORSPolicies).To(Equal([]CORSPolicy{{
+				Host:             "api.example.com",
+				Path:             "/v1",
+				AllowedOrigins:   []string{"https://*.example.com"},
+				AllowedMethods:   []string{"GET", "PUT"},
+				AllowedHeaders:   []string{"Authorization"},
+				ExposedHeaders:   []string{"X-Total-Count"},
+				AllowCredentials: true,
+				MaxAge:           10 * time.Minute,
+			}}))
+		})
+
+		It("sets jwt policies", func() {
+			var b = []byte(`
+jwt:
+  clock_skew: 1m
+  key_sets:
+  - name: uaa
+    jwks_files: [/path/to/jwks.json]
+  policies:
+  - host: api.example.com
+    path: /orders
+    key_set: uaa
+    issuer: https://uaa.example.com
+    audiences: [orders]
+    claim_headers:
+      sub: X-User-Id
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.JWT.ClockSkew).To(Equal(time.Minute))
+			Expect(config.JWT.KeySets).To(Equal([]JWTKeySetConfig{{Name: "uaa", JWKSFiles: []string{"/path/to/jwks.json"}}}))
+			Expect(config.JWT.Policies).To(Equal([]JWTPolicyConfig{{
+				Host:         "api.example.com",
+				Path:         "/orders",
+				KeySet:       "uaa",
+				Issuer:       "https://uaa.example.com",
+				Audiences:    []string{"orders"},
+				ClaimHeaders: map[string]string{"sub": "X-User-Id"},
+			}}))
+			Expect(config.JWT.Policies[0].ExpRequired()).To(BeTrue())
+		})
+
+		It("lets jwt policies accept tokens without exp", func() {
+			var b = []byte(`
+jwt:
+  policies:
+  - host: api.example.com
+    key_set: uaa
+    require_exp: false
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.JWT.Policies[0].ExpRequired()).To(BeFalse())
+		})
+
+		It("defaults the jwt clock skew", func() {
+			Expect(config.JWT.ClockSkew).To(Equal(30 * time.Second))
 		})
 
 		It("sets the load_balancer_healthy_threshold configuration", func() {
@@ -168,6 +360,123 @@ access_log:
 			Expect(config.AccessLog.EnableStreaming).To(BeTrue())
 		})
 
+		It("sets the access log format", func() {
+			var b = []byte(`
+access_log:
+  file: "/var/vcap/sys/log/gorouter/access.log"
+  format: json
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.File).To(Equal("/var/vcap/sys/log/gorouter/access.log"))
+			Expect(config.AccessLog.Format).To(Equal(ACCESS_LOG_FORMAT_JSON))
+		})
+
+		It("sets the access log rotation", func() {
+			var b = []byte(`
+access_log:
+  file: "/var/vcap/sys/log/gorouter/access.log"
+  rotation:
+    max_size_mb: 100
+    interval: 24h
+    max_backups: 7
+    compress: true
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Rotation).To(Equal(AccessLogRotation{MaxSizeMB: 100, Interval: 24 * time.Hour, MaxBackups: 7, Compress: true}))
+		})
+
+		It("sets the access log sinks", func() {
+			var b = []byte(`
+access_log:
+  sinks:
+    syslog:
+      buffer_size: 4096
+      overflow_policy: drop_oldest
+    loggregator:
+      overflow_policy: drop_newest
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Sinks.File).To(Equal(AccessLogSink{BufferSize: 1024, OverflowPolicy: ACCESS_LOG_OVERFLOW_BLOCK}))
+			Expect(config.AccessLog.Sinks.Syslog).To(Equal(AccessLogSink{BufferSize: 4096, OverflowPolicy: ACCESS_LOG_OVERFLOW_DROP_OLDEST}))
+			Expect(config.AccessLog.Sinks.Loggregator).To(Equal(AccessLogSink{BufferSize: 1024, OverflowPolicy: ACCESS_LOG_OVERFLOW_DROP_NEWEST}))
+		})
+
+		It("sets the access log rules", func() {
+			var b = []byte(`
+access_log:
+  keep_slower_than: 2s
+  rules:
+  - path_prefix: /health
+    user_agent: kube-probe
+    action: never
+  - host: static.example.com
+    status_class: 2xx
+    action: sample
+    sample_rate: 0.01
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.KeepSlowerThan).To(Equal(2 * time.Second))
+			Expect(config.AccessLog.Rules).To(Equal([]AccessLogRule{
+				{PathPrefix: "/health", UserAgent: "kube-probe", Action: ACCESS_LOG_RULE_NEVER},
+				{Host: "static.example.com", StatusClass: "2xx", Action: ACCESS_LOG_RULE_SAMPLE, SampleRate: 0.01},
+			}))
+		})
+
+		It("keeps slow requests for a second by default", func() {
+			err := config.Initialize([]byte{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.KeepSlowerThan).To(Equal(1 * time.Second))
+		})
+
+		It("sets the access log redaction", func() {
+			var b = []byte(`
+access_log:
+  redaction:
+    mode: hash
+    query_params: [access_token]
+    headers: [Authorization]
+    uri_patterns: ['/users/[0-9]+']
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Redaction).To(Equal(AccessLogRedaction{
+				Mode:        ACCESS_LOG_REDACT_HASH,
+				QueryParams: []string{"access_token"},
+				Headers:     []string{"Authorization"},
+				URIPatterns: []string{"/users/[0-9]+"},
+			}))
+		})
+
+		It("masks redacted values by default", func() {
+			err := config.Initialize([]byte{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Redaction.Mode).To(Equal(ACCESS_LOG_REDACT_MASK))
+		})
+
+		It("sets the access log template", func() {
+			var b = []byte(`
+access_log:
+  format: template
+  template: '$remote_addr "$request" $status'
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.AccessLog.Format).To(Equal(ACCESS_LOG_FORMAT_TEMPLATE))
+			Expect(config.AccessLog.Template).To(Equal(`$remote_addr "$request" $status`))
+		})
+
 		It("sets logging config", func() {
 			var b = []byte(`
 logging:
@@ -381,6 +690,133 @@ enable_proxy: true
 			Expect(config.Tracing.EnableZipkin).To(BeFalse())
 		})
 
+		It("sets Tracing.Format", func() {
+			var b = []byte("tracing:\n  enable_zipkin: true\n  format: both")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Tracing.Format).To(Equal("both"))
+		})
+
+		It("defaults Tracing.Format", func() {
+			Expect(config.Tracing.Format).To(Equal("b3"))
+		})
+
+		It("sets the Tracing exporter", func() {
+			var b = []byte(`
+tracing:
+  enable_zipkin: true
+  service_name: edge-router
+  sample_rate: 0.25
+  exporter:
+    type: otlp
+    endpoint: http://collector:4318/v1/traces
+    timeout: 2s
+    buffer_size: 50
+    batch_size: 10
+    flush_interval: 500ms
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Tracing.ServiceName).To(Equal("edge-router"))
+			Expect(config.Tracing.SampleRate).To(Equal(0.25))
+			Expect(config.Tracing.Exporter).To(Equal(TraceExporterConfig{
+				Type:          "otlp",
+				Endpoint:      "http://collector:4318/v1/traces",
+				Timeout:       2 * time.Second,
+				BufferSize:    50,
+				BatchSize:     10,
+				FlushInterval: 500 * time.Millisecond,
+			}))
+		})
+
+		It("defaults the Tracing exporter", func() {
+			Expect(config.Tracing.ServiceName).To(Equal("gorouter"))
+			Expect(config.Tracing.SampleRate).To(Equal(1.0))
+			Expect(config.Tracing.Exporter.Type).To(BeEmpty())
+			Expect(config.Tracing.Exporter.BufferSize).To(Equal(1000))
+			Expect(config.Tracing.Exporter.BatchSize).To(Equal(100))
+			Expect(config.Tracing.Exporter.FlushInterval).To(Equal(time.Second))
+		})
+
+		It("sets Prometheus.Enabled", func() {
+			var b = []byte("prometheus:\n  enabled: true")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.Prometheus.Enabled).To(BeTrue())
+		})
+
+		It("defaults Prometheus.Enabled", func() {
+			Expect(config.Prometheus.Enabled).To(BeFalse())
+		})
+
+		It("sets StatsD", func() {
+			var b = []byte(`
+statsd:
+  address: 127.0.0.1:8125
+  prefix: router.
+  flush_interval: 5s
+  sample_rate: 0.1
+  dogstatsd: true
+  tags: [env:prod]
+`)
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.StatsD).To(Equal(StatsDConfig{
+				Address:       "127.0.0.1:8125",
+				Prefix:        "router.",
+				FlushInterval: 5 * time.Second,
+				SampleRate:    0.1,
+				DogStatsD:     true,
+				Tags:          []string{"env:prod"},
+			}))
+		})
+
+		It("defaults StatsD", func() {
+			Expect(config.StatsD.Address).To(BeEmpty())
+			Expect(config.StatsD.Prefix).To(Equal("gorouter."))
+			Expect(config.StatsD.FlushInterval).To(Equal(time.Second))
+			Expect(config.StatsD.SampleRate).To(Equal(1.0))
+		})
+
+		It("sets VarzBreakdown", func() {
+			var b = []byte("varz_breakdown:\n  by_app: true\n  by_route: true\n  max_entries: 20")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.VarzBreakdown).To(Equal(VarzBreakdownConfig{ByApp: true, ByRoute: true, MaxEntries: 20}))
+		})
+
+		It("defaults VarzBreakdown", func() {
+			Expect(config.VarzBreakdown).To(Equal(VarzBreakdownConfig{MaxEntries: 100}))
+		})
+
+		It("sets RegistrySnapshot", func() {
+			var b = []byte("registry_snapshot:\n  file: /var/vcap/data/gorouter/routes.json\n  interval: 1m\n  max_age: 5m\n  stale_threshold: 45s\n  warm_start_max_age: 10s")
+			err := config.Initialize(b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.RegistrySnapshot).To(Equal(RegistrySnapshotConfig{
+				File:            "/var/vcap/data/gorouter/routes.json",
+				Interval:        time.Minute,
+				MaxAge:          5 * time.Minute,
+				StaleThreshold:  45 * time.Second,
+				WarmStartMaxAge: 10 * time.Second,
+			}))
+		})
+
+		It("defaults RegistrySnapshot", func() {
+			Expect(config.RegistrySnapshot).To(Equal(RegistrySnapshotConfig{
+				Interval:        30 * time.Second,
+				MaxAge:          120 * time.Second,
+				StaleThreshold:  60 * time.Second,
+				WarmStartMaxAge: 30 * time.Second,
+			}))
+		})
+
 		It("sets the proxy forwarded proto header", func() {
 			var b = []byte("force_forwarded_proto_https: true")
 			config.Initialize(b)
@@ -689,6 +1125,327 @@ ssl_key_path: ../test/assets/certs/server.key
 			})
 		})
 
+		Context("When given a redirect route with an invalid status code", func() {
+			var b = []byte(`
+redirect_routes:
+- route: old.example.com
+  status_code: 200
+  target: https://new.example.com
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid tracing format", func() {
+			var b = []byte(`
+tracing:
+  format: jaeger
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid trace exporter", func() {
+			var b = []byte(`
+tracing:
+  enable_zipkin: true
+  exporter:
+    type: jaeger
+    endpoint: http://collector:14268
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a trace exporter without an endpoint", func() {
+			var b = []byte(`
+tracing:
+  enable_zipkin: true
+  exporter:
+    type: zipkin
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a trace exporter without enable_zipkin", func() {
+			var b = []byte(`
+tracing:
+  exporter:
+    type: zipkin
+    endpoint: http://zipkin:9411/api/v2/spans
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a sample rate out of range", func() {
+			var b = []byte(`
+tracing:
+  sample_rate: 1.5
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid statsd sample rate", func() {
+			var b = []byte(`
+statsd:
+  address: 127.0.0.1:8125
+  sample_rate: 0
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a varz breakdown without entries", func() {
+			var b = []byte(`
+varz_breakdown:
+  by_app: true
+  max_entries: 0
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a template access log format without a template", func() {
+			var b = []byte(`
+access_log:
+  format: template
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid access log overflow policy", func() {
+			var b = []byte(`
+access_log:
+  sinks:
+    file:
+      overflow_policy: spill
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an empty access log sink buffer", func() {
+			var b = []byte(`
+access_log:
+  sinks:
+    syslog:
+      buffer_size: 0
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a negative access log rotation", func() {
+			var b = []byte(`
+access_log:
+  rotation:
+    max_backups: -1
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an access log rule with an invalid action", func() {
+			var b = []byte(`
+access_log:
+  rules:
+  - host: example.com
+    action: maybe
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an access log rule with a sample rate above 1", func() {
+			var b = []byte(`
+access_log:
+  rules:
+  - host: example.com
+    action: sample
+    sample_rate: 1.5
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an access log rule with an invalid status class", func() {
+			var b = []byte(`
+access_log:
+  rules:
+  - status_class: 200
+    action: never
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid access log redaction mode", func() {
+			var b = []byte(`
+access_log:
+  redaction:
+    mode: drop
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid access log redaction uri pattern", func() {
+			var b = []byte(`
+access_log:
+  redaction:
+    uri_patterns: ['(']
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid access log format", func() {
+			var b = []byte(`
+access_log:
+  format: xml
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given an invalid request id mode", func() {
+			var b = []byte(`
+request_id:
+  mode: keep
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a registry snapshot without an interval", func() {
+			var b = []byte(`
+registry_snapshot:
+  file: /var/vcap/data/gorouter/routes.json
+  interval: 0s
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
+		Context("When given a path rewrite with an invalid regex", func() {
+			var b = []byte(`
+path_rewrites:
+- route: example.com/api
+  regex: "("
+`)
+
+			It("panics", func() {
+				err := config.Initialize(b)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(config.Process).To(Panic())
+			})
+		})
+
 		Describe("Timeout", func() {
 			It("converts timeouts to a duration", func() {
 				var b = []byte(`
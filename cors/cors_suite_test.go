@@ -0,0 +1,13 @@
+package cors_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCors(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cors Suite")
+}
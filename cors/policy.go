@@ -0,0 +1,234 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const (
+	OriginHeader              = "Origin"
+	RequestMethodHeader       = "Access-Control-Request-Method"
+	RequestHeadersHeader      = "Access-Control-Request-Headers"
+	AllowOriginHeader         = "Access-Control-Allow-Origin"
+	AllowMethodsHeader        = "Access-Control-Allow-Methods"
+	AllowHeadersHeader        = "Access-Control-Allow-Headers"
+	AllowCredentialsHeader    = "Access-Control-Allow-Credentials"
+	ExposeHeadersHeader       = "Access-Control-Expose-Headers"
+	MaxAgeHeader              = "Access-Control-Max-Age"
+	accessControlHeaderPrefix = "Access-Control-"
+)
+
+var defaultAllowedMethods = []string{"GET", "HEAD", "POST"}
+
+// Policy holds the CORS settings for the routes matching its host and path.
+type Policy struct {
+	matcher          route.Matcher
+	origins          []string
+	anyOrigin        bool
+	methods          []string
+	headers          []string
+	anyHeader        bool
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           int
+}
+
+// Policies holds the CORS policies in the order they were configured.
+type Policies struct {
+	policies []*Policy
+}
+
+func NewPolicies(c []config.CORSPolicy) (*Policies, error) {
+	p := &Policies{}
+
+	for i, pc := range c {
+		policy := &Policy{
+			matcher:          route.NewMatcher(pc.Host, pc.Path),
+			methods:          defaultAllowedMethods,
+			allowCredentials: pc.AllowCredentials,
+			maxAge:           int(pc.MaxAge.Seconds()),
+		}
+
+		if len(pc.AllowedOrigins) == 0 {
+			return nil, fmt.Errorf("cors policy %d: no allowed origins", i)
+		}
+		for _, origin := range pc.AllowedOrigins {
+			if origin == "*" {
+				policy.anyOrigin = true
+				continue
+			}
+			origin = strings.ToLower(origin)
+			if _, err := path.Match(origin, ""); err != nil {
+				return nil, fmt.Errorf("cors policy %d: invalid origin %q: %s", i, origin, err)
+			}
+			policy.origins = append(policy.origins, origin)
+		}
+
+		if len(pc.AllowedMethods) > 0 {
+			policy.methods = nil
+			for _, method := range pc.AllowedMethods {
+				policy.methods = append(policy.methods, strings.ToUpper(method))
+			}
+		}
+
+		for _, header := range pc.AllowedHeaders {
+			if header == "*" {
+				policy.anyHeader = true
+				continue
+			}
+			policy.headers = append(policy.headers, http.CanonicalHeaderKey(header))
+		}
+
+		policy.exposedHeaders = strings.Join(pc.ExposedHeaders, ", ")
+
+		p.policies = append(p.policies, policy)
+	}
+
+	return p, nil
+}
+
+// Match returns the first policy matching the request, or nil.
+func (p *Policies) Match(request *http.Request) *Policy {
+	if p == nil {
+		return nil
+	}
+
+	for _, policy := range p.policies {
+		if policy.matcher.Match(request.Host, request.URL.Path) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// IsPreflight reports whether the request is a CORS preflight request.
+func IsPreflight(request *http.Request) bool {
+	return request.Method == http.MethodOptions &&
+		request.Header.Get(OriginHeader) != "" &&
+		request.Header.Get(RequestMethodHeader) != ""
+}
+
+// Preflight sets the response headers for a preflight request and reports
+// whether the requested origin, method and headers are allowed.
+func (p *Policy) Preflight(request *http.Request, header http.Header) bool {
+	header.Add("Vary", OriginHeader)
+	header.Add("Vary", RequestMethodHeader)
+	header.Add("Vary", RequestHeadersHeader)
+
+	origin := request.Header.Get(OriginHeader)
+	if !p.originAllowed(origin) {
+		return false
+	}
+
+	method := strings.ToUpper(request.Header.Get(RequestMethodHeader))
+	if !contains(p.methods, method) {
+		return false
+	}
+
+	requested := parseList(request.Header.Get(RequestHeadersHeader))
+	for _, h := range requested {
+		if !p.anyHeader && !contains(p.headers, http.CanonicalHeaderKey(h)) {
+			return false
+		}
+	}
+
+	p.setOrigin(origin, header)
+	header.Set(AllowMethodsHeader, strings.Join(p.methods, ", "))
+	if len(requested) > 0 {
+		header.Set(AllowHeadersHeader, strings.Join(requested, ", "))
+	}
+	if p.maxAge > 0 {
+		header.Set(MaxAgeHeader, strconv.Itoa(p.maxAge))
+	}
+	return true
+}
+
+// SetResponseHeaders replaces the CORS headers of a proxied response with
+// the ones of the policy. Responses to requests without an Origin header
+// are left untouched apart from Vary.
+func (p *Policy) SetResponseHeaders(request *http.Request, header http.Header) {
+	header.Add("Vary", OriginHeader)
+
+	origin := request.Header.Get(OriginHeader)
+	if origin == "" {
+		return
+	}
+
+	for name := range header {
+		if strings.HasPrefix(name, accessControlHeaderPrefix) {
+			delete(header, name)
+		}
+	}
+
+	if !p.originAllowed(origin) {
+		return
+	}
+
+	p.setOrigin(origin, header)
+	if p.exposedHeaders != "" {
+		header.Set(ExposeHeadersHeader, p.exposedHeaders)
+	}
+}
+
+// SetResponseHeaders applies the policy matching the request, if any, to
+// the response headers.
+func (p *Policies) SetResponseHeaders(request *http.Request, header http.Header) {
+	if policy := p.Match(request); policy != nil {
+		policy.SetResponseHeaders(request, header)
+	}
+}
+
+func (p *Policy) originAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, pattern := range p.origins {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin echoes the request origin unless every origin is allowed and
+// credentials are not, since browsers reject "*" for credentialed requests.
+func (p *Policy) setOrigin(origin string, header http.Header) {
+	if p.anyOrigin && !p.allowCredentials {
+		header.Set(AllowOriginHeader, "*")
+	} else {
+		header.Set(AllowOriginHeader, origin)
+	}
+	if p.allowCredentials {
+		header.Set(AllowCredentialsHeader, "true")
+	}
+}
+
+func parseList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package cors_test
+
+import (
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/cors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policies", func() {
+	var (
+		cfg      []config.CORSPolicy
+		policies *cors.Policies
+		req      *http.Request
+		header   http.Header
+	)
+
+	BeforeEach(func() {
+		cfg = []config.CORSPolicy{{
+			Host:           "api.example.com",
+			Path:           "/v1",
+			AllowedOrigins: []string{"https://app.example.com", "https://*.example.org"},
+			AllowedMethods: []string{"get", "put"},
+			AllowedHeaders: []string{"authorization", "X-Custom"},
+			ExposedHeaders: []string{"X-Total-Count"},
+			MaxAge:         10 * time.Minute,
+		}}
+
+		var err error
+		req, err = http.NewRequest("OPTIONS", "http://api.example.com/v1/items", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		header = http.Header{}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		policies, err = cors.NewPolicies(cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("detects preflight requests", func() {
+		Expect(cors.IsPreflight(req)).To(BeTrue())
+
+		req.Header.Del("Access-Control-Request-Method")
+		Expect(cors.IsPreflight(req)).To(BeFalse())
+	})
+
+	It("matches requests by host and path", func() {
+		Expect(policies.Match(req)).NotTo(BeNil())
+
+		req.URL.Path = "/v2"
+		Expect(policies.Match(req)).To(BeNil())
+	})
+
+	Describe("Preflight", func() {
+		It("allows configured origins, methods and headers", func() {
+			req.Header.Set("Access-Control-Request-Headers", "Authorization, x-custom")
+
+			Expect(policies.Match(req).Preflight(req, header)).To(BeTrue())
+			Expect(header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+			Expect(header.Get("Access-Control-Allow-Methods")).To(Equal("GET, PUT"))
+			Expect(header.Get("Access-Control-Allow-Headers")).To(Equal("Authorization, x-custom"))
+			Expect(header.Get("Access-Control-Max-Age")).To(Equal("600"))
+			Expect(header).NotTo(HaveKey("Access-Control-Allow-Credentials"))
+			Expect(header["Vary"]).To(ContainElement("Origin"))
+		})
+
+		It("allows origins matching a pattern", func() {
+			req.Header.Set("Origin", "https://shop.example.org")
+			Expect(policies.Match(req).Preflight(req, header)).To(BeTrue())
+		})
+
+		It("rejects other origins", func() {
+			req.Header.Set("Origin", "https://evil.com")
+			Expect(policies.Match(req).Preflight(req, header)).To(BeFalse())
+			Expect(header).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("rejects other methods and headers", func() {
+			req.Header.Set("Access-Control-Request-Method", "DELETE")
+			Expect(policies.Match(req).Preflight(req, header)).To(BeFalse())
+
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			req.Header.Set("Access-Control-Request-Headers", "X-Other")
+			Expect(policies.Match(req).Preflight(req, header)).To(BeFalse())
+		})
+
+		Context("when every origin is allowed", func() {
+			BeforeEach(func() {
+				cfg[0].AllowedOrigins = []string{"*"}
+			})
+
+			It("responds with a wildcard origin", func() {
+				Expect(policies.Match(req).Preflight(req, header)).To(BeTrue())
+				Expect(header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+			})
+
+			It("echoes the origin when credentials are allowed", func() {
+				cfg[0].AllowCredentials = true
+				policies, _ = cors.NewPolicies(cfg)
+
+				Expect(policies.Match(req).Preflight(req, header)).To(BeTrue())
+				Expect(header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+				Expect(header.Get("Access-Control-Allow-Credentials")).To(Equal("true"))
+			})
+		})
+	})
+
+	Describe("SetResponseHeaders", func() {
+		BeforeEach(func() {
+			req.Method = "GET"
+			header.Set("Access-Control-Allow-Origin", "*")
+			header.Set("Access-Control-Allow-Credentials", "true")
+		})
+
+		It("replaces the backend cors headers", func() {
+			policies.SetResponseHeaders(req, header)
+
+			Expect(header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+			Expect(header.Get("Access-Control-Expose-Headers")).To(Equal("X-Total-Count"))
+			Expect(header).NotTo(HaveKey("Access-Control-Allow-Credentials"))
+		})
+
+		It("removes the backend cors headers for other origins", func() {
+			req.Header.Set("Origin", "https://evil.com")
+			policies.SetResponseHeaders(req, header)
+
+			Expect(header).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("leaves responses for other routes untouched", func() {
+			req.Host = "other.example.com"
+			policies.SetResponseHeaders(req, header)
+
+			Expect(header).To(Equal(http.Header{
+				"Access-Control-Allow-Origin":      []string{"*"},
+				"Access-Control-Allow-Credentials": []string{"true"},
+			}))
+		})
+	})
+
+	It("requires allowed origins", func() {
+		cfg[0].AllowedOrigins = nil
+		_, err := cors.NewPolicies(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects invalid origin patterns", func() {
+		cfg[0].AllowedOrigins = []string{"https://[.example.com"}
+		_, err := cors.NewPolicies(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,323 @@
+package errorpage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/lager"
+)
+
+const (
+	HTML = "html"
+	JSON = "json"
+
+	// DefaultTemplate is used when neither the error type nor the status code
+	// has a dedicated template.
+	DefaultTemplate = "default"
+)
+
+// Data is passed to every error page template.
+type Data struct {
+	Host       string
+	RequestId  string
+	ErrorType  string
+	StatusCode int
+	StatusText string
+	Message    string
+}
+
+type templateSet struct {
+	html map[string]*htmltemplate.Template
+	json map[string]*texttemplate.Template
+}
+
+// Templates holds operator provided error pages loaded from a directory.
+// Templates are named after the X-Cf-RouterError value (unknown_route.html),
+// the status code (502.json) or DefaultTemplate, with an .html or .json
+// extension.
+type Templates struct {
+	logger         lager.Logger
+	dir            string
+	reloadInterval time.Duration
+
+	lock        sync.RWMutex
+	set         *templateSet
+	fingerprint string
+}
+
+func NewTemplates(logger lager.Logger, dir string, reloadInterval time.Duration) (*Templates, error) {
+	t := &Templates{
+		logger:         logger,
+		dir:            dir,
+		reloadInterval: reloadInterval,
+	}
+
+	err := t.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload parses every template in the directory. The previously loaded
+// templates are kept if any of them fails to parse.
+func (t *Templates) Reload() error {
+	fingerprint, err := t.currentFingerprint()
+	if err != nil {
+		return err
+	}
+
+	set, err := t.load()
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	t.set = set
+	t.fingerprint = fingerprint
+	t.lock.Unlock()
+
+	t.logger.Info("error-pages-loaded", lager.Data{"dir": t.dir, "html": len(set.html), "json": len(set.json)})
+	return nil
+}
+
+// Run reloads the templates whenever a file in the directory changes.
+func (t *Templates) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	if t.reloadInterval <= 0 {
+		<-signals
+		return nil
+	}
+
+	ticker := time.NewTicker(t.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if t.changed() {
+				err := t.Reload()
+				if err != nil {
+					t.logger.Error("error-pages-reload-failed", err)
+				}
+			}
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+// Render writes the error page matching the request's Accept header. It
+// returns false when no template applies, in which case nothing has been
+// written to the response.
+func (t *Templates) Render(rw http.ResponseWriter, request *http.Request, code int, message string) bool {
+	if t == nil {
+		return false
+	}
+
+	format := Negotiate(request.Header.Get("Accept"))
+	if format == "" {
+		return false
+	}
+
+	data := Data{
+		Host:       request.Host,
+		RequestId:  router_http.RequestId(request),
+		ErrorType:  rw.Header().Get(router_http.CfRouterErrorHeader),
+		StatusCode: code,
+		StatusText: http.StatusText(code),
+		Message:    message,
+	}
+
+	names := []string{strconv.Itoa(code), DefaultTemplate}
+	if data.ErrorType != "" {
+		names = append([]string{data.ErrorType}, names...)
+	}
+
+	t.lock.RLock()
+	set := t.set
+	t.lock.RUnlock()
+
+	var buf bytes.Buffer
+	var contentType string
+	var err error
+
+	switch format {
+	case HTML:
+		tmpl := set.findHTML(names)
+		if tmpl == nil {
+			return false
+		}
+		contentType = "text/html; charset=utf-8"
+		err = tmpl.Execute(&buf, data)
+	case JSON:
+		tmpl := set.findJSON(names)
+		if tmpl == nil {
+			return false
+		}
+		contentType = "application/json"
+		err = tmpl.Execute(&buf, data)
+	}
+
+	if err != nil {
+		t.logger.Error("error-page-render-failed", err, lager.Data{"format": format, "status": code})
+		return false
+	}
+
+	rw.Header().Set("Content-Type", contentType)
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(code)
+	rw.Write(buf.Bytes())
+	return true
+}
+
+// Negotiate picks HTML or JSON from an Accept header. Wildcard-only headers
+// such as `*/*` return an empty string so that non-browser clients keep
+// receiving the plain text body.
+func Negotiate(accept string) string {
+	format := ""
+	best := 0.0
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		parts := strings.Split(mediaRange, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(parts[0]))
+
+		q := 1.0
+		for _, param := range parts[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		var candidate string
+		switch {
+		case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+			candidate = HTML
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			candidate = JSON
+		default:
+			continue
+		}
+
+		if q > best {
+			format = candidate
+			best = q
+		}
+	}
+
+	return format
+}
+
+func (t *Templates) changed() bool {
+	fingerprint, err := t.currentFingerprint()
+	if err != nil {
+		t.logger.Error("error-pages-stat-failed", err)
+		return false
+	}
+
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return fingerprint != t.fingerprint
+}
+
+func (t *Templates) currentFingerprint() (string, error) {
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s:%d:%d;", f.Name(), f.Size(), f.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+func (t *Templates) load() (*templateSet, error) {
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &templateSet{
+		html: make(map[string]*htmltemplate.Template),
+		json: make(map[string]*texttemplate.Template),
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(f.Name())
+		name := strings.TrimSuffix(f.Name(), ext)
+		path := filepath.Join(t.dir, f.Name())
+
+		switch ext {
+		case ".html":
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			tmpl, err := htmltemplate.New(f.Name()).Parse(string(content))
+			if err != nil {
+				return nil, err
+			}
+			set.html[name] = tmpl
+		case ".json":
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			tmpl, err := texttemplate.New(f.Name()).Funcs(jsonFuncs).Parse(string(content))
+			if err != nil {
+				return nil, err
+			}
+			set.json[name] = tmpl
+		}
+	}
+
+	return set, nil
+}
+
+func (s *templateSet) findHTML(names []string) *htmltemplate.Template {
+	for _, name := range names {
+		if tmpl, ok := s.html[name]; ok {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+func (s *templateSet) findJSON(names []string) *texttemplate.Template {
+	for _, name := range names {
+		if tmpl, ok := s.json[name]; ok {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// jsonFuncs lets JSON templates quote values, e.g. {"host": {{json .Host}}}
+var jsonFuncs = texttemplate.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
@@ -0,0 +1,189 @@
+package errorpage_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Templates", func() {
+	var (
+		logger    lager.Logger
+		dir       string
+		templates *errorpage.Templates
+		rw        *httptest.ResponseRecorder
+		req       *http.Request
+	)
+
+	writeTemplate := func(name, content string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("test")
+		dir, err = ioutil.TempDir("", "error-pages")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeTemplate("unknown_route.html", `<h1>{{.Host}} is not here</h1><p>{{.RequestId}}</p>`)
+		writeTemplate("502.json", `{"error": {{json .ErrorType}}, "status": {{.StatusCode}}, "host": {{json .Host}}}`)
+		writeTemplate("default.html", `<p>{{.StatusCode}} {{.StatusText}}: {{.Message}}</p>`)
+
+		req, err = http.NewRequest("GET", "http://example.com/foo", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("X-Vcap-Request-Id", "some-request-id")
+		rw = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		templates, err = errorpage.NewTemplates(logger, dir, 0)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Render", func() {
+		Context("when the client accepts html", func() {
+			BeforeEach(func() {
+				req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+			})
+
+			It("renders the template for the error type", func() {
+				rw.Header().Set("X-Cf-RouterError", "unknown_route")
+
+				Expect(templates.Render(rw, req, http.StatusNotFound, "not found")).To(BeTrue())
+				Expect(rw.Code).To(Equal(http.StatusNotFound))
+				Expect(rw.Header().Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+				Expect(rw.Body.String()).To(Equal("<h1>example.com is not here</h1><p>some-request-id</p>"))
+			})
+
+			It("renders the request id chosen by the router", func() {
+				req = router_http.WithRequestId(req, "custom-request-id")
+				rw.Header().Set("X-Cf-RouterError", "unknown_route")
+
+				Expect(templates.Render(rw, req, http.StatusNotFound, "not found")).To(BeTrue())
+				Expect(rw.Body.String()).To(ContainSubstring("<p>custom-request-id</p>"))
+			})
+
+			It("escapes template values", func() {
+				req.Host = "<script>"
+				rw.Header().Set("X-Cf-RouterError", "unknown_route")
+
+				Expect(templates.Render(rw, req, http.StatusNotFound, "not found")).To(BeTrue())
+				Expect(rw.Body.String()).To(ContainSubstring("&lt;script&gt;"))
+			})
+
+			It("falls back to the default template", func() {
+				Expect(templates.Render(rw, req, http.StatusBadGateway, "oops")).To(BeTrue())
+				Expect(rw.Body.String()).To(Equal("<p>502 Bad Gateway: oops</p>"))
+			})
+		})
+
+		Context("when the client accepts json", func() {
+			BeforeEach(func() {
+				req.Header.Set("Accept", "application/json")
+			})
+
+			It("renders the template for the status code", func() {
+				rw.Header().Set("X-Cf-RouterError", "endpoint_failure")
+
+				Expect(templates.Render(rw, req, http.StatusBadGateway, "oops")).To(BeTrue())
+				Expect(rw.Header().Get("Content-Type")).To(Equal("application/json"))
+				Expect(rw.Body.String()).To(MatchJSON(`{"error": "endpoint_failure", "status": 502, "host": "example.com"}`))
+			})
+
+			It("does not render when there is no matching template", func() {
+				Expect(templates.Render(rw, req, http.StatusNotFound, "not found")).To(BeFalse())
+				Expect(rw.Body.Len()).To(BeZero())
+			})
+		})
+
+		Context("when the client does not ask for html or json", func() {
+			It("does not render", func() {
+				req.Header.Set("Accept", "*/*")
+				Expect(templates.Render(rw, req, http.StatusBadGateway, "oops")).To(BeFalse())
+			})
+		})
+
+		Context("when there are no templates", func() {
+			It("does not render", func() {
+				var nilTemplates *errorpage.Templates
+				req.Header.Set("Accept", "text/html")
+				Expect(nilTemplates.Render(rw, req, http.StatusBadGateway, "oops")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Reload", func() {
+		BeforeEach(func() {
+			req.Header.Set("Accept", "text/html")
+		})
+
+		It("picks up changed templates", func() {
+			writeTemplate("default.html", `<p>changed</p>`)
+			Expect(templates.Reload()).To(Succeed())
+
+			templates.Render(rw, req, http.StatusBadGateway, "oops")
+			Expect(rw.Body.String()).To(Equal("<p>changed</p>"))
+		})
+
+		It("keeps the previous templates when a template is invalid", func() {
+			writeTemplate("default.html", `<p>{{.Broken</p>`)
+			Expect(templates.Reload()).NotTo(Succeed())
+
+			templates.Render(rw, req, http.StatusBadGateway, "oops")
+			Expect(rw.Body.String()).To(Equal("<p>502 Bad Gateway: oops</p>"))
+		})
+	})
+
+	Describe("Run", func() {
+		It("reloads the templates when the directory changes", func() {
+			var err error
+			templates, err = errorpage.NewTemplates(logger, dir, 10*time.Millisecond)
+			Expect(err).NotTo(HaveOccurred())
+
+			signals := make(chan os.Signal)
+			ready := make(chan struct{})
+			go templates.Run(signals, ready)
+			defer close(signals)
+			Eventually(ready).Should(BeClosed())
+
+			writeTemplate("404.html", `<p>new</p>`)
+
+			req.Header.Set("Accept", "text/html")
+			Eventually(func() string {
+				rw = httptest.NewRecorder()
+				templates.Render(rw, req, http.StatusNotFound, "not found")
+				return rw.Body.String()
+			}).Should(Equal("<p>new</p>"))
+		})
+	})
+
+	Describe("Negotiate", func() {
+		It("prefers the media type with the highest quality", func() {
+			Expect(errorpage.Negotiate("application/json;q=0.5, text/html")).To(Equal(errorpage.HTML))
+			Expect(errorpage.Negotiate("text/html;q=0.1, application/json")).To(Equal(errorpage.JSON))
+			Expect(errorpage.Negotiate("application/problem+json")).To(Equal(errorpage.JSON))
+		})
+
+		It("ignores wildcards", func() {
+			Expect(errorpage.Negotiate("*/*")).To(BeEmpty())
+			Expect(errorpage.Negotiate("")).To(BeEmpty())
+		})
+	})
+})
@@ -16,12 +16,16 @@ import (
 type accessLog struct {
 	accessLogger      access_log.AccessLogger
 	extraHeadersToLog *[]string
+	filter            *access_log.Filter
 }
 
-func NewAccessLog(accessLogger access_log.AccessLogger, extraHeadersToLog *[]string) negroni.Handler {
+// NewAccessLog logs a record of every request that filter keeps. A nil filter
+// keeps every record.
+func NewAccessLog(accessLogger access_log.AccessLogger, extraHeadersToLog *[]string, filter *access_log.Filter) negroni.Handler {
 	return &accessLog{
 		accessLogger:      accessLogger,
 		extraHeadersToLog: extraHeadersToLog,
+		filter:            filter,
 	}
 }
 
@@ -31,6 +35,7 @@ func (a *accessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http
 		Request:           r,
 		StartedAt:         time.Now(),
 		ExtraHeadersToLog: a.extraHeadersToLog,
+		TLS:               schema.NewTLSDetails(r.TLS),
 	}
 
 	requestBodyCounter := &countingReadCloser{delegate: r.Body}
@@ -42,8 +47,11 @@ func (a *accessLog) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http
 
 	alr.RequestBytesReceived = requestBodyCounter.GetCount()
 	alr.BodyBytesSent = proxyWriter.Size()
+	alr.ResponseHeader = proxyWriter.Header()
 	alr.FinishedAt = time.Now()
-	a.accessLogger.Log(*alr)
+	if a.filter.Keep(alr) {
+		a.accessLogger.Log(*alr)
+	}
 }
 
 type countingReadCloser struct {
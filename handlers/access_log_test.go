@@ -2,12 +2,15 @@ package handlers_test
 
 import (
 	"bytes"
+	"crypto/tls"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 
+	"code.cloudfoundry.org/gorouter/access_log"
 	"code.cloudfoundry.org/gorouter/access_log/fakes"
 	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
 	"code.cloudfoundry.org/gorouter/handlers"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/gorouter/test_util"
@@ -38,6 +41,7 @@ var _ = Describe("AccessLog", func() {
 		_, err := ioutil.ReadAll(req.Body)
 		Expect(err).NotTo(HaveOccurred())
 
+		rw.Header().Set("Content-Type", "text/plain")
 		rw.WriteHeader(http.StatusTeapot)
 		rw.Write([]byte("I'm a little teapot, short and stout."))
 
@@ -55,7 +59,7 @@ var _ = Describe("AccessLog", func() {
 
 		accessLogger = &fakes.FakeAccessLogger{}
 
-		handler = handlers.NewAccessLog(accessLogger, &extraHeadersToLog)
+		handler = handlers.NewAccessLog(accessLogger, &extraHeadersToLog, nil)
 
 		nextCalled = false
 	})
@@ -84,5 +88,46 @@ var _ = Describe("AccessLog", func() {
 		Expect(alr.FinishedAt).ToNot(BeZero())
 		Expect(alr.RequestBytesReceived).To(Equal(13))
 		Expect(alr.BodyBytesSent).To(Equal(37))
+		Expect(alr.ResponseHeader.Get("Content-Type")).To(Equal("text/plain"))
+		Expect(alr.TLS).To(BeNil())
+	})
+
+	It("records the TLS details of requests received over TLS", func() {
+		req.TLS = &tls.ConnectionState{
+			Version:     tls.VersionTLS12,
+			CipherSuite: tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			ServerName:  "example.com",
+		}
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		alr := accessLogger.LogArgsForCall(0)
+		Expect(alr.TLS).To(Equal(&schema.TLSDetails{
+			Version:     "TLSv1.2",
+			CipherSuite: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+			ServerName:  "example.com",
+		}))
+	})
+
+	Context("with a filter", func() {
+		BeforeEach(func() {
+			filter, err := access_log.NewFilter(config.AccessLog{
+				Rules: []config.AccessLogRule{{PathPrefix: "/health", Action: config.ACCESS_LOG_RULE_NEVER}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			handler = handlers.NewAccessLog(accessLogger, &extraHeadersToLog, filter)
+		})
+
+		It("does not log the records it drops", func() {
+			req = httptest.NewRequest("GET", "http://example.com/health", nil)
+			handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+			Expect(accessLogger.LogCallCount()).To(Equal(0))
+		})
+
+		It("logs the records it keeps", func() {
+			handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+			Expect(accessLogger.LogCallCount()).To(Equal(1))
+		})
 	})
 })
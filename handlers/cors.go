@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/urfave/negroni"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/lager"
+)
+
+type corsPreflight struct {
+	policies   *cors.Policies
+	errorPages *errorpage.Templates
+	logger     lager.Logger
+}
+
+// NewCORS creates a handler that answers CORS preflight requests for routes
+// matching a CORS policy instead of forwarding them to the backend. Rejected
+// preflights are rendered with errorPages when it has a matching template.
+func NewCORS(policies *cors.Policies, errorPages *errorpage.Templates, logger lager.Logger) negroni.Handler {
+	return &corsPreflight{
+		policies:   policies,
+		errorPages: errorPages,
+		logger:     logger,
+	}
+}
+
+func (c *corsPreflight) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !cors.IsPreflight(r) {
+		next(rw, r)
+		return
+	}
+
+	policy := c.policies.Match(r)
+	if policy == nil {
+		next(rw, r)
+		return
+	}
+
+	status := http.StatusNoContent
+	if !policy.Preflight(r, rw.Header()) {
+		c.logger.Info("cors-preflight-rejected", lager.Data{
+			"host":   r.Host,
+			"path":   r.URL.Path,
+			"origin": r.Header.Get(cors.OriginHeader),
+			"method": r.Header.Get(cors.RequestMethodHeader),
+		})
+		status = http.StatusForbidden
+		rw.Header().Set(router_http.CfRouterErrorHeader, "cors_rejected")
+	}
+
+	if proxyWriter, ok := rw.(utils.ProxyResponseWriter); ok {
+		alr := proxyWriter.Context().Value("AccessLogRecord")
+		if alr == nil {
+			c.logger.Error("AccessLogRecord not set on context", errors.New("failed-to-access-log-record"))
+		} else {
+			alr.(*schema.AccessLogRecord).StatusCode = status
+		}
+	}
+
+	if status == http.StatusForbidden && c.errorPages.Render(rw, r, status, "CORS preflight request is not allowed.") {
+		return
+	}
+	rw.WriteHeader(status)
+}
@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/handlers"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni"
+)
+
+var _ = Describe("CORS", func() {
+	var (
+		handler     negroni.Handler
+		resp        *httptest.ResponseRecorder
+		proxyWriter utils.ProxyResponseWriter
+		req         *http.Request
+		alr         *schema.AccessLogRecord
+		nextCalled  bool
+		policies    *cors.Policies
+	)
+
+	nextHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+	})
+
+	BeforeEach(func() {
+		var err error
+		policies, err = cors.NewPolicies([]config.CORSPolicy{{
+			Host:           "cors.example.com",
+			AllowedOrigins: []string{"https://app.example.com"},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		handler = handlers.NewCORS(policies, nil, lagertest.NewTestLogger("cors"))
+
+		req, err = http.NewRequest("OPTIONS", "http://cors.example.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+
+		resp = httptest.NewRecorder()
+		proxyWriter = utils.NewProxyResponseWriter(resp)
+		alr = &schema.AccessLogRecord{
+			Request: req,
+		}
+		proxyWriter.AddToContext("AccessLogRecord", alr)
+		nextCalled = false
+	})
+
+	It("answers allowed preflight requests", func() {
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusNoContent))
+		Expect(resp.Header().Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+		Expect(alr.StatusCode).To(Equal(http.StatusNoContent))
+	})
+
+	It("rejects preflight requests from other origins", func() {
+		req.Header.Set("Origin", "https://evil.com")
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		Expect(resp.Header().Get("X-Cf-RouterError")).To(Equal("cors_rejected"))
+		Expect(resp.Header()).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		Expect(alr.StatusCode).To(Equal(http.StatusForbidden))
+	})
+
+	It("renders rejected preflight requests with the error pages", func() {
+		dir, err := ioutil.TempDir("", "error-pages")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		err = ioutil.WriteFile(filepath.Join(dir, "cors_rejected.json"), []byte(`{"error": {{json .ErrorType}}}`), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		errorPages, err := errorpage.NewTemplates(lagertest.NewTestLogger("cors"), dir, 0)
+		Expect(err).NotTo(HaveOccurred())
+		handler = handlers.NewCORS(policies, errorPages, lagertest.NewTestLogger("cors"))
+
+		req.Header.Set("Origin", "https://evil.com")
+		req.Header.Set("Accept", "application/json")
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(resp.Code).To(Equal(http.StatusForbidden))
+		Expect(resp.Body.String()).To(Equal(`{"error": "cors_rejected"}`))
+		Expect(alr.StatusCode).To(Equal(http.StatusForbidden))
+	})
+
+	It("passes on requests that are not preflight requests", func() {
+		req.Method = "GET"
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+	})
+
+	It("passes on preflight requests for other routes", func() {
+		req.Host = "other.example.com"
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header()).To(BeEmpty())
+	})
+})
@@ -8,6 +8,7 @@ import (
 	"github.com/urfave/negroni"
 
 	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/errorpage"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/lager"
 )
@@ -15,17 +16,21 @@ import (
 type healthcheck struct {
 	userAgent   string
 	heartbeatOK *int32
+	errorPages  *errorpage.Templates
 	logger      lager.Logger
 }
 
 // NewHealthcheck creates a handler that responds to healthcheck requests.
 // If userAgent is set to a non-empty string, it will use that user agent to
 // differentiate between healthcheck requests and non-healthcheck requests.
-// Otherwise, it will treat all requests as healthcheck requests.
-func NewHealthcheck(userAgent string, heartbeatOK *int32, logger lager.Logger) negroni.Handler {
+// Otherwise, it will treat all requests as healthcheck requests. While the
+// router is draining, the 503 is rendered with errorPages when it has a
+// matching template.
+func NewHealthcheck(userAgent string, heartbeatOK *int32, errorPages *errorpage.Templates, logger lager.Logger) negroni.Handler {
 	return &healthcheck{
 		userAgent:   userAgent,
 		heartbeatOK: heartbeatOK,
+		errorPages:  errorPages,
 		logger:      logger,
 	}
 }
@@ -53,7 +58,9 @@ func (h *healthcheck) ServeHTTP(rw http.ResponseWriter, r *http.Request, next ht
 				accessLogRecord.StatusCode = http.StatusOK
 			}
 		} else {
-			rw.WriteHeader(http.StatusServiceUnavailable)
+			if !h.errorPages.Render(rw, r, http.StatusServiceUnavailable, "Router is draining.") {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+			}
 			r.Close = true
 			if ok {
 				accessLogRecord.StatusCode = http.StatusServiceUnavailable
@@ -4,8 +4,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 
 	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/errorpage"
 	"code.cloudfoundry.org/gorouter/handlers"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/gorouter/test_util"
@@ -108,7 +111,7 @@ var _ = Describe("Healthcheck", func() {
 
 	Context("with User-Agent checking", func() {
 		BeforeEach(func() {
-			handler = handlers.NewHealthcheck("HTTP-Monitor/1.1", &heartbeatOK, logger)
+			handler = handlers.NewHealthcheck("HTTP-Monitor/1.1", &heartbeatOK, nil, logger)
 		})
 
 		Context("when User-Agent is set to the healthcheck User-Agent", func() {
@@ -152,7 +155,7 @@ var _ = Describe("Healthcheck", func() {
 
 	Context("without User-Agent checking", func() {
 		BeforeEach(func() {
-			handler = handlers.NewHealthcheck("", &heartbeatOK, logger)
+			handler = handlers.NewHealthcheck("", &heartbeatOK, nil, logger)
 		})
 
 		TestHealthcheckOK()
@@ -172,4 +175,42 @@ var _ = Describe("Healthcheck", func() {
 			TestHealthcheckOK()
 		})
 	})
+
+	Context("with error pages", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "error-pages")
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "503.html"), []byte(`<p>{{.StatusCode}}: {{.Message}}</p>`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			errorPages, err := errorpage.NewTemplates(logger, dir, 0)
+			Expect(err).NotTo(HaveOccurred())
+			handler = handlers.NewHealthcheck("", &heartbeatOK, errorPages, logger)
+			heartbeatOK = 0
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("renders the 503 while draining", func() {
+			req.Header.Set("Accept", "text/html")
+			handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+			Expect(resp.Code).To(Equal(503))
+			Expect(resp.Body.String()).To(Equal("<p>503: Router is draining.</p>"))
+			Expect(alr.StatusCode).To(Equal(503))
+			Expect(req.Close).To(BeTrue())
+		})
+
+		It("responds without a body when no template applies", func() {
+			handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+			Expect(resp.Code).To(Equal(503))
+			Expect(resp.Body.String()).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/urfave/negroni"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager"
+)
+
+const httpsRedirectTarget = "https://{{.Host}}{{.RequestURI}}"
+
+type httpsRedirect struct {
+	domains  []route.Matcher
+	redirect *route.Redirect
+	logger   lager.Logger
+}
+
+// NewHTTPSRedirect creates a handler that redirects plain HTTP requests for
+// the given domains to HTTPS. Requests are considered secure when they were
+// received over TLS or carry X-Forwarded-Proto: https.
+func NewHTTPSRedirect(domains []string, statusCode int, logger lager.Logger) negroni.Handler {
+	h := &httpsRedirect{logger: logger}
+
+	redirect, err := route.NewRedirect(statusCode, httpsRedirectTarget)
+	if err != nil {
+		logger.Error("invalid-https-redirect", err)
+		return h
+	}
+	h.redirect = redirect
+
+	for _, domain := range domains {
+		h.domains = append(h.domains, route.NewMatcher(domain, ""))
+	}
+	return h
+}
+
+func (h *httpsRedirect) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if !h.matches(r) {
+		next(rw, r)
+		return
+	}
+
+	location, err := h.redirect.Location(r)
+	if err != nil {
+		h.logger.Error("https-redirect-failed", err)
+		next(rw, r)
+		return
+	}
+
+	if proxyWriter, ok := rw.(utils.ProxyResponseWriter); ok {
+		alr := proxyWriter.Context().Value("AccessLogRecord")
+		if alr == nil {
+			h.logger.Error("AccessLogRecord not set on context", errors.New("failed-to-access-log-record"))
+		} else {
+			alr.(*schema.AccessLogRecord).StatusCode = h.redirect.StatusCode
+		}
+	}
+
+	http.Redirect(rw, r, location, h.redirect.StatusCode)
+}
+
+func (h *httpsRedirect) matches(r *http.Request) bool {
+	if len(h.domains) == 0 || r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		return false
+	}
+
+	for _, domain := range h.domains {
+		if domain.Match(r.Host, r.URL.Path) {
+			return true
+		}
+	}
+	return false
+}
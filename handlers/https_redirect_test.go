@@ -0,0 +1,91 @@
+package handlers_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/handlers"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni"
+)
+
+var _ = Describe("HTTPSRedirect", func() {
+	var (
+		handler     negroni.Handler
+		logger      lager.Logger
+		resp        *httptest.ResponseRecorder
+		proxyWriter utils.ProxyResponseWriter
+		req         *http.Request
+		alr         *schema.AccessLogRecord
+		nextCalled  bool
+	)
+
+	nextHandler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		nextCalled = true
+	})
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("https-redirect")
+		req, err = http.NewRequest("GET", "http://app.example.com:80/some/path?foo=bar", nil)
+		Expect(err).NotTo(HaveOccurred())
+		resp = httptest.NewRecorder()
+		proxyWriter = utils.NewProxyResponseWriter(resp)
+		alr = &schema.AccessLogRecord{
+			Request: req,
+		}
+		proxyWriter.AddToContext("AccessLogRecord", alr)
+		nextCalled = false
+
+		handler = handlers.NewHTTPSRedirect([]string{"*.example.com", "example.org"}, 308, logger)
+	})
+
+	It("redirects plain http requests for a listed domain", func() {
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusPermanentRedirect))
+		Expect(resp.Header().Get("Location")).To(Equal("https://app.example.com/some/path?foo=bar"))
+		Expect(alr.StatusCode).To(Equal(http.StatusPermanentRedirect))
+	})
+
+	It("does not redirect requests received over tls", func() {
+		req.TLS = &tls.ConnectionState{}
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+	})
+
+	It("does not redirect requests forwarded as https", func() {
+		req.Header.Set("X-Forwarded-Proto", "https")
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+	})
+
+	It("does not redirect other domains", func() {
+		req.Host = "example.net"
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(resp.Header().Get("Location")).To(BeEmpty())
+	})
+
+	Context("when no domains are configured", func() {
+		BeforeEach(func() {
+			handler = handlers.NewHTTPSRedirect(nil, 301, logger)
+		})
+
+		It("forwards the request to the next handler", func() {
+			handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+			Expect(nextCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/negroni"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/jwt"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/lager"
+)
+
+type jwtAuth struct {
+	policies   *jwt.Policies
+	errorPages *errorpage.Templates
+	logger     lager.Logger
+}
+
+// NewJWT creates a handler that requires a valid bearer token for routes
+// matching a JWT policy. Requests for other routes are passed on once the
+// claim headers are stripped.
+func NewJWT(policies *jwt.Policies, errorPages *errorpage.Templates, logger lager.Logger) negroni.Handler {
+	return &jwtAuth{
+		policies:   policies,
+		errorPages: errorPages,
+		logger:     logger,
+	}
+}
+
+func (j *jwtAuth) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	j.policies.StripClaimHeaders(r.Header)
+
+	policy := j.policies.Match(r)
+	if policy == nil {
+		next(rw, r)
+		return
+	}
+
+	claims, err := policy.Authenticate(r)
+	if err != nil {
+		j.logger.Info("jwt-rejected", lager.Data{"host": r.Host, "path": r.URL.Path, "error": err.Error()})
+		j.unauthorized(rw, r, err)
+		return
+	}
+
+	policy.SetClaimHeaders(r.Header, claims)
+	next(rw, r)
+}
+
+func (j *jwtAuth) unauthorized(rw http.ResponseWriter, r *http.Request, err error) {
+	if proxyWriter, ok := rw.(utils.ProxyResponseWriter); ok {
+		alr := proxyWriter.Context().Value("AccessLogRecord")
+		if alr == nil {
+			j.logger.Error("AccessLogRecord not set on context", errors.New("failed-to-access-log-record"))
+		} else {
+			alr.(*schema.AccessLogRecord).StatusCode = http.StatusUnauthorized
+		}
+	}
+
+	if err == jwt.ErrMissingToken {
+		rw.Header().Set("WWW-Authenticate", `Bearer`)
+	} else {
+		rw.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, err.Error()))
+	}
+	rw.Header().Set(router_http.CfRouterErrorHeader, "invalid_token")
+	if !j.errorPages.Render(rw, r, http.StatusUnauthorized, err.Error()) {
+		http.Error(rw, "401 Unauthorized: "+err.Error(), http.StatusUnauthorized)
+	}
+}
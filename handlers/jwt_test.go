@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/handlers"
+	"code.cloudfoundry.org/gorouter/jwt"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/urfave/negroni"
+)
+
+var _ = Describe("JWT", func() {
+	var (
+		handler     negroni.Handler
+		policies    *jwt.Policies
+		logger      lager.Logger
+		dir         string
+		resp        *httptest.ResponseRecorder
+		proxyWriter utils.ProxyResponseWriter
+		req         *http.Request
+		alr         *schema.AccessLogRecord
+		nextCalled  bool
+		nextRequest *http.Request
+	)
+
+	nextHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+		nextRequest = r
+	})
+
+	token := func(payload string) string {
+		enc := base64.RawURLEncoding
+		signed := enc.EncodeToString([]byte(`{"alg":"HS256","kid":"k"}`)) + "." + enc.EncodeToString([]byte(payload))
+		mac := hmac.New(crypto.SHA256.New, []byte("secret"))
+		mac.Write([]byte(signed))
+		return signed + "." + enc.EncodeToString(mac.Sum(nil))
+	}
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("jwt")
+
+		dir, err = ioutil.TempDir("", "jwt")
+		Expect(err).NotTo(HaveOccurred())
+		jwks := `{"keys":[{"kty":"oct","kid":"k","k":"` + base64.RawURLEncoding.EncodeToString([]byte("secret")) + `"}]}`
+		Expect(ioutil.WriteFile(filepath.Join(dir, "jwks.json"), []byte(jwks), 0644)).To(Succeed())
+
+		policies, err = jwt.NewPolicies(config.JWTConfig{
+			KeySets: []config.JWTKeySetConfig{{Name: "keys", JWKSFiles: []string{filepath.Join(dir, "jwks.json")}}},
+			Policies: []config.JWTPolicyConfig{{
+				Host:         "secure.example.com",
+				KeySet:       "keys",
+				ClaimHeaders: map[string]string{"sub": "X-User-Id"},
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		handler = handlers.NewJWT(policies, nil, logger)
+
+		req, err = http.NewRequest("GET", "http://secure.example.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("X-User-Id", "spoofed")
+		resp = httptest.NewRecorder()
+		proxyWriter = utils.NewProxyResponseWriter(resp)
+		alr = &schema.AccessLogRecord{
+			Request: req,
+		}
+		proxyWriter.AddToContext("AccessLogRecord", alr)
+		nextCalled = false
+		nextRequest = nil
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("passes requests with a valid token and sets the claim headers", func() {
+		req.Header.Set("Authorization", "Bearer "+token(`{"sub":"user-1","exp":4102444800}`))
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(nextRequest.Header.Get("X-User-Id")).To(Equal("user-1"))
+	})
+
+	It("strips client supplied claim headers when the claim is missing", func() {
+		req.Header.Set("Authorization", "Bearer "+token(`{"iss":"someone","exp":4102444800}`))
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(nextRequest.Header).NotTo(HaveKey("X-User-Id"))
+	})
+
+	It("responds with 401 to invalid tokens", func() {
+		req.Header.Set("Authorization", "Bearer "+token(`{"exp":1}`))
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error="invalid_token"`))
+		Expect(resp.Header().Get("X-Cf-RouterError")).To(Equal("invalid_token"))
+		Expect(alr.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("responds with 401 to tokens without exp", func() {
+		req.Header.Set("Authorization", "Bearer "+token(`{"sub":"user-1"}`))
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error_description="token has no expiration"`))
+	})
+
+	It("renders rejected requests with the error pages", func() {
+		err := ioutil.WriteFile(filepath.Join(dir, "invalid_token.json"), []byte(`{"error": {{json .ErrorType}}, "message": {{json .Message}}}`), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		errorPages, err := errorpage.NewTemplates(logger, dir, 0)
+		Expect(err).NotTo(HaveOccurred())
+		handler = handlers.NewJWT(policies, errorPages, logger)
+
+		req.Header.Set("Authorization", "Bearer "+token(`{"exp":1}`))
+		req.Header.Set("Accept", "application/json")
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Body.String()).To(Equal(`{"error": "invalid_token", "message": "token is expired"}`))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(ContainSubstring(`error="invalid_token"`))
+		Expect(alr.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("responds with 401 when the token is missing", func() {
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeFalse())
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+		Expect(resp.Header().Get("WWW-Authenticate")).To(Equal("Bearer"))
+	})
+
+	It("passes requests for other routes without the claim headers", func() {
+		req.Host = "public.example.com"
+		handler.ServeHTTP(proxyWriter, req, nextHandler)
+
+		Expect(nextCalled).To(BeTrue())
+		Expect(nextRequest.Header).NotTo(HaveKey("X-User-Id"))
+	})
+})
@@ -2,52 +2,78 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/urfave/negroni"
 
+	"code.cloudfoundry.org/gorouter/access_log/schema"
 	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"code.cloudfoundry.org/lager"
 )
 
 type zipkin struct {
 	zipkinEnabled bool
+	format        string
+	tracer        *tracing.Tracer
 	logger        lager.Logger
-	headersToLog  *[]string // Shared state with proxy for access logs
 }
 
-func NewZipkin(enabled bool, headersToLog *[]string, logger lager.Logger) negroni.Handler {
+// NewZipkin creates a handler that starts a router span for every request and
+// propagates it in the B3 and/or W3C trace context headers selected by
+// format. The trace and span ids are recorded in the access log, and sampled
+// spans are recorded by the tracer when one is configured.
+func NewZipkin(enabled bool, format string, tracer *tracing.Tracer, logger lager.Logger) negroni.Handler {
 	return &zipkin{
 		zipkinEnabled: enabled,
-		headersToLog:  headersToLog,
+		format:        format,
+		tracer:        tracer,
 		logger:        logger,
 	}
 }
 
 func (z *zipkin) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	defer next(rw, r)
 	if !z.zipkinEnabled {
+		next(rw, r)
 		return
 	}
-	router_http.SetB3Headers(r, z.logger)
 
-	if !contains(*z.headersToLog, router_http.B3TraceIdHeader) {
-		*z.headersToLog = append(*z.headersToLog, router_http.B3TraceIdHeader)
+	tc, err := router_http.NewTraceContext(r.Header, z.format, z.logger)
+	if err != nil {
+		z.logger.Info("failed-to-create-trace-context", lager.Data{"error": err.Error()})
+		next(rw, r)
+		return
+	}
+	if tc.Sampled == "" {
+		tc.Sampled = z.tracer.Sample()
 	}
+	router_http.WriteTraceContextHeaders(r.Header, z.format, tc)
 
-	if !contains(*z.headersToLog, router_http.B3SpanIdHeader) {
-		*z.headersToLog = append(*z.headersToLog, router_http.B3SpanIdHeader)
+	proxyWriter, _ := rw.(utils.ProxyResponseWriter)
+	if proxyWriter != nil {
+		if alr, ok := proxyWriter.Context().Value("AccessLogRecord").(*schema.AccessLogRecord); ok {
+			alr.TraceId = tc.TraceId
+			alr.SpanId = tc.SpanId
+			alr.B3Headers = router_http.WritesB3Headers(z.format)
+		}
 	}
 
-	if !contains(*z.headersToLog, router_http.B3ParentSpanIdHeader) {
-		*z.headersToLog = append(*z.headersToLog, router_http.B3ParentSpanIdHeader)
+	span := z.tracer.StartSpan(tc, r.Method)
+	if span == nil {
+		next(rw, r)
+		return
 	}
-}
 
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
+	span.SetTag("http.method", r.Method)
+	span.SetTag("http.host", r.Host)
+	span.SetTag("http.path", r.URL.Path)
+	defer func() {
+		if proxyWriter != nil && proxyWriter.Status() != 0 {
+			span.SetTag("http.status_code", strconv.Itoa(proxyWriter.Status()))
 		}
-	}
-	return false
+		span.Finish()
+	}()
+
+	next(rw, r.WithContext(tracing.NewContext(r.Context(), span)))
 }
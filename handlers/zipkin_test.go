@@ -3,30 +3,57 @@ package handlers_test
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/gorouter/access_log/schema"
 	router_http "code.cloudfoundry.org/gorouter/common/http"
 
 	"code.cloudfoundry.org/gorouter/handlers"
+	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
 	"github.com/urfave/negroni"
 )
 
 // 64-bit random hexadecimal string
 const b3_id_regex = `^[[:xdigit:]]{16}$`
 
+const traceparent_regex = `^00-[[:xdigit:]]{32}-[[:xdigit:]]{16}-0[01]$`
+
+type fakeSpanExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (f *fakeSpanExporter) Export(spans []*tracing.Span) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spans = append(f.spans, spans...)
+	return nil
+}
+
+func (f *fakeSpanExporter) Spans() []*tracing.Span {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.spans
+}
+
 var _ = Describe("Zipkin", func() {
 	var (
-		handler      negroni.Handler
-		headersToLog *[]string
-		logger       lager.Logger
-		resp         http.ResponseWriter
-		req          *http.Request
-		nextCalled   bool
+		handler    negroni.Handler
+		logger     lager.Logger
+		resp       utils.ProxyResponseWriter
+		alr        *schema.AccessLogRecord
+		req        *http.Request
+		nextCalled bool
 	)
 
 	nextHandler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
@@ -34,10 +61,11 @@ var _ = Describe("Zipkin", func() {
 	})
 
 	BeforeEach(func() {
-		headersToLog = &[]string{}
 		logger = lagertest.NewTestLogger("zipkin")
 		req = test_util.NewRequest("GET", "example.com", "/", nil)
-		resp = httptest.NewRecorder()
+		resp = utils.NewProxyResponseWriter(httptest.NewRecorder())
+		alr = &schema.AccessLogRecord{Request: req}
+		resp.AddToContext("AccessLogRecord", alr)
 		nextCalled = false
 	})
 
@@ -47,7 +75,7 @@ var _ = Describe("Zipkin", func() {
 
 	Context("with Zipkin enabled", func() {
 		BeforeEach(func() {
-			handler = handlers.NewZipkin(true, headersToLog, logger)
+			handler = handlers.NewZipkin(true, router_http.TraceFormatB3, nil, logger)
 		})
 
 		It("sets zipkin headers", func() {
@@ -55,13 +83,14 @@ var _ = Describe("Zipkin", func() {
 			Expect(req.Header.Get(router_http.B3SpanIdHeader)).ToNot(BeEmpty())
 			Expect(req.Header.Get(router_http.B3TraceIdHeader)).ToNot(BeEmpty())
 			Expect(req.Header.Get(router_http.B3ParentSpanIdHeader)).To(BeEmpty())
+			Expect(req.Header.Get(router_http.W3CTraceParentHeader)).To(BeEmpty())
 		})
 
-		It("adds zipkin headers to access log record", func() {
+		It("adds the trace and span ids to the access log record", func() {
 			handler.ServeHTTP(resp, req, nextHandler)
-			Expect(*headersToLog).To(ContainElement(router_http.B3SpanIdHeader))
-			Expect(*headersToLog).To(ContainElement(router_http.B3TraceIdHeader))
-			Expect(*headersToLog).To(ContainElement(router_http.B3ParentSpanIdHeader))
+			Expect(alr.TraceId).To(Equal(req.Header.Get(router_http.B3TraceIdHeader)))
+			Expect(alr.SpanId).To(Equal(req.Header.Get(router_http.B3SpanIdHeader)))
+			Expect(alr.B3Headers).To(BeTrue())
 		})
 
 		Context("with B3TraceIdHeader and B3SpanIdHeader already set", func() {
@@ -104,23 +133,90 @@ var _ = Describe("Zipkin", func() {
 			})
 		})
 
-		Context("when X-B3-* headers are already set to be logged", func() {
+		Context("with only a traceparent header set", func() {
 			BeforeEach(func() {
-				newSlice := []string{router_http.B3TraceIdHeader, router_http.B3SpanIdHeader, router_http.B3ParentSpanIdHeader}
-				headersToLog = &newSlice
+				req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
 			})
-			It("adds zipkin headers to access log record", func() {
+
+			It("continues the trace in the B3 headers", func() {
 				handler.ServeHTTP(resp, req, nextHandler)
-				Expect(*headersToLog).To(ContainElement(router_http.B3SpanIdHeader))
-				Expect(*headersToLog).To(ContainElement(router_http.B3TraceIdHeader))
-				Expect(*headersToLog).To(ContainElement(router_http.B3ParentSpanIdHeader))
+				Expect(req.Header.Get(router_http.B3TraceIdHeader)).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+				Expect(req.Header.Get(router_http.B3ParentSpanIdHeader)).To(Equal("00f067aa0ba902b7"))
+				Expect(req.Header.Get(router_http.B3SampledHeader)).To(Equal("1"))
 			})
 		})
 	})
 
+	Context("with the w3c format", func() {
+		BeforeEach(func() {
+			handler = handlers.NewZipkin(true, router_http.TraceFormatW3C, nil, logger)
+		})
+
+		It("starts a new trace", func() {
+			handler.ServeHTTP(resp, req, nextHandler)
+			Expect(req.Header.Get("traceparent")).To(MatchRegexp(traceparent_regex))
+			Expect(req.Header.Get(router_http.B3TraceIdHeader)).To(BeEmpty())
+			Expect(alr.TraceId).To(HaveLen(32))
+			Expect(alr.B3Headers).To(BeFalse())
+		})
+
+		It("continues an incoming trace and keeps tracestate", func() {
+			req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+			req.Header.Set("tracestate", "congo=t61rcWkgMzE")
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			Expect(req.Header.Get("traceparent")).To(MatchRegexp(`^00-4bf92f3577b34da6a3ce929d0e0e4736-[[:xdigit:]]{16}-00$`))
+			Expect(req.Header.Get("traceparent")).NotTo(ContainSubstring("00f067aa0ba902b7"))
+			Expect(req.Header.Get("tracestate")).To(Equal("congo=t61rcWkgMzE"))
+			Expect(alr.SpanId).To(Equal(req.Header.Get("traceparent")[36:52]))
+		})
+
+		It("translates B3 headers", func() {
+			req.Header.Set(router_http.B3TraceIdHeader, "a3ce929d0e0e4736")
+			req.Header.Set(router_http.B3SpanIdHeader, "00f067aa0ba902b7")
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			Expect(req.Header.Get("traceparent")).To(MatchRegexp(`^00-0000000000000000a3ce929d0e0e4736-[[:xdigit:]]{16}-01$`))
+		})
+	})
+
+	Context("with both formats", func() {
+		BeforeEach(func() {
+			handler = handlers.NewZipkin(true, router_http.TraceFormatBoth, nil, logger)
+		})
+
+		It("writes the same span to both formats", func() {
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			traceId := req.Header.Get(router_http.B3TraceIdHeader)
+			spanId := req.Header.Get(router_http.B3SpanIdHeader)
+			Expect(req.Header.Get("traceparent")).To(Equal("00-" + traceId + "-" + spanId + "-01"))
+		})
+	})
+
+	Context("with the b3 single header format", func() {
+		BeforeEach(func() {
+			handler = handlers.NewZipkin(true, router_http.TraceFormatB3Single, nil, logger)
+		})
+
+		It("continues the trace in the b3 header", func() {
+			req.Header.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90")
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			Expect(req.Header.Get("b3")).To(MatchRegexp(`^80f198ee56343ba864fe8b2a57d3eff7-[[:xdigit:]]{16}-1-e457b5a2e4d86bd1$`))
+		})
+
+		It("keeps a deny sampling decision", func() {
+			req.Header.Set("b3", "0")
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			Expect(req.Header.Get("b3")).To(MatchRegexp(`^[[:xdigit:]]{16}-[[:xdigit:]]{16}-0$`))
+		})
+	})
+
 	Context("with Zipkin disabled", func() {
 		BeforeEach(func() {
-			handler = handlers.NewZipkin(false, headersToLog, logger)
+			handler = handlers.NewZipkin(false, router_http.TraceFormatBoth, nil, logger)
 		})
 
 		It("doesn't set any headers", func() {
@@ -128,26 +224,59 @@ var _ = Describe("Zipkin", func() {
 			Expect(req.Header.Get(router_http.B3SpanIdHeader)).To(BeEmpty())
 			Expect(req.Header.Get(router_http.B3TraceIdHeader)).To(BeEmpty())
 			Expect(req.Header.Get(router_http.B3ParentSpanIdHeader)).To(BeEmpty())
+			Expect(req.Header.Get("traceparent")).To(BeEmpty())
 		})
 
-		It("does not add zipkin headers to access log record", func() {
+		It("does not add trace ids to the access log record", func() {
 			handler.ServeHTTP(resp, req, nextHandler)
-			Expect(*headersToLog).NotTo(ContainElement(router_http.B3SpanIdHeader))
-			Expect(*headersToLog).NotTo(ContainElement(router_http.B3ParentSpanIdHeader))
-			Expect(*headersToLog).NotTo(ContainElement(router_http.B3TraceIdHeader))
+			Expect(alr.TraceId).To(BeEmpty())
+			Expect(alr.SpanId).To(BeEmpty())
 		})
+	})
 
-		Context("when X-B3-* headers are already set to be logged", func() {
-			BeforeEach(func() {
-				newSlice := []string{router_http.B3TraceIdHeader, router_http.B3SpanIdHeader, router_http.B3ParentSpanIdHeader}
-				headersToLog = &newSlice
-			})
-			It("adds zipkin headers to access log record", func() {
-				handler.ServeHTTP(resp, req, nextHandler)
-				Expect(*headersToLog).To(ContainElement(router_http.B3SpanIdHeader))
-				Expect(*headersToLog).To(ContainElement(router_http.B3ParentSpanIdHeader))
-				Expect(*headersToLog).To(ContainElement(router_http.B3TraceIdHeader))
+	Context("with a tracer", func() {
+		var (
+			exporter *fakeSpanExporter
+			process  ifrit.Process
+			tracer   *tracing.Tracer
+		)
+
+		BeforeEach(func() {
+			exporter = &fakeSpanExporter{}
+			tracer = tracing.New(exporter, router_http.TraceFormatB3, 1, 10, 1, 10*time.Millisecond, logger)
+			process = ifrit.Invoke(tracer)
+			handler = handlers.NewZipkin(true, router_http.TraceFormatB3, tracer, logger)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		})
+
+		It("records a server span for sampled requests", func() {
+			var span *tracing.Span
+			handler.ServeHTTP(resp, req, func(rw http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				span = tracing.FromContext(r.Context())
+				rw.WriteHeader(http.StatusTeapot)
 			})
+
+			Expect(req.Header.Get(router_http.B3SampledHeader)).To(Equal("1"))
+			Expect(span).NotTo(BeNil())
+			Expect(span.Id).To(Equal(req.Header.Get(router_http.B3SpanIdHeader)))
+
+			Eventually(exporter.Spans).Should(HaveLen(1))
+			Expect(exporter.Spans()[0].Kind).To(Equal(tracing.KindServer))
+			Expect(exporter.Spans()[0].Tags).To(HaveKeyWithValue("http.host", "example.com"))
+			Expect(exporter.Spans()[0].Tags).To(HaveKeyWithValue("http.status_code", "418"))
+		})
+
+		It("honours an incoming sampling decision", func() {
+			req.Header.Set(router_http.B3SampledHeader, "0")
+			handler.ServeHTTP(resp, req, nextHandler)
+
+			Expect(req.Header.Get(router_http.B3SampledHeader)).To(Equal("0"))
+			Consistently(exporter.Spans, 50*time.Millisecond).Should(BeEmpty())
 		})
 	})
 })
@@ -0,0 +1,140 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+type rule struct {
+	matcher route.Matcher
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+}
+
+// Filter checks client addresses against the allow and deny lists of the
+// first rule matching the request host and path.
+type Filter struct {
+	rules          []rule
+	trustedProxies []*net.IPNet
+}
+
+func NewFilter(rules []config.IPRule, trustedProxies []string) (*Filter, error) {
+	f := &Filter{}
+
+	var err error
+	f.trustedProxies, err = parseNets(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("trusted proxies: %s", err)
+	}
+
+	for i, c := range rules {
+		r := rule{matcher: route.NewMatcher(c.Host, c.Path)}
+
+		r.allow, err = parseNets(c.Allow)
+		if err != nil {
+			return nil, fmt.Errorf("ip rule %d: %s", i, err)
+		}
+		r.deny, err = parseNets(c.Deny)
+		if err != nil {
+			return nil, fmt.Errorf("ip rule %d: %s", i, err)
+		}
+
+		f.rules = append(f.rules, r)
+	}
+
+	return f, nil
+}
+
+// Allowed reports whether the client of the request may reach the route.
+// Requests that match no rule are allowed.
+func (f *Filter) Allowed(request *http.Request) bool {
+	if f == nil || len(f.rules) == 0 {
+		return true
+	}
+
+	for _, r := range f.rules {
+		if !r.matcher.Match(request.Host, request.URL.Path) {
+			continue
+		}
+
+		ip := f.ClientIP(request)
+		if ip == nil {
+			return false
+		}
+		if contains(r.deny, ip) {
+			return false
+		}
+		return len(r.allow) == 0 || contains(r.allow, ip)
+	}
+
+	return true
+}
+
+// ClientIP returns the address of the connection, which is the PROXY
+// protocol source address when enabled. When that address is a trusted
+// proxy, the right-most X-Forwarded-For hop that is not a trusted proxy is
+// used instead.
+func (f *Filter) ClientIP(request *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		host = request.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !contains(f.trustedProxies, ip) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(request.Header["X-Forwarded-For"], ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !contains(f.trustedProxies, hop) {
+			break
+		}
+	}
+	return ip
+}
+
+func parseNets(cidrs []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
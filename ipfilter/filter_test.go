@@ -0,0 +1,123 @@
+package ipfilter_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/ipfilter"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter", func() {
+	var (
+		rules          []config.IPRule
+		trustedProxies []string
+		filter         *ipfilter.Filter
+		req            *http.Request
+	)
+
+	BeforeEach(func() {
+		rules = []config.IPRule{
+			{
+				Host:  "admin.example.com",
+				Allow: []string{"10.0.0.0/8", "2001:db8::/32"},
+				Deny:  []string{"10.1.0.0/16"},
+			},
+			{
+				Host: "*.example.com",
+				Path: "/internal",
+				Deny: []string{"0.0.0.0/0"},
+			},
+		}
+		trustedProxies = []string{"192.168.0.1", "192.168.1.0/24"}
+
+		var err error
+		req, err = http.NewRequest("GET", "http://admin.example.com/", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.RemoteAddr = "10.2.3.4:5678"
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		filter, err = ipfilter.NewFilter(rules, trustedProxies)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Allowed", func() {
+		It("allows addresses in the allow list", func() {
+			Expect(filter.Allowed(req)).To(BeTrue())
+
+			req.RemoteAddr = "[2001:db8::1]:5678"
+			Expect(filter.Allowed(req)).To(BeTrue())
+		})
+
+		It("denies addresses outside of the allow list", func() {
+			req.RemoteAddr = "172.16.0.1:5678"
+			Expect(filter.Allowed(req)).To(BeFalse())
+		})
+
+		It("checks the deny list first", func() {
+			req.RemoteAddr = "10.1.2.3:5678"
+			Expect(filter.Allowed(req)).To(BeFalse())
+		})
+
+		It("scopes rules by host and path", func() {
+			req.Host = "app.example.com"
+			req.RemoteAddr = "172.16.0.1:5678"
+			Expect(filter.Allowed(req)).To(BeTrue())
+
+			req.URL.Path = "/internal/status"
+			Expect(filter.Allowed(req)).To(BeFalse())
+		})
+
+		It("matches the path of rules case insensitively", func() {
+			req.Host = "app.example.com"
+			req.RemoteAddr = "172.16.0.1:5678"
+			for _, path := range []string{"/INTERNAL/x", "/Internal"} {
+				req.URL.Path = path
+				Expect(filter.Allowed(req)).To(BeFalse(), path)
+			}
+		})
+
+		It("allows requests when there is no filter", func() {
+			var nilFilter *ipfilter.Filter
+			Expect(nilFilter.Allowed(req)).To(BeTrue())
+		})
+	})
+
+	Describe("ClientIP", func() {
+		It("uses the connection address", func() {
+			req.Header.Set("X-Forwarded-For", "10.9.9.9")
+			Expect(filter.ClientIP(req).String()).To(Equal("10.2.3.4"))
+		})
+
+		Context("when the connection comes from a trusted proxy", func() {
+			BeforeEach(func() {
+				req.RemoteAddr = "192.168.0.1:5678"
+			})
+
+			It("uses the right-most untrusted X-Forwarded-For hop", func() {
+				req.Header.Add("X-Forwarded-For", "1.1.1.1, 172.16.0.1")
+				req.Header.Add("X-Forwarded-For", "192.168.1.5")
+				Expect(filter.ClientIP(req).String()).To(Equal("172.16.0.1"))
+				Expect(filter.Allowed(req)).To(BeFalse())
+			})
+
+			It("uses the proxy address when there is no X-Forwarded-For header", func() {
+				Expect(filter.ClientIP(req).String()).To(Equal("192.168.0.1"))
+			})
+		})
+	})
+
+	Describe("NewFilter", func() {
+		It("fails on an invalid CIDR", func() {
+			_, err := ipfilter.NewFilter([]config.IPRule{{Allow: []string{"10.0.0.0/33"}}}, nil)
+			Expect(err).To(HaveOccurred())
+
+			_, err = ipfilter.NewFilter(nil, []string{"not-an-ip"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
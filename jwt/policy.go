@@ -0,0 +1,132 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+var ErrMissingToken = errors.New("missing bearer token")
+
+// Policy requires a valid bearer token for the routes matching its host and
+// path.
+type Policy struct {
+	matcher      route.Matcher
+	keys         *KeySet
+	issuer       string
+	audiences    []string
+	claimHeaders map[string]string
+	clockSkew    time.Duration
+	requireExp   bool
+}
+
+// Policies holds the JWT policies in the order they were configured.
+type Policies struct {
+	policies []*Policy
+}
+
+func NewPolicies(c config.JWTConfig) (*Policies, error) {
+	keySets := make(map[string]*KeySet)
+	for _, ks := range c.KeySets {
+		keys, err := LoadKeySet(ks.JWKSFiles, ks.PublicKeyFiles)
+		if err != nil {
+			return nil, fmt.Errorf("key set %q: %s", ks.Name, err)
+		}
+		keySets[ks.Name] = keys
+	}
+
+	p := &Policies{}
+	for i, pc := range c.Policies {
+		keys, ok := keySets[pc.KeySet]
+		if !ok {
+			return nil, fmt.Errorf("jwt policy %d: unknown key set %q", i, pc.KeySet)
+		}
+
+		claimHeaders := make(map[string]string)
+		for claim, name := range pc.ClaimHeaders {
+			claimHeaders[claim] = http.CanonicalHeaderKey(name)
+		}
+
+		p.policies = append(p.policies, &Policy{
+			matcher:      route.NewMatcher(pc.Host, pc.Path),
+			keys:         keys,
+			issuer:       pc.Issuer,
+			audiences:    pc.Audiences,
+			claimHeaders: claimHeaders,
+			clockSkew:    c.ClockSkew,
+			requireExp:   pc.ExpRequired(),
+		})
+	}
+
+	return p, nil
+}
+
+// Match returns the first policy matching the request, or nil.
+func (p *Policies) Match(request *http.Request) *Policy {
+	if p == nil {
+		return nil
+	}
+
+	for _, policy := range p.policies {
+		if policy.matcher.Match(request.Host, request.URL.Path) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// Authenticate verifies the bearer token of the request.
+func (p *Policy) Authenticate(request *http.Request) (Claims, error) {
+	token := bearerToken(request)
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims, err := Verify(token, p.keys)
+	if err != nil {
+		return nil, err
+	}
+
+	err = claims.Validate(time.Now(), p.clockSkew, p.requireExp, p.issuer, p.audiences)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// StripClaimHeaders removes client supplied copies of the claim headers of
+// every policy, so that they cannot reach a backend whatever route the
+// request ends up on.
+func (p *Policies) StripClaimHeaders(header http.Header) {
+	if p == nil {
+		return
+	}
+
+	for _, policy := range p.policies {
+		for _, name := range policy.claimHeaders {
+			header.Del(name)
+		}
+	}
+}
+
+// SetClaimHeaders passes the selected claims to the backend.
+func (p *Policy) SetClaimHeaders(header http.Header, claims Claims) {
+	for claim, name := range p.claimHeaders {
+		if value, ok := claims.String(claim); ok {
+			header.Set(name, value)
+		}
+	}
+}
+
+func bearerToken(request *http.Request) string {
+	auth := request.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package jwt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/jwt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Policies", func() {
+	var (
+		dir      string
+		key      *rsa.PrivateKey
+		pemKey   *rsa.PrivateKey
+		cfg      config.JWTConfig
+		policies *jwt.Policies
+		req      *http.Request
+		claims   map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "jwt")
+		Expect(err).NotTo(HaveOccurred())
+
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		jwks, err := json.Marshal(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   encode(key.PublicKey.N.Bytes()),
+				"e":   encode(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(dir, "jwks.json"), jwks, 0644)).To(Succeed())
+
+		pemKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.MarshalPKIXPublicKey(&pemKey.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		Expect(ioutil.WriteFile(filepath.Join(dir, "key.pem"), pemBytes, 0644)).To(Succeed())
+
+		cfg = config.JWTConfig{
+			KeySets: []config.JWTKeySetConfig{{
+				Name:           "uaa",
+				JWKSFiles:      []string{filepath.Join(dir, "jwks.json")},
+				PublicKeyFiles: []string{filepath.Join(dir, "key.pem")},
+			}},
+			Policies: []config.JWTPolicyConfig{{
+				Host:         "api.example.com",
+				Path:         "/orders",
+				KeySet:       "uaa",
+				Issuer:       "https://uaa.example.com",
+				Audiences:    []string{"orders"},
+				ClaimHeaders: map[string]string{"sub": "x-user-id"},
+			}},
+		}
+
+		claims = map[string]interface{}{
+			"sub": "user-1",
+			"iss": "https://uaa.example.com",
+			"aud": "orders",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+
+		req, err = http.NewRequest("GET", "http://api.example.com/orders/42", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		policies, err = jwt.NewPolicies(cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("matches requests by host and path", func() {
+		Expect(policies.Match(req)).NotTo(BeNil())
+
+		req.URL.Path = "/ORDERS/42"
+		Expect(policies.Match(req)).NotTo(BeNil())
+
+		req.URL.Path = "/other"
+		Expect(policies.Match(req)).To(BeNil())
+	})
+
+	It("authenticates tokens signed with keys from JWKS and PEM files", func() {
+		policy := policies.Match(req)
+
+		req.Header.Set("Authorization", "Bearer "+sign("RS256", "key-1", key, claims))
+		c, err := policy.Authenticate(req)
+		Expect(err).NotTo(HaveOccurred())
+
+		req.Header.Set("Authorization", "bearer "+sign("RS256", "", pemKey, claims))
+		_, err = policy.Authenticate(req)
+		Expect(err).NotTo(HaveOccurred())
+
+		req.Header.Set("X-User-Id", "spoofed")
+		policies.StripClaimHeaders(req.Header)
+		Expect(req.Header.Get("X-User-Id")).To(BeEmpty())
+
+		policy.SetClaimHeaders(req.Header, c)
+		Expect(req.Header.Get("X-User-Id")).To(Equal("user-1"))
+	})
+
+	It("rejects requests without a token", func() {
+		_, err := policies.Match(req).Authenticate(req)
+		Expect(err).To(Equal(jwt.ErrMissingToken))
+	})
+
+	It("rejects tokens without exp unless the policy allows them", func() {
+		delete(claims, "exp")
+		req.Header.Set("Authorization", "Bearer "+sign("RS256", "key-1", key, claims))
+
+		_, err := policies.Match(req).Authenticate(req)
+		Expect(err).To(Equal(jwt.ErrMissingExp))
+
+		requireExp := false
+		cfg.Policies[0].RequireExp = &requireExp
+		policies, err = jwt.NewPolicies(cfg)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = policies.Match(req).Authenticate(req)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects tokens for another audience", func() {
+		claims["aud"] = "billing"
+		req.Header.Set("Authorization", "Bearer "+sign("RS256", "key-1", key, claims))
+
+		_, err := policies.Match(req).Authenticate(req)
+		Expect(err).To(Equal(jwt.ErrInvalidAudience))
+	})
+
+	It("fails on an unknown key set", func() {
+		cfg.Policies[0].KeySet = "missing"
+		_, err := jwt.NewPolicies(cfg)
+		Expect(err).To(HaveOccurred())
+	})
+})
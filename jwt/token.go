@@ -0,0 +1,244 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed        = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("token is expired")
+	ErrMissingExp       = errors.New("token has no expiration")
+	ErrNotYetValid      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid issuer")
+	ErrInvalidAudience  = errors.New("invalid audience")
+)
+
+// Claims are the decoded claims of a verified token.
+type Claims map[string]interface{}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks the signature of a compact JWS against the key set and
+// returns its claims. Time based and issuer/audience checks are done by
+// Claims.Validate.
+func Verify(token string, keys *KeySet) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := json.Unmarshal(headerJSON, &h); err != nil {
+		return nil, ErrMalformed
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	hash, ok := algHashes[h.Alg]
+	if !ok {
+		return nil, ErrUnsupportedAlg
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys.candidates(h.Kid) {
+		if key.Alg != "" && key.Alg != h.Alg {
+			continue
+		}
+		if verifySignature(h.Alg, hash, key.Key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrInvalidSignature
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var claims Claims
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, ErrMalformed
+	}
+	return claims, nil
+}
+
+var algHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+}
+
+// verifySignature only accepts keys whose type matches the algorithm family,
+// so that a public key can never be used as an HMAC secret.
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed, signature []byte) bool {
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(pub, hash, h.Sum(nil), signature, nil) == nil
+		}
+		return rsa.VerifyPKCS1v15(pub, hash, h.Sum(nil), signature) == nil
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return false
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pub, h.Sum(nil), r, s)
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(hash.New, secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	return false
+}
+
+// Validate checks exp and nbf, allowing for clock skew, and the issuer and
+// audience when they are given. Tokens without exp are only accepted when
+// requireExp is false.
+func (c Claims) Validate(now time.Time, skew time.Duration, requireExp bool, issuer string, audiences []string) error {
+	exp, ok := c.time("exp")
+	if !ok && requireExp {
+		return ErrMissingExp
+	}
+	if ok && !now.Before(exp.Add(skew)) {
+		return ErrExpired
+	}
+	if nbf, ok := c.time("nbf"); ok && now.Add(skew).Before(nbf) {
+		return ErrNotYetValid
+	}
+
+	if issuer != "" {
+		if iss, _ := c["iss"].(string); iss != issuer {
+			return ErrInvalidIssuer
+		}
+	}
+
+	if len(audiences) > 0 && !c.hasAudience(audiences) {
+		return ErrInvalidAudience
+	}
+	return nil
+}
+
+func (c Claims) time(name string) (time.Time, bool) {
+	n, ok := c[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+func (c Claims) hasAudience(audiences []string) bool {
+	var aud []string
+	switch v := c["aud"].(type) {
+	case string:
+		aud = []string{v}
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+	}
+
+	for _, a := range aud {
+		for _, expected := range audiences {
+			if a == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String formats a claim as a header value. Lists are joined with commas
+// and objects are encoded as JSON.
+func (c Claims) String(name string) (string, bool) {
+	v, ok := c[name]
+	if !ok || v == nil {
+		return "", false
+	}
+
+	switch value := v.(type) {
+	case string:
+		return value, true
+	case json.Number:
+		return value.String(), true
+	case bool:
+		return fmt.Sprintf("%t", value), true
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			} else {
+				b, _ := json.Marshal(item)
+				values = append(values, string(b))
+			}
+		}
+		return strings.Join(values, ","), true
+	default:
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", false
+		}
+		return string(b), true
+	}
+}
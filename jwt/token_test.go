@@ -0,0 +1,129 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/jwt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		rsaKey *rsa.PrivateKey
+		ecKey  *ecdsa.PrivateKey
+		keys   *jwt.KeySet
+		claims map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		keys = jwt.NewKeySet(
+			jwt.Key{Id: "rsa", Key: &rsaKey.PublicKey},
+			jwt.Key{Id: "ec", Key: &ecKey.PublicKey},
+		)
+		claims = map[string]interface{}{"sub": "user-1", "scope": []string{"read", "write"}}
+	})
+
+	It("verifies RS256 and ES256 signatures", func() {
+		c, err := jwt.Verify(sign("RS256", "rsa", rsaKey, claims), keys)
+		Expect(err).NotTo(HaveOccurred())
+		sub, _ := c.String("sub")
+		Expect(sub).To(Equal("user-1"))
+		scope, _ := c.String("scope")
+		Expect(scope).To(Equal("read,write"))
+
+		_, err = jwt.Verify(sign("ES256", "ec", ecKey, claims), keys)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("tries every key when the token has no key id", func() {
+		_, err := jwt.Verify(sign("ES256", "", ecKey, claims), keys)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects tokens signed with another key", func() {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = jwt.Verify(sign("RS256", "rsa", other, claims), keys)
+		Expect(err).To(Equal(jwt.ErrInvalidSignature))
+	})
+
+	It("does not accept a public key as an HMAC secret", func() {
+		token := sign("HS256", "rsa", []byte("secret"), claims)
+		_, err := jwt.Verify(token, keys)
+		Expect(err).To(Equal(jwt.ErrInvalidSignature))
+	})
+
+	It("verifies HS256 with a symmetric key", func() {
+		keys = jwt.NewKeySet(jwt.Key{Id: "hmac", Key: []byte("secret")})
+		_, err := jwt.Verify(sign("HS256", "hmac", []byte("secret"), claims), keys)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("rejects unsigned and malformed tokens", func() {
+		_, err := jwt.Verify("abc", keys)
+		Expect(err).To(Equal(jwt.ErrMalformed))
+
+		token := sign("RS256", "rsa", rsaKey, claims)
+		header := encode([]byte(`{"alg":"none"}`))
+		_, err = jwt.Verify(header+token[len(header):], keys)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Claims", func() {
+	var (
+		now    time.Time
+		claims jwt.Claims
+	)
+
+	BeforeEach(func() {
+		now = time.Unix(1000000, 0)
+		claims = jwt.Claims{
+			"iss": "https://issuer.example.com",
+			"aud": []interface{}{"orders", "billing"},
+			"exp": json.Number("1000100"),
+			"nbf": json.Number("999900"),
+		}
+	})
+
+	It("accepts valid claims", func() {
+		Expect(claims.Validate(now, 0, true, "https://issuer.example.com", []string{"billing"})).To(Succeed())
+	})
+
+	It("rejects expired tokens, allowing for clock skew", func() {
+		Expect(claims.Validate(now.Add(200*time.Second), 0, true, "", nil)).To(Equal(jwt.ErrExpired))
+		Expect(claims.Validate(now.Add(200*time.Second), 150*time.Second, true, "", nil)).To(Succeed())
+	})
+
+	It("rejects tokens that are not valid yet", func() {
+		Expect(claims.Validate(now.Add(-200*time.Second), 0, true, "", nil)).To(Equal(jwt.ErrNotYetValid))
+	})
+
+	It("rejects tokens without exp unless it is optional", func() {
+		delete(claims, "exp")
+		Expect(claims.Validate(now, 0, true, "", nil)).To(Equal(jwt.ErrMissingExp))
+		Expect(claims.Validate(now, 0, false, "", nil)).To(Succeed())
+	})
+
+	It("checks the issuer and audience", func() {
+		Expect(claims.Validate(now, 0, true, "https://other.example.com", nil)).To(Equal(jwt.ErrInvalidIssuer))
+		Expect(claims.Validate(now, 0, true, "", []string{"admin"})).To(Equal(jwt.ErrInvalidAudience))
+
+		claims["aud"] = "admin"
+		Expect(claims.Validate(now, 0, true, "", []string{"admin"})).To(Succeed())
+	})
+})
@@ -3,6 +3,7 @@ package main
 import (
 	"crypto/tls"
 	"errors"
+	"net/http"
 	"net/url"
 	"sync/atomic"
 
@@ -10,16 +11,23 @@ import (
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/debugserver"
 	"code.cloudfoundry.org/gorouter/access_log"
+	"code.cloudfoundry.org/gorouter/common"
 	"code.cloudfoundry.org/gorouter/common/schema"
 	"code.cloudfoundry.org/gorouter/common/secure"
 	"code.cloudfoundry.org/gorouter/common/uuid"
 	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/ipfilter"
+	"code.cloudfoundry.org/gorouter/jwt"
 	"code.cloudfoundry.org/gorouter/mbus"
 	"code.cloudfoundry.org/gorouter/metrics/reporter"
 	"code.cloudfoundry.org/gorouter/proxy"
 	rregistry "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/rewrite"
 	"code.cloudfoundry.org/gorouter/route_fetcher"
 	"code.cloudfoundry.org/gorouter/router"
+	"code.cloudfoundry.org/gorouter/tracing"
 	rvarz "code.cloudfoundry.org/gorouter/varz"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/routing-api"
@@ -45,13 +53,6 @@ var configFile string
 
 var healthCheck int32
 
-const (
-	DEBUG = "debug"
-	INFO  = "info"
-	ERROR = "error"
-	FATAL = "fatal"
-)
-
 func main() {
 	flag.StringVar(&configFile, "c", "", "Configuration File")
 	cflager.AddFlags(flag.CommandLine)
@@ -68,8 +69,12 @@ func main() {
 	if c.Logging.Syslog != "" {
 		prefix = c.Logging.Syslog
 	}
-	logger, reconfigurableSink := cflager.New(prefix)
-	InitLoggerFromConfig(logger, c, logCounter)
+	// The sink of cflager only holds the level of stdout, which is set by the
+	// -logLevel flag and the debug server. Stdout is written through the
+	// LogLevel so that its changes and debug patterns apply there too.
+	_, reconfigurableSink := cflager.New(prefix)
+	logger := lager.NewLogger(prefix)
+	logLevel := InitLoggerFromConfig(logger, c, logCounter, reconfigurableSink.GetMinLevel)
 
 	logger.Info("starting")
 
@@ -92,21 +97,83 @@ func main() {
 	natsClient := connectToNatsServer(logger.Session("nats"), c, startMsgChan)
 
 	metricsReporter := metrics.NewMetricsReporter()
-	registry := rregistry.NewRouteRegistry(logger.Session("registry"), c, metricsReporter)
+	var proxyReporter reporter.ProxyReporter = metricsReporter
+	var registryReporter reporter.RouteRegistryReporter = metricsReporter
+	var accessLogReporter reporter.AccessLogReporter = metricsReporter
+	var tlsReporter reporter.TLSReporter = metricsReporter
+	var routeFetcherReporter reporter.RouteFetcherReporter
+	statusHandlers := map[string]http.Handler{
+		"/log_level": logLevel,
+	}
+
+	var prometheusReporter *metrics.PrometheusReporter
+	if c.Prometheus.Enabled {
+		prometheusReporter = metrics.NewPrometheusReporter()
+		prometheusReporter.AddGaugeFunc("gorouter_nats_connected", "Whether the router is connected to NATS.", func() float64 {
+			if natsClient.Status() == nats.CONNECTED {
+				return 1
+			}
+			return 0
+		})
+		proxyReporter = metrics.NewCompositeReporter(proxyReporter, prometheusReporter)
+		registryReporter = metrics.NewCompositeRegistryReporter(registryReporter, prometheusReporter)
+		accessLogReporter = metrics.NewCompositeAccessLogReporter(accessLogReporter, prometheusReporter)
+		tlsReporter = metrics.NewCompositeTLSReporter(tlsReporter, prometheusReporter)
+		routeFetcherReporter = prometheusReporter
+		statusHandlers["/metrics"] = prometheusReporter
+	}
+
+	var statsdReporter *metrics.StatsdReporter
+	if c.StatsD.Address != "" {
+		statsdReporter, err = metrics.NewStatsdReporter(c.StatsD, logger.Session("statsd"))
+		if err != nil {
+			logger.Fatal("error-creating-statsd-reporter", err)
+		}
+		proxyReporter = metrics.NewCompositeReporter(proxyReporter, statsdReporter)
+		registryReporter = metrics.NewCompositeRegistryReporter(registryReporter, statsdReporter)
+		accessLogReporter = metrics.NewCompositeAccessLogReporter(accessLogReporter, statsdReporter)
+		tlsReporter = metrics.NewCompositeTLSReporter(tlsReporter, statsdReporter)
+		if routeFetcherReporter == nil {
+			routeFetcherReporter = statsdReporter
+		} else {
+			routeFetcherReporter = metrics.NewCompositeRouteFetcherReporter(routeFetcherReporter, statsdReporter)
+		}
+	}
+
+	registry := rregistry.NewRouteRegistry(logger.Session("registry"), c, registryReporter)
 	if c.SuspendPruningIfNatsUnavailable {
 		registry.SuspendPruning(func() bool { return !(natsClient.Status() == nats.CONNECTED) })
 	}
+	if c.RegistrySnapshot.File != "" {
+		err = registry.RestoreSnapshot(c.RegistrySnapshot)
+		if err != nil {
+			logger.Error("registry-snapshot-ignored", err, lager.Data{"file": c.RegistrySnapshot.File})
+		}
+	}
+
+	statusHandlers["/routes/events"] = rregistry.NewEventStream(logger.Session("route-events"), registry, 15*time.Second)
+	statusHandlers[rregistry.AdminAPIPrefix] = rregistry.NewAdminAPI(registry)
 
 	subscriber := createSubscriber(logger, c, natsClient, registry, startMsgChan)
 
-	varz := rvarz.NewVarz(registry)
-	compositeReporter := metrics.NewCompositeReporter(varz, metricsReporter)
+	var breakdown *rvarz.Breakdown
+	if c.VarzBreakdown.ByApp || c.VarzBreakdown.ByRoute {
+		breakdown = rvarz.NewBreakdown(c.VarzBreakdown)
+		statusHandlers["/varz/breakdown"] = breakdown
+	}
+	varz := rvarz.NewVarzWithBreakdown(registry, breakdown)
+	compositeReporter := metrics.NewCompositeReporter(varz, proxyReporter)
 
-	accessLogger, err := access_log.CreateRunningAccessLogger(logger.Session("access-log"), c)
+	accessLogger, err := access_log.CreateRunningAccessLogger(logger.Session("access-log"), c, accessLogReporter)
 	if err != nil {
 		logger.Fatal("error-creating-access-logger", err)
 	}
 
+	accessLogFilter, err := access_log.NewFilter(c.AccessLog)
+	if err != nil {
+		logger.Fatal("error-creating-access-log-filter", err)
+	}
+
 	var crypto secure.Crypto
 	var cryptoPrev secure.Crypto
 	if c.RouteServiceEnabled {
@@ -116,9 +183,42 @@ func main() {
 		}
 	}
 
-	proxy := buildProxy(logger.Session("proxy"), c, registry, accessLogger, compositeReporter, crypto, cryptoPrev)
+	var errorPages *errorpage.Templates
+	if c.ErrorPages.Directory != "" {
+		errorPages, err = errorpage.NewTemplates(logger.Session("error-pages"), c.ErrorPages.Directory, c.ErrorPages.ReloadInterval)
+		if err != nil {
+			logger.Fatal("error-loading-error-pages", err)
+		}
+	}
+
+	headerRules, err := rewrite.NewHeaderRules(c.HeaderRules)
+	if err != nil {
+		logger.Fatal("error-creating-header-rules", err)
+	}
+
+	ipFilter, err := ipfilter.NewFilter(c.IPRules, c.TrustedProxies)
+	if err != nil {
+		logger.Fatal("error-creating-ip-filter", err)
+	}
+
+	jwtPolicies, err := jwt.NewPolicies(c.JWT)
+	if err != nil {
+		logger.Fatal("error-creating-jwt-policies", err)
+	}
+
+	corsPolicies, err := cors.NewPolicies(c.CORSPolicies)
+	if err != nil {
+		logger.Fatal("error-creating-cors-policies", err)
+	}
+
+	tracer, err := tracing.NewTracer(c.Tracing, logger.Session("tracer"))
+	if err != nil {
+		logger.Fatal("error-creating-tracer", err)
+	}
+
+	proxy := buildProxy(logger.Session("proxy"), c, registry, accessLogger, accessLogFilter, compositeReporter, crypto, cryptoPrev, errorPages, headerRules, ipFilter, jwtPolicies, corsPolicies, tracer)
 	healthCheck = 0
-	router, err := router.NewRouter(logger.Session("router"), c, proxy, natsClient, registry, varz, &healthCheck, logCounter, nil)
+	router, err := router.NewRouter(logger.Session("router"), c, proxy, natsClient, registry, varz, &healthCheck, logCounter, nil, statusHandlers, tlsReporter)
 	if err != nil {
 		logger.Fatal("initialize-router-error", err)
 	}
@@ -127,9 +227,23 @@ func main() {
 		grouper.Member{Name: "subscriber", Runner: subscriber},
 		grouper.Member{Name: "router", Runner: router},
 	}
+	if c.RegistrySnapshot.File != "" {
+		snapshotter := rregistry.NewSnapshotter(logger.Session("registry-snapshot"), registry, c.RegistrySnapshot)
+		members = append(members, grouper.Member{Name: "registry-snapshot", Runner: snapshotter})
+	}
+	if errorPages != nil {
+		members = append(members, grouper.Member{Name: "error-pages", Runner: errorPages})
+	}
+	if tracer != nil {
+		members = append(members, grouper.Member{Name: "tracer", Runner: tracer})
+	}
+	if statsdReporter != nil {
+		members = append(members, grouper.Member{Name: "statsd-reporter", Runner: statsdReporter})
+	}
 	if c.RoutingApiEnabled() {
 		logger.Info("setting-up-routing-api")
 		routeFetcher := setupRouteFetcher(logger.Session("route-fetcher"), c, registry)
+		routeFetcher.Reporter = routeFetcherReporter
 
 		// check connectivity to routing api
 		err = routeFetcher.FetchRoutes()
@@ -162,7 +276,7 @@ func createCrypto(logger lager.Logger, secret string) *secure.AesGCM {
 	return crypto
 }
 
-func buildProxy(logger lager.Logger, c *config.Config, registry rregistry.RegistryInterface, accessLogger access_log.AccessLogger, reporter reporter.ProxyReporter, crypto secure.Crypto, cryptoPrev secure.Crypto) proxy.Proxy {
+func buildProxy(logger lager.Logger, c *config.Config, registry rregistry.RegistryInterface, accessLogger access_log.AccessLogger, accessLogFilter *access_log.Filter, reporter reporter.ProxyReporter, crypto secure.Crypto, cryptoPrev secure.Crypto, errorPages *errorpage.Templates, headerRules *rewrite.HeaderRules, ipFilter *ipfilter.Filter, jwtPolicies *jwt.Policies, corsPolicies *cors.Policies, tracer *tracing.Tracer) proxy.Proxy {
 	args := proxy.ProxyArgs{
 		Logger:          logger,
 		EndpointTimeout: c.EndpointTimeout,
@@ -171,6 +285,7 @@ func buildProxy(logger lager.Logger, c *config.Config, registry rregistry.Regist
 		Registry:        registry,
 		Reporter:        reporter,
 		AccessLogger:    accessLogger,
+		AccessLogFilter: accessLogFilter,
 		SecureCookies:   c.SecureCookies,
 		TLSConfig: &tls.Config{
 			CipherSuites:       c.CipherSuites,
@@ -185,8 +300,18 @@ func buildProxy(logger lager.Logger, c *config.Config, registry rregistry.Regist
 		HealthCheckUserAgent:     c.HealthCheckUserAgent,
 		HeartbeatOK:              &healthCheck,
 		EnableZipkin:             c.Tracing.EnableZipkin,
+		TraceFormat:              c.Tracing.Format,
+		Tracer:                   tracer,
 		ForceForwardedProtoHttps: c.ForceForwardedProtoHttps,
 		DefaultLoadBalance:       c.LoadBalance,
+		ErrorPages:               errorPages,
+		HeaderRules:              headerRules,
+		HTTPSRedirectDomains:     c.HTTPSRedirect.Domains,
+		HTTPSRedirectStatusCode:  c.HTTPSRedirect.StatusCode,
+		IPFilter:                 ipFilter,
+		JWTPolicies:              jwtPolicies,
+		CORSPolicies:             corsPolicies,
+		RequestIdHeader:          c.RequestId.Header,
 	}
 	return proxy.NewProxy(args)
 }
@@ -309,29 +434,29 @@ func connectToNatsServer(logger lager.Logger, c *config.Config, startMsg chan<-
 	return natsClient
 }
 
-func InitLoggerFromConfig(logger lager.Logger, c *config.Config, logCounter *schema.LogCounter) {
+// InitLoggerFromConfig registers the sinks of the log file, stdout and the
+// log counter. The levels of the log file and of stdout can be changed at
+// runtime through the returned LogLevel.
+func InitLoggerFromConfig(logger lager.Logger, c *config.Config, logCounter *schema.LogCounter, stdoutLevel func() lager.LogLevel) *common.LogLevel {
+	level, err := common.ParseLogLevel(c.Logging.Level)
+	if err != nil {
+		panic(err)
+	}
+
+	var fileSink lager.Sink
 	if c.Logging.File != "" {
 		file, err := os.OpenFile(c.Logging.File, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
 		if err != nil {
 			logger.Fatal("error-opening-log-file", err, lager.Data{"file": c.Logging.File})
 		}
-		var logLevel lager.LogLevel
-		switch c.Logging.Level {
-		case DEBUG:
-			logLevel = lager.DEBUG
-		case INFO:
-			logLevel = lager.INFO
-		case ERROR:
-			logLevel = lager.ERROR
-		case FATAL:
-			logLevel = lager.FATAL
-		default:
-			panic(fmt.Errorf("unknown log level: %s", c.Logging.Level))
-		}
-		logger.RegisterSink(lager.NewWriterSink(file, logLevel))
+		fileSink = lager.NewWriterSink(file, lager.DEBUG)
 	}
+	logLevel := common.NewLogLevel(fileSink, level, logger.Session("log-level"))
+	logLevel.AddSink(lager.NewWriterSink(os.Stdout, lager.DEBUG), stdoutLevel)
+	logger.RegisterSink(logLevel)
 
 	logger.RegisterSink(logCounter)
+	return logLevel
 }
 
 func createSubscriber(
@@ -59,5 +59,25 @@ var _ = Describe("RegistryMessage", func() {
 				Expect(message.ValidateMessage()).To(BeFalse())
 			})
 		})
+
+		Describe("With a payload with a redirect", func() {
+			BeforeEach(func() {
+				payload = []byte(`{"app":"app1","uris":["old.com"],"host":"1.2.3.4","port":1234,"redirect":{"status_code":308,"target":"https://new.com{{.RequestURI}}"}}`)
+			})
+
+			It("passes validation", func() {
+				Expect(message.ValidateMessage()).To(BeTrue())
+			})
+		})
+
+		Describe("With a payload with an invalid redirect status code", func() {
+			BeforeEach(func() {
+				payload = []byte(`{"app":"app1","uris":["old.com"],"host":"1.2.3.4","port":1234,"redirect":{"status_code":200,"target":"https://new.com"}}`)
+			})
+
+			It("fails validation", func() {
+				Expect(message.ValidateMessage()).To(BeFalse())
+			})
+		})
 	})
 })
@@ -27,10 +27,17 @@ type RegistryMessage struct {
 	RouteServiceURL         string            `json:"route_service_url"`
 	PrivateInstanceID       string            `json:"private_instance_id"`
 	PrivateInstanceIndex    string            `json:"private_instance_index"`
+	Redirect                *RedirectMessage  `json:"redirect,omitempty"`
+}
+
+// RedirectMessage registers the uris as redirect routes answered by the router
+type RedirectMessage struct {
+	StatusCode int    `json:"status_code"`
+	Target     string `json:"target"`
 }
 
 func (rm *RegistryMessage) makeEndpoint() *route.Endpoint {
-	return route.NewEndpoint(
+	endpoint := route.NewEndpoint(
 		rm.App,
 		rm.Host,
 		rm.Port,
@@ -40,10 +47,20 @@ func (rm *RegistryMessage) makeEndpoint() *route.Endpoint {
 		rm.StaleThresholdInSeconds,
 		rm.RouteServiceURL,
 		models.ModificationTag{})
+
+	if rm.Redirect != nil {
+		endpoint.Redirect, _ = route.NewRedirect(rm.Redirect.StatusCode, rm.Redirect.Target)
+	}
+	return endpoint
 }
 
 // ValidateMessage checks to ensure the registry message is valid
 func (rm *RegistryMessage) ValidateMessage() bool {
+	if rm.Redirect != nil {
+		if _, err := route.NewRedirect(rm.Redirect.StatusCode, rm.Redirect.Target); err != nil {
+			return false
+		}
+	}
 	return rm.RouteServiceURL == "" || strings.HasPrefix(rm.RouteServiceURL, "https")
 }
 
@@ -206,7 +223,7 @@ func createRegistryMessage(data []byte) (*RegistryMessage, error) {
 	}
 
 	if !msg.ValidateMessage() {
-		return nil, errors.New("Unable to validate message. route_service_url must be https")
+		return nil, errors.New("Unable to validate message. route_service_url must be https and redirect must have a valid status_code and target")
 	}
 
 	return &msg, nil
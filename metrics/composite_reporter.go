@@ -30,6 +30,11 @@ func (c *CompositeReporter) CaptureBadGateway(req *http.Request) {
 	c.second.CaptureBadGateway(req)
 }
 
+func (c *CompositeReporter) CaptureForbidden(req *http.Request) {
+	c.first.CaptureForbidden(req)
+	c.second.CaptureForbidden(req)
+}
+
 func (c *CompositeReporter) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
 	c.first.CaptureRoutingRequest(b, req)
 	c.second.CaptureRoutingRequest(b, req)
@@ -39,3 +44,96 @@ func (c *CompositeReporter) CaptureRoutingResponse(b *route.Endpoint, res *http.
 	c.first.CaptureRoutingResponse(b, res, t, d)
 	c.second.CaptureRoutingResponse(b, res, t, d)
 }
+
+func (c *CompositeReporter) CaptureUpstreamConnectTime(d time.Duration) {
+	c.first.CaptureUpstreamConnectTime(d)
+	c.second.CaptureUpstreamConnectTime(d)
+}
+
+func (c *CompositeReporter) CaptureUpstreamAttemptFailed() {
+	c.first.CaptureUpstreamAttemptFailed()
+	c.second.CaptureUpstreamAttemptFailed()
+}
+
+type CompositeRegistryReporter struct {
+	first  reporter.RouteRegistryReporter
+	second reporter.RouteRegistryReporter
+}
+
+func NewCompositeRegistryReporter(first, second reporter.RouteRegistryReporter) reporter.RouteRegistryReporter {
+	return &CompositeRegistryReporter{
+		first:  first,
+		second: second,
+	}
+}
+
+func (c *CompositeRegistryReporter) CaptureRouteStats(totalRoutes int, msSinceLastUpdate uint64) {
+	c.first.CaptureRouteStats(totalRoutes, msSinceLastUpdate)
+	c.second.CaptureRouteStats(totalRoutes, msSinceLastUpdate)
+}
+
+func (c *CompositeRegistryReporter) CaptureLookupTime(t time.Duration) {
+	c.first.CaptureLookupTime(t)
+	c.second.CaptureLookupTime(t)
+}
+
+func (c *CompositeRegistryReporter) CaptureRegistryMessage(msg reporter.ComponentTagged) {
+	c.first.CaptureRegistryMessage(msg)
+	c.second.CaptureRegistryMessage(msg)
+}
+
+type CompositeRouteFetcherReporter struct {
+	first  reporter.RouteFetcherReporter
+	second reporter.RouteFetcherReporter
+}
+
+func NewCompositeRouteFetcherReporter(first, second reporter.RouteFetcherReporter) reporter.RouteFetcherReporter {
+	return &CompositeRouteFetcherReporter{
+		first:  first,
+		second: second,
+	}
+}
+
+func (c *CompositeRouteFetcherReporter) CaptureRouteFetcherError(name string) {
+	c.first.CaptureRouteFetcherError(name)
+	c.second.CaptureRouteFetcherError(name)
+}
+
+type CompositeAccessLogReporter struct {
+	first  reporter.AccessLogReporter
+	second reporter.AccessLogReporter
+}
+
+func NewCompositeAccessLogReporter(first, second reporter.AccessLogReporter) reporter.AccessLogReporter {
+	return &CompositeAccessLogReporter{
+		first:  first,
+		second: second,
+	}
+}
+
+func (c *CompositeAccessLogReporter) CaptureAccessLogDropped(sink string) {
+	c.first.CaptureAccessLogDropped(sink)
+	c.second.CaptureAccessLogDropped(sink)
+}
+
+func (c *CompositeAccessLogReporter) CaptureAccessLogDelayed(sink string) {
+	c.first.CaptureAccessLogDelayed(sink)
+	c.second.CaptureAccessLogDelayed(sink)
+}
+
+type CompositeTLSReporter struct {
+	first  reporter.TLSReporter
+	second reporter.TLSReporter
+}
+
+func NewCompositeTLSReporter(first, second reporter.TLSReporter) reporter.TLSReporter {
+	return &CompositeTLSReporter{
+		first:  first,
+		second: second,
+	}
+}
+
+func (c *CompositeTLSReporter) CaptureTLSHandshakeFailure(listener, reason string) {
+	c.first.CaptureTLSHandshakeFailure(listener, reason)
+	c.second.CaptureTLSHandshakeFailure(listener, reason)
+}
@@ -57,6 +57,15 @@ var _ = Describe("CompositeReporter", func() {
 		Expect(fakeReporter2.CaptureBadGatewayArgsForCall(0)).To(Equal(req))
 	})
 
+	It("forwards CaptureForbidden to both reporters", func() {
+		composite.CaptureForbidden(req)
+		Expect(fakeReporter1.CaptureForbiddenCallCount()).To(Equal(1))
+		Expect(fakeReporter2.CaptureForbiddenCallCount()).To(Equal(1))
+
+		Expect(fakeReporter1.CaptureForbiddenArgsForCall(0)).To(Equal(req))
+		Expect(fakeReporter2.CaptureForbiddenArgsForCall(0)).To(Equal(req))
+	})
+
 	It("forwards CaptureRoutingRequest to both reporters", func() {
 		composite.CaptureRoutingRequest(endpoint, req)
 		Expect(fakeReporter1.CaptureRoutingRequestCallCount()).To(Equal(1))
@@ -89,4 +98,125 @@ var _ = Describe("CompositeReporter", func() {
 		Expect(callTime).To(Equal(responseTime))
 		Expect(callDuration).To(Equal(responseDuration))
 	})
+
+	It("forwards CaptureUpstreamConnectTime and CaptureUpstreamAttemptFailed to both reporters", func() {
+		composite.CaptureUpstreamConnectTime(responseDuration)
+		composite.CaptureUpstreamAttemptFailed()
+
+		Expect(fakeReporter1.CaptureUpstreamConnectTimeArgsForCall(0)).To(Equal(responseDuration))
+		Expect(fakeReporter2.CaptureUpstreamConnectTimeArgsForCall(0)).To(Equal(responseDuration))
+		Expect(fakeReporter1.CaptureUpstreamAttemptFailedCallCount()).To(Equal(1))
+		Expect(fakeReporter2.CaptureUpstreamAttemptFailedCallCount()).To(Equal(1))
+	})
+})
+
+var _ = Describe("CompositeRegistryReporter", func() {
+	var fakeReporter1 *fakes.FakeRouteRegistryReporter
+	var fakeReporter2 *fakes.FakeRouteRegistryReporter
+	var composite reporter.RouteRegistryReporter
+
+	BeforeEach(func() {
+		fakeReporter1 = new(fakes.FakeRouteRegistryReporter)
+		fakeReporter2 = new(fakes.FakeRouteRegistryReporter)
+
+		composite = metrics.NewCompositeRegistryReporter(fakeReporter1, fakeReporter2)
+	})
+
+	It("forwards CaptureRouteStats to both reporters", func() {
+		composite.CaptureRouteStats(3, 100)
+
+		totalRoutes, msSinceLastUpdate := fakeReporter1.CaptureRouteStatsArgsForCall(0)
+		Expect(totalRoutes).To(Equal(3))
+		Expect(msSinceLastUpdate).To(BeEquivalentTo(100))
+
+		totalRoutes, msSinceLastUpdate = fakeReporter2.CaptureRouteStatsArgsForCall(0)
+		Expect(totalRoutes).To(Equal(3))
+		Expect(msSinceLastUpdate).To(BeEquivalentTo(100))
+	})
+
+	It("forwards CaptureLookupTime to both reporters", func() {
+		composite.CaptureLookupTime(time.Millisecond)
+
+		Expect(fakeReporter1.CaptureLookupTimeArgsForCall(0)).To(Equal(time.Millisecond))
+		Expect(fakeReporter2.CaptureLookupTimeArgsForCall(0)).To(Equal(time.Millisecond))
+	})
+
+	It("forwards CaptureRegistryMessage to both reporters", func() {
+		endpoint := route.NewEndpoint("someId", "host", 2222, "privateId", "2", map[string]string{"component": "dea-1"}, 30, "", models.ModificationTag{})
+		composite.CaptureRegistryMessage(endpoint)
+
+		Expect(fakeReporter1.CaptureRegistryMessageArgsForCall(0)).To(Equal(endpoint))
+		Expect(fakeReporter2.CaptureRegistryMessageArgsForCall(0)).To(Equal(endpoint))
+	})
+})
+
+var _ = Describe("CompositeRouteFetcherReporter", func() {
+	var fakeReporter1 *fakes.FakeRouteFetcherReporter
+	var fakeReporter2 *fakes.FakeRouteFetcherReporter
+	var composite reporter.RouteFetcherReporter
+
+	BeforeEach(func() {
+		fakeReporter1 = new(fakes.FakeRouteFetcherReporter)
+		fakeReporter2 = new(fakes.FakeRouteFetcherReporter)
+
+		composite = metrics.NewCompositeRouteFetcherReporter(fakeReporter1, fakeReporter2)
+	})
+
+	It("forwards CaptureRouteFetcherError to both reporters", func() {
+		composite.CaptureRouteFetcherError("token_fetch_errors")
+
+		Expect(fakeReporter1.CaptureRouteFetcherErrorArgsForCall(0)).To(Equal("token_fetch_errors"))
+		Expect(fakeReporter2.CaptureRouteFetcherErrorArgsForCall(0)).To(Equal("token_fetch_errors"))
+	})
+})
+
+var _ = Describe("CompositeAccessLogReporter", func() {
+	var fakeReporter1 *fakes.FakeAccessLogReporter
+	var fakeReporter2 *fakes.FakeAccessLogReporter
+	var composite reporter.AccessLogReporter
+
+	BeforeEach(func() {
+		fakeReporter1 = new(fakes.FakeAccessLogReporter)
+		fakeReporter2 = new(fakes.FakeAccessLogReporter)
+
+		composite = metrics.NewCompositeAccessLogReporter(fakeReporter1, fakeReporter2)
+	})
+
+	It("forwards CaptureAccessLogDropped to both reporters", func() {
+		composite.CaptureAccessLogDropped("syslog")
+
+		Expect(fakeReporter1.CaptureAccessLogDroppedArgsForCall(0)).To(Equal("syslog"))
+		Expect(fakeReporter2.CaptureAccessLogDroppedArgsForCall(0)).To(Equal("syslog"))
+	})
+
+	It("forwards CaptureAccessLogDelayed to both reporters", func() {
+		composite.CaptureAccessLogDelayed("file")
+
+		Expect(fakeReporter1.CaptureAccessLogDelayedArgsForCall(0)).To(Equal("file"))
+		Expect(fakeReporter2.CaptureAccessLogDelayedArgsForCall(0)).To(Equal("file"))
+	})
+})
+
+var _ = Describe("CompositeTLSReporter", func() {
+	var fakeReporter1 *fakes.FakeTLSReporter
+	var fakeReporter2 *fakes.FakeTLSReporter
+	var composite reporter.TLSReporter
+
+	BeforeEach(func() {
+		fakeReporter1 = new(fakes.FakeTLSReporter)
+		fakeReporter2 = new(fakes.FakeTLSReporter)
+
+		composite = metrics.NewCompositeTLSReporter(fakeReporter1, fakeReporter2)
+	})
+
+	It("forwards CaptureTLSHandshakeFailure to both reporters", func() {
+		composite.CaptureTLSHandshakeFailure("https", "timeout")
+
+		listener, reason := fakeReporter1.CaptureTLSHandshakeFailureArgsForCall(0)
+		Expect(listener).To(Equal("https"))
+		Expect(reason).To(Equal("timeout"))
+		listener, reason = fakeReporter2.CaptureTLSHandshakeFailureArgsForCall(0)
+		Expect(listener).To(Equal("https"))
+		Expect(reason).To(Equal("timeout"))
+	})
 })
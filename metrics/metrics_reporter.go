@@ -27,6 +27,10 @@ func (m *MetricsReporter) CaptureBadGateway(req *http.Request) {
 	dropsondeMetrics.BatchIncrementCounter("bad_gateways")
 }
 
+func (m *MetricsReporter) CaptureForbidden(req *http.Request) {
+	dropsondeMetrics.BatchIncrementCounter("forbidden_requests")
+}
+
 func (m *MetricsReporter) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
 	dropsondeMetrics.BatchIncrementCounter("total_requests")
 
@@ -53,6 +57,14 @@ func (m *MetricsReporter) CaptureRoutingResponse(b *route.Endpoint, res *http.Re
 	}
 }
 
+func (m *MetricsReporter) CaptureUpstreamConnectTime(d time.Duration) {
+	dropsondeMetrics.SendValue("upstream_connect_time", float64(d)/float64(time.Millisecond), "ms")
+}
+
+func (m *MetricsReporter) CaptureUpstreamAttemptFailed() {
+	dropsondeMetrics.BatchIncrementCounter("upstream_failed_attempts")
+}
+
 func (c *MetricsReporter) CaptureLookupTime(t time.Duration) {
 	unit := "ns"
 	dropsondeMetrics.SendValue("route_lookup_time", float64(t.Nanoseconds()), unit)
@@ -67,6 +79,18 @@ func (c *MetricsReporter) CaptureRegistryMessage(msg reporter.ComponentTagged) {
 	dropsondeMetrics.IncrementCounter("registry_message." + msg.Component())
 }
 
+func (c *MetricsReporter) CaptureAccessLogDropped(sink string) {
+	dropsondeMetrics.BatchIncrementCounter("access_log_dropped." + sink)
+}
+
+func (c *MetricsReporter) CaptureAccessLogDelayed(sink string) {
+	dropsondeMetrics.BatchIncrementCounter("access_log_delayed." + sink)
+}
+
+func (c *MetricsReporter) CaptureTLSHandshakeFailure(listener, reason string) {
+	dropsondeMetrics.BatchIncrementCounter("tls_handshake_failures." + listener + "." + reason)
+}
+
 func getResponseCounterName(res *http.Response) string {
 	var statusCode int
 
@@ -46,6 +46,11 @@ var _ = Describe("MetricsReporter", func() {
 		Eventually(func() uint64 { return sender.GetCounter("bad_gateways") }).Should(BeEquivalentTo(2))
 	})
 
+	It("increments the forbidden_requests metric", func() {
+		metricsReporter.CaptureForbidden(req)
+		Eventually(func() uint64 { return sender.GetCounter("forbidden_requests") }).Should(BeEquivalentTo(1))
+	})
+
 	Context("increments the request metrics", func() {
 		It("increments the total requests metric", func() {
 			metricsReporter.CaptureRoutingRequest(&route.Endpoint{}, req)
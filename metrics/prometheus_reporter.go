@@ -0,0 +1,349 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	statusClasses = []string{"2xx", "3xx", "4xx", "5xx", "xxx"}
+
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	lookupBuckets  = []float64{.000001, .0000025, .000005, .00001, .000025, .00005, .0001, .00025, .0005, .001}
+	connectBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+)
+
+// PrometheusReporter keeps the router's metrics in memory and serves them in
+// the Prometheus text exposition format.
+type PrometheusReporter struct {
+	badRequests       uint64
+	badGateways       uint64
+	forbidden         uint64
+	routedRequests    uint64
+	responses         [5]uint64
+	totalRoutes       int64
+	msSinceLastUpdate uint64
+	latency           *histogram
+	lookupTime        *histogram
+	connectTime       *histogram
+	failedAttempts    uint64
+
+	mu                 sync.Mutex
+	registryMessages   map[string]uint64
+	routeFetcherErrors map[string]uint64
+	accessLogDropped   map[string]uint64
+	accessLogDelayed   map[string]uint64
+	handshakeFailures  map[handshakeFailureKey]uint64
+	inFlightByEndpoint map[endpointKey]*route.Endpoint
+	gauges             []gaugeFunc
+}
+
+type endpointKey struct {
+	addr  string
+	appId string
+}
+
+type endpointKeys []endpointKey
+
+func (k endpointKeys) Len() int      { return len(k) }
+func (k endpointKeys) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k endpointKeys) Less(i, j int) bool {
+	if k[i].addr != k[j].addr {
+		return k[i].addr < k[j].addr
+	}
+	return k[i].appId < k[j].appId
+}
+
+type handshakeFailureKey struct {
+	listener string
+	reason   string
+}
+
+type handshakeFailureKeys []handshakeFailureKey
+
+func (k handshakeFailureKeys) Len() int      { return len(k) }
+func (k handshakeFailureKeys) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k handshakeFailureKeys) Less(i, j int) bool {
+	if k[i].listener != k[j].listener {
+		return k[i].listener < k[j].listener
+	}
+	return k[i].reason < k[j].reason
+}
+
+type gaugeFunc struct {
+	name string
+	help string
+	f    func() float64
+}
+
+func NewPrometheusReporter() *PrometheusReporter {
+	return &PrometheusReporter{
+		latency:            newHistogram(latencyBuckets),
+		lookupTime:         newHistogram(lookupBuckets),
+		connectTime:        newHistogram(connectBuckets),
+		registryMessages:   map[string]uint64{},
+		routeFetcherErrors: map[string]uint64{},
+		accessLogDropped:   map[string]uint64{},
+		accessLogDelayed:   map[string]uint64{},
+		handshakeFailures:  map[handshakeFailureKey]uint64{},
+		inFlightByEndpoint: map[endpointKey]*route.Endpoint{},
+	}
+}
+
+// AddGaugeFunc adds a gauge whose value is read from f on every scrape.
+func (p *PrometheusReporter) AddGaugeFunc(name, help string, f func() float64) {
+	p.mu.Lock()
+	p.gauges = append(p.gauges, gaugeFunc{name: name, help: help, f: f})
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureBadRequest(req *http.Request) {
+	atomic.AddUint64(&p.badRequests, 1)
+}
+
+func (p *PrometheusReporter) CaptureBadGateway(req *http.Request) {
+	atomic.AddUint64(&p.badGateways, 1)
+}
+
+func (p *PrometheusReporter) CaptureForbidden(req *http.Request) {
+	atomic.AddUint64(&p.forbidden, 1)
+}
+
+func (p *PrometheusReporter) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
+	atomic.AddUint64(&p.routedRequests, 1)
+
+	if b.Stats == nil {
+		return
+	}
+	key := endpointKey{addr: b.CanonicalAddr(), appId: b.ApplicationId}
+	p.mu.Lock()
+	p.inFlightByEndpoint[key] = b
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureRoutingResponse(b *route.Endpoint, res *http.Response, t time.Time, d time.Duration) {
+	class := 4
+	if res != nil && res.StatusCode >= 200 && res.StatusCode < 600 {
+		class = res.StatusCode/100 - 2
+	}
+	atomic.AddUint64(&p.responses[class], 1)
+	p.latency.observe(d.Seconds())
+}
+
+func (p *PrometheusReporter) CaptureUpstreamConnectTime(d time.Duration) {
+	p.connectTime.observe(d.Seconds())
+}
+
+func (p *PrometheusReporter) CaptureUpstreamAttemptFailed() {
+	atomic.AddUint64(&p.failedAttempts, 1)
+}
+
+func (p *PrometheusReporter) CaptureRouteStats(totalRoutes int, msSinceLastUpdate uint64) {
+	atomic.StoreInt64(&p.totalRoutes, int64(totalRoutes))
+	atomic.StoreUint64(&p.msSinceLastUpdate, msSinceLastUpdate)
+}
+
+func (p *PrometheusReporter) CaptureLookupTime(t time.Duration) {
+	p.lookupTime.observe(t.Seconds())
+}
+
+func (p *PrometheusReporter) CaptureRegistryMessage(msg reporter.ComponentTagged) {
+	p.mu.Lock()
+	p.registryMessages[msg.Component()]++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureRouteFetcherError(name string) {
+	p.mu.Lock()
+	p.routeFetcherErrors[strings.TrimSuffix(name, "_errors")]++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureAccessLogDropped(sink string) {
+	p.mu.Lock()
+	p.accessLogDropped[sink]++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureAccessLogDelayed(sink string) {
+	p.mu.Lock()
+	p.accessLogDelayed[sink]++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) CaptureTLSHandshakeFailure(listener, reason string) {
+	p.mu.Lock()
+	p.handshakeFailures[handshakeFailureKey{listener: listener, reason: reason}]++
+	p.mu.Unlock()
+}
+
+func (p *PrometheusReporter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var b bytes.Buffer
+	p.write(&b)
+
+	w.Header().Set("Content-Type", prometheusContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.Bytes())
+}
+
+func (p *PrometheusReporter) write(b *bytes.Buffer) {
+	writeCounter(b, "gorouter_bad_requests_total", "Requests rejected as malformed.", atomic.LoadUint64(&p.badRequests))
+	writeCounter(b, "gorouter_bad_gateways_total", "Requests that could not be routed to a backend.", atomic.LoadUint64(&p.badGateways))
+	writeCounter(b, "gorouter_forbidden_requests_total", "Requests rejected as forbidden.", atomic.LoadUint64(&p.forbidden))
+	writeCounter(b, "gorouter_routed_requests_total", "Attempts to route a request to a backend.", atomic.LoadUint64(&p.routedRequests))
+
+	writeHeader(b, "gorouter_responses_total", "Responses by status class.", "counter")
+	for i, class := range statusClasses {
+		fmt.Fprintf(b, "gorouter_responses_total{status_class=%q} %d\n", class, atomic.LoadUint64(&p.responses[i]))
+	}
+
+	p.latency.write(b, "gorouter_request_duration_seconds", "Time from receiving a request to receiving the backend response.")
+	p.connectTime.write(b, "gorouter_upstream_connect_duration_seconds", "Time to connect to a backend or route service, including the TLS handshake.")
+	writeCounter(b, "gorouter_upstream_failed_attempts_total", "Attempts to reach a backend or route service that failed.", atomic.LoadUint64(&p.failedAttempts))
+
+	writeGauge(b, "gorouter_registry_routes", "Routes in the route registry.", float64(atomic.LoadInt64(&p.totalRoutes)))
+	writeGauge(b, "gorouter_registry_last_update_age_seconds", "Time since the route registry was last updated.", float64(atomic.LoadUint64(&p.msSinceLastUpdate))/1000)
+	p.lookupTime.write(b, "gorouter_route_lookup_duration_seconds", "Time to look up a route in the route registry.")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	writeHeader(b, "gorouter_registry_messages_total", "Route registration messages by component.", "counter")
+	for _, component := range sortedKeys(p.registryMessages) {
+		fmt.Fprintf(b, "gorouter_registry_messages_total{component=\"%s\"} %d\n", escapeLabel(component), p.registryMessages[component])
+	}
+
+	writeHeader(b, "gorouter_route_fetcher_errors_total", "Routing API errors of the route fetcher.", "counter")
+	for _, kind := range sortedKeys(p.routeFetcherErrors) {
+		fmt.Fprintf(b, "gorouter_route_fetcher_errors_total{error=\"%s\"} %d\n", escapeLabel(kind), p.routeFetcherErrors[kind])
+	}
+
+	writeHeader(b, "gorouter_access_log_dropped_records_total", "Access log records dropped because the buffer of a sink was full.", "counter")
+	for _, sink := range sortedKeys(p.accessLogDropped) {
+		fmt.Fprintf(b, "gorouter_access_log_dropped_records_total{sink=\"%s\"} %d\n", escapeLabel(sink), p.accessLogDropped[sink])
+	}
+
+	writeHeader(b, "gorouter_access_log_delayed_records_total", "Access log records that waited for room in the buffer of a sink.", "counter")
+	for _, sink := range sortedKeys(p.accessLogDelayed) {
+		fmt.Fprintf(b, "gorouter_access_log_delayed_records_total{sink=\"%s\"} %d\n", escapeLabel(sink), p.accessLogDelayed[sink])
+	}
+
+	writeHeader(b, "gorouter_tls_handshake_failures_total", "TLS handshakes that failed by listener and reason.", "counter")
+	failures := make(handshakeFailureKeys, 0, len(p.handshakeFailures))
+	for key := range p.handshakeFailures {
+		failures = append(failures, key)
+	}
+	sort.Sort(failures)
+	for _, key := range failures {
+		fmt.Fprintf(b, "gorouter_tls_handshake_failures_total{listener=\"%s\",reason=\"%s\"} %d\n", escapeLabel(key.listener), escapeLabel(key.reason), p.handshakeFailures[key])
+	}
+
+	// Endpoints are reported while they serve requests and once more after
+	// they become idle.
+	writeHeader(b, "gorouter_endpoint_in_flight_requests", "Requests in flight to a backend endpoint.", "gauge")
+	keys := make(endpointKeys, 0, len(p.inFlightByEndpoint))
+	for key := range p.inFlightByEndpoint {
+		keys = append(keys, key)
+	}
+	sort.Sort(keys)
+	for _, key := range keys {
+		count := p.inFlightByEndpoint[key].Stats.NumberConnections.Count()
+		fmt.Fprintf(b, "gorouter_endpoint_in_flight_requests{endpoint=\"%s\",app_id=\"%s\"} %d\n", escapeLabel(key.addr), escapeLabel(key.appId), count)
+		if count <= 0 {
+			delete(p.inFlightByEndpoint, key)
+		}
+	}
+
+	for _, g := range p.gauges {
+		writeGauge(b, g.name, g.help, g.f())
+	}
+}
+
+// histogram is a Prometheus histogram with cumulative buckets that can be
+// observed without locking.
+type histogram struct {
+	count  uint64
+	sum    uint64
+	bounds []float64
+	counts []uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	if i < len(h.bounds) {
+		atomic.AddUint64(&h.counts[i], 1)
+	}
+	atomic.AddUint64(&h.count, 1)
+
+	for {
+		old := atomic.LoadUint64(&h.sum)
+		sum := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&h.sum, old, sum) {
+			return
+		}
+	}
+}
+
+func (h *histogram) write(b *bytes.Buffer, name, help string) {
+	writeHeader(b, name, help, "histogram")
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		fmt.Fprintf(b, "%s_bucket{le=\"%s\"} %d\n", name, formatFloat(bound), cumulative)
+	}
+	count := atomic.LoadUint64(&h.count)
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, formatFloat(math.Float64frombits(atomic.LoadUint64(&h.sum))))
+	fmt.Fprintf(b, "%s_count %d\n", name, count)
+}
+
+func writeHeader(b *bytes.Buffer, name, help, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeCounter(b *bytes.Buffer, name, help string, value uint64) {
+	writeHeader(b, name, help, "counter")
+	fmt.Fprintf(b, "%s %d\n", name, value)
+}
+
+func writeGauge(b *bytes.Buffer, name, help string, value float64) {
+	writeHeader(b, name, help, "gauge")
+	fmt.Fprintf(b, "%s %s\n", name, formatFloat(value))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
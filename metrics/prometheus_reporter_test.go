@@ -0,0 +1,151 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusReporter", func() {
+	var (
+		reporter *metrics.PrometheusReporter
+		endpoint *route.Endpoint
+		req      *http.Request
+	)
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		reporter.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4; charset=utf-8"))
+		body, err := ioutil.ReadAll(recorder.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		reporter = metrics.NewPrometheusReporter()
+		endpoint = route.NewEndpoint("app-guid", "10.0.0.1", 8080, "", "0", map[string]string{"component": "route-emitter"}, 30, "", models.ModificationTag{})
+		req, _ = http.NewRequest("GET", "https://example.com", nil)
+	})
+
+	It("counts rejected requests", func() {
+		reporter.CaptureBadRequest(req)
+		reporter.CaptureBadGateway(req)
+		reporter.CaptureBadGateway(req)
+		reporter.CaptureForbidden(req)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("# TYPE gorouter_bad_requests_total counter\ngorouter_bad_requests_total 1\n"))
+		Expect(body).To(ContainSubstring("gorouter_bad_gateways_total 2\n"))
+		Expect(body).To(ContainSubstring("gorouter_forbidden_requests_total 1\n"))
+	})
+
+	It("counts responses by status class", func() {
+		reporter.CaptureRoutingRequest(endpoint, req)
+		reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 200}, time.Now(), time.Millisecond)
+		reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 503}, time.Now(), time.Millisecond)
+		reporter.CaptureRoutingResponse(endpoint, nil, time.Now(), time.Millisecond)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_routed_requests_total 1\n"))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="2xx"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="4xx"} 0` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="5xx"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_responses_total{status_class="xxx"} 1` + "\n"))
+	})
+
+	It("records response latency in a histogram", func() {
+		reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 200}, time.Now(), 20*time.Millisecond)
+		reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 200}, time.Now(), 3*time.Second)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("# TYPE gorouter_request_duration_seconds histogram\n"))
+		Expect(body).To(ContainSubstring(`gorouter_request_duration_seconds_bucket{le="0.01"} 0` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_request_duration_seconds_bucket{le="0.025"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_request_duration_seconds_bucket{le="5"} 2` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_request_duration_seconds_bucket{le="+Inf"} 2` + "\n"))
+		Expect(body).To(ContainSubstring("gorouter_request_duration_seconds_sum 3.02\n"))
+		Expect(body).To(ContainSubstring("gorouter_request_duration_seconds_count 2\n"))
+	})
+
+	It("reports upstream connect times and failed attempts", func() {
+		reporter.CaptureUpstreamConnectTime(2 * time.Millisecond)
+		reporter.CaptureUpstreamAttemptFailed()
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_upstream_connect_duration_seconds_bucket{le="0.001"} 0` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_upstream_connect_duration_seconds_bucket{le="0.0025"} 1` + "\n"))
+		Expect(body).To(ContainSubstring("gorouter_upstream_failed_attempts_total 1\n"))
+	})
+
+	It("reports the registry", func() {
+		reporter.CaptureRouteStats(12, 1500)
+		reporter.CaptureLookupTime(3 * time.Microsecond)
+		reporter.CaptureRegistryMessage(endpoint)
+
+		body := scrape()
+		Expect(body).To(ContainSubstring("gorouter_registry_routes 12\n"))
+		Expect(body).To(ContainSubstring("gorouter_registry_last_update_age_seconds 1.5\n"))
+		Expect(body).To(ContainSubstring(`gorouter_route_lookup_duration_seconds_bucket{le="5e-06"} 1` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_registry_messages_total{component="route-emitter"} 1` + "\n"))
+	})
+
+	It("counts route fetcher errors", func() {
+		reporter.CaptureRouteFetcherError("token_fetch_errors")
+
+		Expect(scrape()).To(ContainSubstring(`gorouter_route_fetcher_errors_total{error="token_fetch"} 1` + "\n"))
+	})
+
+	It("counts dropped and delayed access log records by sink", func() {
+		reporter.CaptureAccessLogDropped("syslog")
+		reporter.CaptureAccessLogDropped("syslog")
+		reporter.CaptureAccessLogDelayed("file")
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_access_log_dropped_records_total{sink="syslog"} 2` + "\n"))
+		Expect(body).To(ContainSubstring(`gorouter_access_log_delayed_records_total{sink="file"} 1` + "\n"))
+	})
+
+	It("counts failed TLS handshakes by listener and reason", func() {
+		reporter.CaptureTLSHandshakeFailure("https", "timeout")
+		reporter.CaptureTLSHandshakeFailure("https", "timeout")
+		reporter.CaptureTLSHandshakeFailure("https", "not_tls")
+
+		body := scrape()
+		Expect(body).To(ContainSubstring(`gorouter_tls_handshake_failures_total{listener="https",reason="not_tls"} 1` + "\n" +
+			`gorouter_tls_handshake_failures_total{listener="https",reason="timeout"} 2` + "\n"))
+	})
+
+	It("reports the requests in flight to each endpoint until it is idle", func() {
+		reporter.CaptureRoutingRequest(endpoint, req)
+		endpoint.Stats.NumberConnections.Increment()
+
+		Expect(scrape()).To(ContainSubstring(`gorouter_endpoint_in_flight_requests{endpoint="10.0.0.1:8080",app_id="app-guid"} 1` + "\n"))
+
+		endpoint.Stats.NumberConnections.Decrement()
+		Expect(scrape()).To(ContainSubstring(`gorouter_endpoint_in_flight_requests{endpoint="10.0.0.1:8080",app_id="app-guid"} 0` + "\n"))
+		Expect(scrape()).NotTo(ContainSubstring(`gorouter_endpoint_in_flight_requests{`))
+	})
+
+	It("reports gauge functions", func() {
+		reporter.AddGaugeFunc("gorouter_nats_connected", "Whether the router is connected to NATS.", func() float64 { return 1 })
+
+		Expect(scrape()).To(ContainSubstring("# HELP gorouter_nats_connected Whether the router is connected to NATS.\n# TYPE gorouter_nats_connected gauge\ngorouter_nats_connected 1\n"))
+	})
+
+	It("escapes label values", func() {
+		reporter.CaptureRegistryMessage(route.NewEndpoint("", "10.0.0.1", 8080, "", "0", map[string]string{"component": "a\"b\\c"}, 30, "", models.ModificationTag{}))
+
+		Expect(scrape()).To(ContainSubstring(`gorouter_registry_messages_total{component="a\"b\\c"} 1`))
+	})
+})
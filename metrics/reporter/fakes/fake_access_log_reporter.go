@@ -0,0 +1,69 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+)
+
+type FakeAccessLogReporter struct {
+	CaptureAccessLogDroppedStub        func(sink string)
+	captureAccessLogDroppedMutex       sync.RWMutex
+	captureAccessLogDroppedArgsForCall []struct {
+		sink string
+	}
+	CaptureAccessLogDelayedStub        func(sink string)
+	captureAccessLogDelayedMutex       sync.RWMutex
+	captureAccessLogDelayedArgsForCall []struct {
+		sink string
+	}
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDropped(sink string) {
+	fake.captureAccessLogDroppedMutex.Lock()
+	fake.captureAccessLogDroppedArgsForCall = append(fake.captureAccessLogDroppedArgsForCall, struct {
+		sink string
+	}{sink})
+	fake.captureAccessLogDroppedMutex.Unlock()
+	if fake.CaptureAccessLogDroppedStub != nil {
+		fake.CaptureAccessLogDroppedStub(sink)
+	}
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDroppedCallCount() int {
+	fake.captureAccessLogDroppedMutex.RLock()
+	defer fake.captureAccessLogDroppedMutex.RUnlock()
+	return len(fake.captureAccessLogDroppedArgsForCall)
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDroppedArgsForCall(i int) string {
+	fake.captureAccessLogDroppedMutex.RLock()
+	defer fake.captureAccessLogDroppedMutex.RUnlock()
+	return fake.captureAccessLogDroppedArgsForCall[i].sink
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDelayed(sink string) {
+	fake.captureAccessLogDelayedMutex.Lock()
+	fake.captureAccessLogDelayedArgsForCall = append(fake.captureAccessLogDelayedArgsForCall, struct {
+		sink string
+	}{sink})
+	fake.captureAccessLogDelayedMutex.Unlock()
+	if fake.CaptureAccessLogDelayedStub != nil {
+		fake.CaptureAccessLogDelayedStub(sink)
+	}
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDelayedCallCount() int {
+	fake.captureAccessLogDelayedMutex.RLock()
+	defer fake.captureAccessLogDelayedMutex.RUnlock()
+	return len(fake.captureAccessLogDelayedArgsForCall)
+}
+
+func (fake *FakeAccessLogReporter) CaptureAccessLogDelayedArgsForCall(i int) string {
+	fake.captureAccessLogDelayedMutex.RLock()
+	defer fake.captureAccessLogDelayedMutex.RUnlock()
+	return fake.captureAccessLogDelayedArgsForCall[i].sink
+}
+
+var _ reporter.AccessLogReporter = new(FakeAccessLogReporter)
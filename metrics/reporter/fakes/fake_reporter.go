@@ -21,6 +21,11 @@ type FakeProxyReporter struct {
 	captureBadGatewayArgsForCall []struct {
 		req *http.Request
 	}
+	CaptureForbiddenStub        func(req *http.Request)
+	captureForbiddenMutex       sync.RWMutex
+	captureForbiddenArgsForCall []struct {
+		req *http.Request
+	}
 	CaptureRoutingRequestStub        func(b *route.Endpoint, req *http.Request)
 	captureRoutingRequestMutex       sync.RWMutex
 	captureRoutingRequestArgsForCall []struct {
@@ -35,6 +40,14 @@ type FakeProxyReporter struct {
 		t   time.Time
 		d   time.Duration
 	}
+	CaptureUpstreamConnectTimeStub        func(d time.Duration)
+	captureUpstreamConnectTimeMutex       sync.RWMutex
+	captureUpstreamConnectTimeArgsForCall []struct {
+		d time.Duration
+	}
+	CaptureUpstreamAttemptFailedStub        func()
+	captureUpstreamAttemptFailedMutex       sync.RWMutex
+	captureUpstreamAttemptFailedArgsForCall []struct{}
 }
 
 func (fake *FakeProxyReporter) CaptureBadRequest(req *http.Request) {
@@ -83,6 +96,29 @@ func (fake *FakeProxyReporter) CaptureBadGatewayArgsForCall(i int) *http.Request
 	return fake.captureBadGatewayArgsForCall[i].req
 }
 
+func (fake *FakeProxyReporter) CaptureForbidden(req *http.Request) {
+	fake.captureForbiddenMutex.Lock()
+	fake.captureForbiddenArgsForCall = append(fake.captureForbiddenArgsForCall, struct {
+		req *http.Request
+	}{req})
+	fake.captureForbiddenMutex.Unlock()
+	if fake.CaptureForbiddenStub != nil {
+		fake.CaptureForbiddenStub(req)
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureForbiddenCallCount() int {
+	fake.captureForbiddenMutex.RLock()
+	defer fake.captureForbiddenMutex.RUnlock()
+	return len(fake.captureForbiddenArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureForbiddenArgsForCall(i int) *http.Request {
+	fake.captureForbiddenMutex.RLock()
+	defer fake.captureForbiddenMutex.RUnlock()
+	return fake.captureForbiddenArgsForCall[i].req
+}
+
 func (fake *FakeProxyReporter) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
 	fake.captureRoutingRequestMutex.Lock()
 	fake.captureRoutingRequestArgsForCall = append(fake.captureRoutingRequestArgsForCall, struct {
@@ -133,4 +169,42 @@ func (fake *FakeProxyReporter) CaptureRoutingResponseArgsForCall(i int) (*route.
 	return fake.captureRoutingResponseArgsForCall[i].b, fake.captureRoutingResponseArgsForCall[i].res, fake.captureRoutingResponseArgsForCall[i].t, fake.captureRoutingResponseArgsForCall[i].d
 }
 
+func (fake *FakeProxyReporter) CaptureUpstreamConnectTime(d time.Duration) {
+	fake.captureUpstreamConnectTimeMutex.Lock()
+	fake.captureUpstreamConnectTimeArgsForCall = append(fake.captureUpstreamConnectTimeArgsForCall, struct {
+		d time.Duration
+	}{d})
+	fake.captureUpstreamConnectTimeMutex.Unlock()
+	if fake.CaptureUpstreamConnectTimeStub != nil {
+		fake.CaptureUpstreamConnectTimeStub(d)
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureUpstreamConnectTimeCallCount() int {
+	fake.captureUpstreamConnectTimeMutex.RLock()
+	defer fake.captureUpstreamConnectTimeMutex.RUnlock()
+	return len(fake.captureUpstreamConnectTimeArgsForCall)
+}
+
+func (fake *FakeProxyReporter) CaptureUpstreamConnectTimeArgsForCall(i int) time.Duration {
+	fake.captureUpstreamConnectTimeMutex.RLock()
+	defer fake.captureUpstreamConnectTimeMutex.RUnlock()
+	return fake.captureUpstreamConnectTimeArgsForCall[i].d
+}
+
+func (fake *FakeProxyReporter) CaptureUpstreamAttemptFailed() {
+	fake.captureUpstreamAttemptFailedMutex.Lock()
+	fake.captureUpstreamAttemptFailedArgsForCall = append(fake.captureUpstreamAttemptFailedArgsForCall, struct{}{})
+	fake.captureUpstreamAttemptFailedMutex.Unlock()
+	if fake.CaptureUpstreamAttemptFailedStub != nil {
+		fake.CaptureUpstreamAttemptFailedStub()
+	}
+}
+
+func (fake *FakeProxyReporter) CaptureUpstreamAttemptFailedCallCount() int {
+	fake.captureUpstreamAttemptFailedMutex.RLock()
+	defer fake.captureUpstreamAttemptFailedMutex.RUnlock()
+	return len(fake.captureUpstreamAttemptFailedArgsForCall)
+}
+
 var _ reporter.ProxyReporter = new(FakeProxyReporter)
@@ -0,0 +1,41 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+)
+
+type FakeRouteFetcherReporter struct {
+	CaptureRouteFetcherErrorStub        func(name string)
+	captureRouteFetcherErrorMutex       sync.RWMutex
+	captureRouteFetcherErrorArgsForCall []struct {
+		name string
+	}
+}
+
+func (fake *FakeRouteFetcherReporter) CaptureRouteFetcherError(name string) {
+	fake.captureRouteFetcherErrorMutex.Lock()
+	fake.captureRouteFetcherErrorArgsForCall = append(fake.captureRouteFetcherErrorArgsForCall, struct {
+		name string
+	}{name})
+	fake.captureRouteFetcherErrorMutex.Unlock()
+	if fake.CaptureRouteFetcherErrorStub != nil {
+		fake.CaptureRouteFetcherErrorStub(name)
+	}
+}
+
+func (fake *FakeRouteFetcherReporter) CaptureRouteFetcherErrorCallCount() int {
+	fake.captureRouteFetcherErrorMutex.RLock()
+	defer fake.captureRouteFetcherErrorMutex.RUnlock()
+	return len(fake.captureRouteFetcherErrorArgsForCall)
+}
+
+func (fake *FakeRouteFetcherReporter) CaptureRouteFetcherErrorArgsForCall(i int) string {
+	fake.captureRouteFetcherErrorMutex.RLock()
+	defer fake.captureRouteFetcherErrorMutex.RUnlock()
+	return fake.captureRouteFetcherErrorArgsForCall[i].name
+}
+
+var _ reporter.RouteFetcherReporter = new(FakeRouteFetcherReporter)
@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+)
+
+type FakeTLSReporter struct {
+	CaptureTLSHandshakeFailureStub        func(listener, reason string)
+	captureTLSHandshakeFailureMutex       sync.RWMutex
+	captureTLSHandshakeFailureArgsForCall []struct {
+		listener string
+		reason   string
+	}
+}
+
+func (fake *FakeTLSReporter) CaptureTLSHandshakeFailure(listener string, reason string) {
+	fake.captureTLSHandshakeFailureMutex.Lock()
+	fake.captureTLSHandshakeFailureArgsForCall = append(fake.captureTLSHandshakeFailureArgsForCall, struct {
+		listener string
+		reason   string
+	}{listener, reason})
+	fake.captureTLSHandshakeFailureMutex.Unlock()
+	if fake.CaptureTLSHandshakeFailureStub != nil {
+		fake.CaptureTLSHandshakeFailureStub(listener, reason)
+	}
+}
+
+func (fake *FakeTLSReporter) CaptureTLSHandshakeFailureCallCount() int {
+	fake.captureTLSHandshakeFailureMutex.RLock()
+	defer fake.captureTLSHandshakeFailureMutex.RUnlock()
+	return len(fake.captureTLSHandshakeFailureArgsForCall)
+}
+
+func (fake *FakeTLSReporter) CaptureTLSHandshakeFailureArgsForCall(i int) (string, string) {
+	fake.captureTLSHandshakeFailureMutex.RLock()
+	defer fake.captureTLSHandshakeFailureMutex.RUnlock()
+	return fake.captureTLSHandshakeFailureArgsForCall[i].listener, fake.captureTLSHandshakeFailureArgsForCall[i].reason
+}
+
+var _ reporter.TLSReporter = new(FakeTLSReporter)
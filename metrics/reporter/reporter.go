@@ -11,8 +11,11 @@ import (
 type ProxyReporter interface {
 	CaptureBadRequest(req *http.Request)
 	CaptureBadGateway(req *http.Request)
+	CaptureForbidden(req *http.Request)
 	CaptureRoutingRequest(b *route.Endpoint, req *http.Request)
 	CaptureRoutingResponse(b *route.Endpoint, res *http.Response, t time.Time, d time.Duration)
+	CaptureUpstreamConnectTime(d time.Duration)
+	CaptureUpstreamAttemptFailed()
 }
 
 type ComponentTagged interface {
@@ -25,3 +28,19 @@ type RouteRegistryReporter interface {
 	CaptureLookupTime(t time.Duration)
 	CaptureRegistryMessage(msg ComponentTagged)
 }
+
+//go:generate counterfeiter -o fakes/fake_route_fetcher_reporter.go . RouteFetcherReporter
+type RouteFetcherReporter interface {
+	CaptureRouteFetcherError(name string)
+}
+
+//go:generate counterfeiter -o fakes/fake_access_log_reporter.go . AccessLogReporter
+type AccessLogReporter interface {
+	CaptureAccessLogDropped(sink string)
+	CaptureAccessLogDelayed(sink string)
+}
+
+//go:generate counterfeiter -o fakes/fake_tls_reporter.go . TLSReporter
+type TLSReporter interface {
+	CaptureTLSHandshakeFailure(listener, reason string)
+}
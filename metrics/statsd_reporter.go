@@ -0,0 +1,254 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager"
+)
+
+// maxStatsdPacketSize keeps packets within the MTU of common networks.
+const maxStatsdPacketSize = 1432
+
+var statsdEscaper = strings.NewReplacer(":", "_", "|", "_", "@", "_", "#", "_", ",", "_", "\n", "_")
+
+// StatsdReporter sends the metrics of MetricsReporter to a StatsD server.
+// Metrics are buffered and sent from its own goroutine every flush interval,
+// or earlier when a packet is full.
+type StatsdReporter struct {
+	conn          io.Writer
+	prefix        string
+	sampleRate    float64
+	dogStatsD     bool
+	tags          []string
+	flushInterval time.Duration
+	logger        lager.Logger
+
+	mu     sync.Mutex
+	buffer bytes.Buffer
+	rand   *rand.Rand
+}
+
+func NewStatsdReporter(c config.StatsDConfig, logger lager.Logger) (*StatsdReporter, error) {
+	conn, err := net.Dial("udp", c.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsdReporter{
+		conn:          conn,
+		prefix:        c.Prefix,
+		sampleRate:    c.SampleRate,
+		dogStatsD:     c.DogStatsD,
+		tags:          c.Tags,
+		flushInterval: c.FlushInterval,
+		logger:        logger,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+func (s *StatsdReporter) CaptureBadRequest(req *http.Request) {
+	s.count("rejected_requests", nil)
+}
+
+func (s *StatsdReporter) CaptureBadGateway(req *http.Request) {
+	s.count("bad_gateways", nil)
+}
+
+func (s *StatsdReporter) CaptureForbidden(req *http.Request) {
+	s.count("forbidden_requests", nil)
+}
+
+func (s *StatsdReporter) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
+	componentName := b.Tags["component"]
+
+	s.count("total_requests", endpointTags(b))
+	if componentName != "" {
+		if !s.dogStatsD {
+			s.count("requests."+componentName, nil)
+		}
+		if strings.HasPrefix(componentName, "dea-") {
+			s.count("routed_app_requests", nil)
+		}
+	}
+}
+
+func (s *StatsdReporter) CaptureRoutingResponse(b *route.Endpoint, res *http.Response, t time.Time, d time.Duration) {
+	tags := endpointTags(b)
+	s.count(getResponseCounterName(res), tags)
+	s.count("responses", tags)
+
+	latency := float64(d) / float64(time.Millisecond)
+	s.timing("latency", latency, tags)
+
+	componentName := b.Tags["component"]
+	if componentName != "" && !s.dogStatsD {
+		s.timing("latency."+componentName, latency, nil)
+	}
+}
+
+func (s *StatsdReporter) CaptureUpstreamConnectTime(d time.Duration) {
+	s.timing("upstream_connect_time", float64(d)/float64(time.Millisecond), nil)
+}
+
+func (s *StatsdReporter) CaptureUpstreamAttemptFailed() {
+	s.count("upstream_failed_attempts", nil)
+}
+
+func (s *StatsdReporter) CaptureLookupTime(t time.Duration) {
+	s.timing("route_lookup_time", float64(t)/float64(time.Millisecond), nil)
+}
+
+func (s *StatsdReporter) CaptureRouteStats(totalRoutes int, msSinceLastUpdate uint64) {
+	s.gauge("total_routes", float64(totalRoutes))
+	s.gauge("ms_since_last_registry_update", float64(msSinceLastUpdate))
+}
+
+func (s *StatsdReporter) CaptureRegistryMessage(msg reporter.ComponentTagged) {
+	if s.dogStatsD {
+		s.count("registry_message", []string{"component:" + statsdEscaper.Replace(msg.Component())})
+		return
+	}
+	s.count("registry_message."+msg.Component(), nil)
+}
+
+func (s *StatsdReporter) CaptureRouteFetcherError(name string) {
+	if s.dogStatsD {
+		s.count("route_fetcher_errors", []string{"error:" + statsdEscaper.Replace(strings.TrimSuffix(name, "_errors"))})
+		return
+	}
+	s.count(name, nil)
+}
+
+func (s *StatsdReporter) CaptureAccessLogDropped(sink string) {
+	s.countBySink("access_log_dropped", sink)
+}
+
+func (s *StatsdReporter) CaptureAccessLogDelayed(sink string) {
+	s.countBySink("access_log_delayed", sink)
+}
+
+func (s *StatsdReporter) CaptureTLSHandshakeFailure(listener, reason string) {
+	if s.dogStatsD {
+		s.count("tls_handshake_failures", []string{
+			"listener:" + statsdEscaper.Replace(listener),
+			"reason:" + statsdEscaper.Replace(reason),
+		})
+		return
+	}
+	s.count("tls_handshake_failures."+listener+"."+reason, nil)
+}
+
+func (s *StatsdReporter) countBySink(name, sink string) {
+	if s.dogStatsD {
+		s.count(name, []string{"sink:" + statsdEscaper.Replace(sink)})
+		return
+	}
+	s.count(name+"."+sink, nil)
+}
+
+func (s *StatsdReporter) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Flush()
+		case <-signals:
+			s.Flush()
+			return nil
+		}
+	}
+}
+
+// Flush sends the buffered metrics.
+func (s *StatsdReporter) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+func (s *StatsdReporter) flushLocked() {
+	if s.buffer.Len() == 0 {
+		return
+	}
+
+	packet := bytes.TrimSuffix(s.buffer.Bytes(), []byte("\n"))
+	if _, err := s.conn.Write(packet); err != nil {
+		s.logger.Error("statsd-write-failed", err)
+	}
+	s.buffer.Reset()
+}
+
+func (s *StatsdReporter) count(name string, tags []string) {
+	if !s.sample() {
+		return
+	}
+	s.send(name, "1", "c", true, tags)
+}
+
+func (s *StatsdReporter) timing(name string, ms float64, tags []string) {
+	if !s.sample() {
+		return
+	}
+	s.send(name, strconv.FormatFloat(ms, 'f', -1, 64), "ms", true, tags)
+}
+
+func (s *StatsdReporter) gauge(name string, value float64) {
+	s.send(name, strconv.FormatFloat(value, 'f', -1, 64), "g", false, nil)
+}
+
+func (s *StatsdReporter) sample() bool {
+	if s.sampleRate >= 1 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64() < s.sampleRate
+}
+
+func (s *StatsdReporter) send(name, value, kind string, sampled bool, tags []string) {
+	line := s.prefix + statsdEscaper.Replace(name) + ":" + value + "|" + kind
+	if sampled && s.sampleRate < 1 {
+		line += "|@" + strconv.FormatFloat(s.sampleRate, 'f', -1, 64)
+	}
+	if s.dogStatsD {
+		if all := append(append([]string{}, s.tags...), tags...); len(all) > 0 {
+			line += "|#" + strings.Join(all, ",")
+		}
+	}
+	line += "\n"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buffer.Len()+len(line) > maxStatsdPacketSize {
+		s.flushLocked()
+	}
+	s.buffer.WriteString(line)
+}
+
+func endpointTags(b *route.Endpoint) []string {
+	var tags []string
+	if componentName := b.Tags["component"]; componentName != "" {
+		tags = append(tags, "component:"+statsdEscaper.Replace(componentName))
+	}
+	if b.ApplicationId != "" {
+		tags = append(tags, "app_id:"+statsdEscaper.Replace(b.ApplicationId))
+	}
+	return tags
+}
@@ -0,0 +1,188 @@
+package metrics_test
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StatsdReporter", func() {
+	var (
+		server   net.PacketConn
+		cfg      config.StatsDConfig
+		reporter *metrics.StatsdReporter
+		endpoint *route.Endpoint
+		req      *http.Request
+	)
+
+	readPacket := func() []string {
+		buf := make([]byte, 65536)
+		server.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := server.ReadFrom(buf)
+		Expect(err).NotTo(HaveOccurred())
+		return strings.Split(string(buf[:n]), "\n")
+	}
+
+	BeforeEach(func() {
+		var err error
+		server, err = net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = config.StatsDConfig{
+			Address:       server.LocalAddr().String(),
+			Prefix:        "gorouter.",
+			FlushInterval: time.Hour,
+			SampleRate:    1,
+		}
+		endpoint = route.NewEndpoint("app-guid", "10.0.0.1", 8080, "", "0", map[string]string{"component": "dea-1"}, 30, "", models.ModificationTag{})
+		req, _ = http.NewRequest("GET", "https://example.com", nil)
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		reporter, err = metrics.NewStatsdReporter(cfg, lagertest.NewTestLogger("statsd"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the metrics of the metrics reporter", func() {
+		reporter.CaptureBadRequest(req)
+		reporter.CaptureRoutingRequest(endpoint, req)
+		reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 200}, time.Now(), 1500*time.Microsecond)
+		reporter.CaptureRouteStats(12, 300)
+		reporter.CaptureRegistryMessage(endpoint)
+		reporter.CaptureRouteFetcherError("token_fetch_errors")
+		reporter.CaptureAccessLogDropped("syslog")
+		reporter.CaptureAccessLogDelayed("file")
+		reporter.CaptureUpstreamConnectTime(2 * time.Millisecond)
+		reporter.CaptureUpstreamAttemptFailed()
+		reporter.CaptureTLSHandshakeFailure("https", "timeout")
+		reporter.Flush()
+
+		Expect(readPacket()).To(Equal([]string{
+			"gorouter.rejected_requests:1|c",
+			"gorouter.total_requests:1|c",
+			"gorouter.requests.dea-1:1|c",
+			"gorouter.routed_app_requests:1|c",
+			"gorouter.responses.2xx:1|c",
+			"gorouter.responses:1|c",
+			"gorouter.latency:1.5|ms",
+			"gorouter.latency.dea-1:1.5|ms",
+			"gorouter.total_routes:12|g",
+			"gorouter.ms_since_last_registry_update:300|g",
+			"gorouter.registry_message.dea-1:1|c",
+			"gorouter.token_fetch_errors:1|c",
+			"gorouter.access_log_dropped.syslog:1|c",
+			"gorouter.access_log_delayed.file:1|c",
+			"gorouter.upstream_connect_time:2|ms",
+			"gorouter.upstream_failed_attempts:1|c",
+			"gorouter.tls_handshake_failures.https.timeout:1|c",
+		}))
+	})
+
+	Context("with DogStatsD", func() {
+		BeforeEach(func() {
+			cfg.DogStatsD = true
+			cfg.Tags = []string{"env:test"}
+		})
+
+		It("tags metrics with the component and app ID", func() {
+			reporter.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: 503}, time.Now(), 2*time.Millisecond)
+			reporter.CaptureRegistryMessage(endpoint)
+			reporter.CaptureRouteStats(1, 0)
+			reporter.CaptureRouteFetcherError("token_fetch_errors")
+			reporter.CaptureAccessLogDropped("syslog")
+			reporter.CaptureTLSHandshakeFailure("https", "timeout")
+			reporter.Flush()
+
+			Expect(readPacket()).To(Equal([]string{
+				"gorouter.responses.5xx:1|c|#env:test,component:dea-1,app_id:app-guid",
+				"gorouter.responses:1|c|#env:test,component:dea-1,app_id:app-guid",
+				"gorouter.latency:2|ms|#env:test,component:dea-1,app_id:app-guid",
+				"gorouter.registry_message:1|c|#env:test,component:dea-1",
+				"gorouter.total_routes:1|g|#env:test",
+				"gorouter.ms_since_last_registry_update:0|g|#env:test",
+				"gorouter.route_fetcher_errors:1|c|#env:test,error:token_fetch",
+				"gorouter.access_log_dropped:1|c|#env:test,sink:syslog",
+				"gorouter.tls_handshake_failures:1|c|#env:test,listener:https,reason:timeout",
+			}))
+		})
+	})
+
+	Context("with a sample rate", func() {
+		BeforeEach(func() {
+			cfg.SampleRate = 0.5
+		})
+
+		It("sends a sample of counters and timings with the rate", func() {
+			for i := 0; i < 200; i++ {
+				reporter.CaptureBadGateway(req)
+			}
+			reporter.CaptureRouteStats(1, 0)
+			reporter.Flush()
+
+			var lines []string
+			for {
+				packet := readPacket()
+				lines = append(lines, packet...)
+				if strings.HasPrefix(packet[len(packet)-1], "gorouter.ms_since_last_registry_update") {
+					break
+				}
+			}
+
+			counters := 0
+			for _, line := range lines {
+				if strings.HasPrefix(line, "gorouter.bad_gateways") {
+					Expect(line).To(Equal("gorouter.bad_gateways:1|c|@0.5"))
+					counters++
+				}
+			}
+			Expect(counters).To(BeNumerically(">", 50))
+			Expect(counters).To(BeNumerically("<", 150))
+			Expect(lines).To(ContainElement("gorouter.total_routes:1|g"))
+		})
+	})
+
+	It("splits metrics into packets that fit the MTU", func() {
+		for i := 0; i < 100; i++ {
+			reporter.CaptureBadGateway(req)
+		}
+		reporter.Flush()
+
+		first := readPacket()
+		Expect(len(strings.Join(first, "\n"))).To(BeNumerically("<=", 1432))
+		second := readPacket()
+		Expect(len(first) + len(second)).To(Equal(100))
+	})
+
+	It("flushes every flush interval and when stopped", func() {
+		cfg.FlushInterval = 10 * time.Millisecond
+		var err error
+		reporter, err = metrics.NewStatsdReporter(cfg, lagertest.NewTestLogger("statsd"))
+		Expect(err).NotTo(HaveOccurred())
+
+		process := ifrit.Invoke(reporter)
+		reporter.CaptureForbidden(req)
+		Expect(readPacket()).To(Equal([]string{"gorouter.forbidden_requests:1|c"}))
+
+		reporter.CaptureForbidden(req)
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+		Expect(readPacket()).To(Equal([]string{"gorouter.forbidden_requests:1|c"}))
+	})
+})
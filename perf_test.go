@@ -24,7 +24,7 @@ var _ = Describe("AccessLogRecord", func() {
 		c := config.DefaultConfig()
 		r := registry.NewRouteRegistry(logger, c, new(fakes.FakeRouteRegistryReporter))
 
-		accesslog, err := access_log.CreateRunningAccessLogger(logger, c)
+		accesslog, err := access_log.CreateRunningAccessLogger(logger, c, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		proxy.NewProxy(proxy.ProxyArgs{
@@ -13,6 +13,7 @@ import (
 
 	"code.cloudfoundry.org/gorouter/access_log/schema"
 	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/errorpage"
 	"code.cloudfoundry.org/gorouter/metrics/reporter"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
 	"code.cloudfoundry.org/gorouter/route"
@@ -26,32 +27,39 @@ const (
 var NoEndpointsAvailable = errors.New("No endpoints available")
 
 type RequestHandler struct {
-	logger    lager.Logger
-	reporter  reporter.ProxyReporter
-	logrecord *schema.AccessLogRecord
+	logger     lager.Logger
+	reporter   reporter.ProxyReporter
+	logrecord  *schema.AccessLogRecord
+	errorPages *errorpage.Templates
 
 	request  *http.Request
 	response utils.ProxyResponseWriter
 }
 
-func NewRequestHandler(request *http.Request, response utils.ProxyResponseWriter, r reporter.ProxyReporter, alr *schema.AccessLogRecord, logger lager.Logger) *RequestHandler {
-	requestLogger := setupLogger(request, logger)
+func NewRequestHandler(request *http.Request, response utils.ProxyResponseWriter, r reporter.ProxyReporter, alr *schema.AccessLogRecord, logger lager.Logger, errorPages *errorpage.Templates, requestIdHeader string) *RequestHandler {
+	requestLogger := setupLogger(request, logger, requestIdHeader)
 	return &RequestHandler{
-		logger:    requestLogger,
-		reporter:  r,
-		logrecord: alr,
-		request:   request,
-		response:  response,
+		logger:     requestLogger,
+		reporter:   r,
+		logrecord:  alr,
+		errorPages: errorPages,
+		request:    request,
+		response:   response,
 	}
 }
 
-func setupLogger(request *http.Request, logger lager.Logger) lager.Logger {
+func setupLogger(request *http.Request, logger lager.Logger, requestIdHeader string) lager.Logger {
+	if requestIdHeader == "" {
+		requestIdHeader = router_http.VcapRequestIdHeader
+	}
+
 	return logger.Session("request-handler", lager.Data{
 		"RemoteAddr":        request.RemoteAddr,
 		"Host":              request.Host,
 		"Path":              request.URL.Path,
 		"X-Forwarded-For":   request.Header["X-Forwarded-For"],
 		"X-Forwarded-Proto": request.Header["X-Forwarded-Proto"],
+		"RequestId":         request.Header.Get(requestIdHeader),
 	})
 }
 
@@ -91,15 +99,37 @@ func (h *RequestHandler) HandleMissingRoute() {
 	h.reporter.CaptureBadRequest(h.request)
 	h.logger.Info("unknown-route")
 
-	h.response.Header().Set("X-Cf-RouterError", "unknown_route")
+	h.response.Header().Set(router_http.CfRouterErrorHeader, "unknown_route")
 	message := fmt.Sprintf("Requested route ('%s') does not exist.", h.request.Host)
 	h.writeStatus(http.StatusNotFound, message)
 }
 
+func (h *RequestHandler) HandleForbidden() {
+	h.reporter.CaptureForbidden(h.request)
+	h.logger.Info("forbidden-client-address")
+
+	h.response.Header().Set(router_http.CfRouterErrorHeader, "forbidden")
+	message := fmt.Sprintf("Access to route ('%s') is not allowed from this address.", h.request.Host)
+	h.writeStatus(http.StatusForbidden, message)
+}
+
+func (h *RequestHandler) HandleRedirect(redirect *route.Redirect) {
+	location, err := redirect.Location(h.request)
+	if err != nil {
+		h.logger.Error("redirect-failed", err)
+		h.writeStatus(http.StatusInternalServerError, "Redirect target could not be rendered.")
+		return
+	}
+
+	h.logger.Debug("redirect", lager.Data{"location": location, "status": redirect.StatusCode})
+	h.logrecord.StatusCode = redirect.StatusCode
+	http.Redirect(h.response, h.request, location, redirect.StatusCode)
+}
+
 func (h *RequestHandler) HandleBadGateway(err error, request *http.Request) {
 	h.reporter.CaptureBadGateway(request)
 
-	h.response.Header().Set("X-Cf-RouterError", "endpoint_failure")
+	h.response.Header().Set(router_http.CfRouterErrorHeader, "endpoint_failure")
 	h.writeStatus(http.StatusBadGateway, "Registered endpoint failed to handle the request.")
 	h.response.Done()
 }
@@ -121,7 +151,7 @@ func (h *RequestHandler) HandleRouteServiceFailure(err error) {
 func (h *RequestHandler) HandleUnsupportedRouteService() {
 	h.logger.Info("route-service-unsupported")
 
-	h.response.Header().Set("X-Cf-RouterError", "route_service_unsupported")
+	h.response.Header().Set(router_http.CfRouterErrorHeader, "route_service_unsupported")
 	h.writeStatus(http.StatusBadGateway, "Support for route services is disabled.")
 	h.response.Done()
 }
@@ -156,7 +186,9 @@ func (h *RequestHandler) writeStatus(code int, message string) {
 	h.logger.Info("status", lager.Data{"body": body})
 	h.logrecord.StatusCode = code
 
-	http.Error(h.response, body, code)
+	if !h.errorPages.Render(h.response, h.request, code, message) {
+		http.Error(h.response, body, code)
+	}
 	if code > 299 {
 		h.response.Header().Del("Connection")
 	}
@@ -13,13 +13,19 @@ import (
 	"code.cloudfoundry.org/gorouter/access_log/schema"
 	router_http "code.cloudfoundry.org/gorouter/common/http"
 	"code.cloudfoundry.org/gorouter/common/secure"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
 	"code.cloudfoundry.org/gorouter/handlers"
+	"code.cloudfoundry.org/gorouter/ipfilter"
+	"code.cloudfoundry.org/gorouter/jwt"
 	"code.cloudfoundry.org/gorouter/metrics/reporter"
 	"code.cloudfoundry.org/gorouter/proxy/handler"
 	"code.cloudfoundry.org/gorouter/proxy/round_tripper"
 	"code.cloudfoundry.org/gorouter/proxy/utils"
+	"code.cloudfoundry.org/gorouter/rewrite"
 	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/gorouter/routeservice"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"code.cloudfoundry.org/lager"
 	"github.com/cloudfoundry/dropsonde"
 	"github.com/urfave/negroni"
@@ -46,6 +52,7 @@ type ProxyArgs struct {
 	Registry                   LookupRegistry
 	Reporter                   reporter.ProxyReporter
 	AccessLogger               access_log.AccessLogger
+	AccessLogFilter            *access_log.Filter
 	SecureCookies              bool
 	TLSConfig                  *tls.Config
 	RouteServiceEnabled        bool
@@ -58,8 +65,18 @@ type ProxyArgs struct {
 	HealthCheckUserAgent       string
 	HeartbeatOK                *int32
 	EnableZipkin               bool
+	TraceFormat                string
+	Tracer                     *tracing.Tracer
 	ForceForwardedProtoHttps   bool
 	DefaultLoadBalance         string
+	ErrorPages                 *errorpage.Templates
+	HeaderRules                *rewrite.HeaderRules
+	HTTPSRedirectDomains       []string
+	HTTPSRedirectStatusCode    int
+	IPFilter                   *ipfilter.Filter
+	JWTPolicies                *jwt.Policies
+	CORSPolicies               *cors.Policies
+	RequestIdHeader            string
 }
 
 type proxyHandler struct {
@@ -79,6 +96,30 @@ func (p *proxyWriterHandler) ServeHTTP(responseWriter http.ResponseWriter, reque
 	next(proxyWriter, request)
 }
 
+type ipFilterHandler struct {
+	proxy *proxy
+}
+
+// ServeHTTP answers requests from addresses the IP rules of their route deny
+// with a 403, before the CORS and JWT handlers see them. Requests for unknown
+// routes are passed on so that they still get a 404.
+func (h *ipFilterHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request, next http.HandlerFunc) {
+	p := h.proxy
+	if p.ipFilter.Allowed(request) || !isProtocolSupported(request) || p.lookup(request) == nil {
+		next(responseWriter, request)
+		return
+	}
+
+	proxyWriter := responseWriter.(utils.ProxyResponseWriter)
+	alr := proxyWriter.Context().Value("AccessLogRecord")
+	if alr == nil {
+		p.logger.Error("AccessLogRecord not set on context", errors.New("failed-to-access-LogRecord"))
+	}
+	accessLog := alr.(*schema.AccessLogRecord)
+
+	handler.NewRequestHandler(request, proxyWriter, p.reporter, accessLog, p.logger, p.errorPages, p.requestIdHeader).HandleForbidden()
+}
+
 type proxy struct {
 	ip                         string
 	traceKey                   string
@@ -95,6 +136,11 @@ type proxy struct {
 	healthCheckUserAgent       string
 	forceForwardedProtoHttps   bool
 	defaultLoadBalance         string
+	errorPages                 *errorpage.Templates
+	headerRules                *rewrite.HeaderRules
+	ipFilter                   *ipfilter.Filter
+	corsPolicies               *cors.Policies
+	requestIdHeader            string
 }
 
 func NewProxy(args ProxyArgs) Proxy {
@@ -130,13 +176,22 @@ func NewProxy(args ProxyArgs) Proxy {
 		healthCheckUserAgent:       args.HealthCheckUserAgent,
 		forceForwardedProtoHttps:   args.ForceForwardedProtoHttps,
 		defaultLoadBalance:         args.DefaultLoadBalance,
+		errorPages:                 args.ErrorPages,
+		headerRules:                args.HeaderRules,
+		ipFilter:                   args.IPFilter,
+		corsPolicies:               args.CORSPolicies,
+		requestIdHeader:            args.RequestIdHeader,
 	}
 
 	n := negroni.New()
 	n.Use(&proxyWriterHandler{})
-	n.Use(handlers.NewAccessLog(args.AccessLogger, args.ExtraHeadersToLog))
-	n.Use(handlers.NewHealthcheck(args.HealthCheckUserAgent, p.heartbeatOK, args.Logger))
-	n.Use(handlers.NewZipkin(args.EnableZipkin, args.ExtraHeadersToLog, args.Logger))
+	n.Use(handlers.NewAccessLog(args.AccessLogger, args.ExtraHeadersToLog, args.AccessLogFilter))
+	n.Use(handlers.NewHealthcheck(args.HealthCheckUserAgent, p.heartbeatOK, args.ErrorPages, args.Logger))
+	n.Use(handlers.NewHTTPSRedirect(args.HTTPSRedirectDomains, args.HTTPSRedirectStatusCode, args.Logger))
+	n.Use(handlers.NewZipkin(args.EnableZipkin, args.TraceFormat, args.Tracer, args.Logger))
+	n.Use(&ipFilterHandler{proxy: p})
+	n.Use(handlers.NewCORS(args.CORSPolicies, args.ErrorPages, args.Logger))
+	n.Use(handlers.NewJWT(args.JWTPolicies, args.ErrorPages, args.Logger))
 
 	n.UseHandler(p)
 	handlers := &proxyHandler{
@@ -197,19 +252,30 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 	}
 	accessLog := alr.(*schema.AccessLogRecord)
 
-	handler := handler.NewRequestHandler(request, proxyWriter, p.reporter, accessLog, p.logger)
+	handler := handler.NewRequestHandler(request, proxyWriter, p.reporter, accessLog, p.logger, p.errorPages, p.requestIdHeader)
 
 	if !isProtocolSupported(request) {
 		handler.HandleUnsupportedProtocol()
 		return
 	}
 
+	lookupStartedAt := time.Now()
 	routePool := p.lookup(request)
+	accessLog.LookupTime = time.Since(lookupStartedAt)
 	if routePool == nil {
 		handler.HandleMissingRoute()
 		return
 	}
 
+	if redirect := routePool.Redirect(); redirect != nil {
+		handler.HandleRedirect(redirect)
+		return
+	}
+
+	if uri := routePool.RouteUri(); uri != "" {
+		request = request.WithContext(route.NewUriContext(request.Context(), uri))
+	}
+
 	stickyEndpointId := p.getStickySession(request)
 	iter := &wrappedIterator{
 		nested: routePool.Endpoints(p.defaultLoadBalance, stickyEndpointId),
@@ -275,6 +341,8 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 	}
 
 	after := func(rsp *http.Response, endpoint *route.Endpoint, err error) {
+		p.reportUpstreamAttempts(accessLog.UpstreamAttempts)
+
 		if endpoint == nil {
 			handler.HandleBadGateway(err, request)
 			return
@@ -298,6 +366,12 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 			return
 		}
 
+		if backend {
+			routePool.PathRewrite().RewriteResponse(request.Host, rsp.Header)
+		}
+		p.headerRules.RewriteResponse(request, rsp.Header)
+		p.corsPolicies.SetResponseHeaders(request, rsp.Header)
+
 		if endpoint.PrivateInstanceId != "" {
 			setupStickySession(responseWriter, rsp, endpoint, stickyEndpointId, p.secureCookies, routePool.ContextPath())
 		}
@@ -309,19 +383,34 @@ func (p *proxy) ServeHTTP(responseWriter http.ResponseWriter, request *http.Requ
 	}
 
 	roundTripper := round_tripper.NewProxyRoundTripper(backend,
-		dropsonde.InstrumentedRoundTripper(p.transport), iter, handler.Logger(), after)
+		dropsonde.InstrumentedRoundTripper(p.transport), iter, handler.Logger(), accessLog, after)
+
+	newReverseProxy(roundTripper, request, routeServiceArgs, p.routeServiceConfig, p.forceForwardedProtoHttps, p.headerRules, routePool.PathRewrite()).ServeHTTP(proxyWriter, request)
+}
 
-	newReverseProxy(roundTripper, request, routeServiceArgs, p.routeServiceConfig, p.forceForwardedProtoHttps).ServeHTTP(proxyWriter, request)
+func (p *proxy) reportUpstreamAttempts(attempts []schema.UpstreamAttempt) {
+	for _, a := range attempts {
+		if a.ConnectTime > 0 {
+			p.reporter.CaptureUpstreamConnectTime(a.ConnectTime)
+		}
+		if a.Error != "" {
+			p.reporter.CaptureUpstreamAttemptFailed()
+		}
+	}
 }
 
 func newReverseProxy(proxyTransport http.RoundTripper, req *http.Request,
 	routeServiceArgs routeservice.RouteServiceRequest,
 	routeServiceConfig *routeservice.RouteServiceConfig,
-	forceForwardedProtoHttps bool) http.Handler {
+	forceForwardedProtoHttps bool,
+	headerRules *rewrite.HeaderRules,
+	pathRewrite *route.PathRewrite) http.Handler {
 	rproxy := &httputil.ReverseProxy{
 		Director: func(request *http.Request) {
 			setupProxyRequest(req, request, forceForwardedProtoHttps)
+			rewritePath(request, pathRewrite)
 			handleRouteServiceIntegration(request, routeServiceArgs, routeServiceConfig)
+			headerRules.RewriteRequest(req, request)
 		},
 		Transport:     proxyTransport,
 		FlushInterval: 50 * time.Millisecond,
@@ -352,6 +441,17 @@ func handleRouteServiceIntegration(
 	}
 }
 
+func rewritePath(target *http.Request, pathRewrite *route.PathRewrite) {
+	if pathRewrite == nil {
+		return
+	}
+
+	if prefix := pathRewrite.Prefix(); prefix != "" {
+		target.Header.Set(router_http.ForwardedPrefixHeader, prefix)
+	}
+	target.URL.Opaque = pathRewrite.RewriteURI(target.URL.Opaque)
+}
+
 func setupProxyRequest(source *http.Request, target *http.Request, forceForwardedProtoHttps bool) {
 	if forceForwardedProtoHttps {
 		target.Header.Set("X-Forwarded-Proto", "https")
@@ -9,8 +9,12 @@ import (
 	"code.cloudfoundry.org/gorouter/access_log"
 	"code.cloudfoundry.org/gorouter/common/secure"
 	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/ipfilter"
 	"code.cloudfoundry.org/gorouter/proxy"
 	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/rewrite"
 	"code.cloudfoundry.org/gorouter/test_util"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
@@ -39,6 +43,10 @@ var (
 	caCertPool     *x509.CertPool
 	recommendHttps bool
 	heartbeatOK    int32
+	errorPages     *errorpage.Templates
+	headerRules    *rewrite.HeaderRules
+	ipFilter       *ipfilter.Filter
+	corsPolicies   *cors.Policies
 )
 
 func TestProxy(t *testing.T) {
@@ -59,6 +67,10 @@ var _ = BeforeEach(func() {
 	conf.TraceKey = "my_trace_key"
 	conf.EndpointTimeout = 500 * time.Millisecond
 	fakeReporter = &fakes.FakeProxyReporter{}
+	errorPages = nil
+	headerRules = nil
+	ipFilter = nil
+	corsPolicies = nil
 })
 
 var _ = JustBeforeEach(func() {
@@ -69,7 +81,7 @@ var _ = JustBeforeEach(func() {
 	dropsonde.InitializeWithEmitter(fakeEmitter)
 
 	accessLogFile = new(test_util.FakeFile)
-	accessLog = access_log.NewFileAndLoggregatorAccessLogger(logger, "", accessLogFile)
+	accessLog = access_log.NewFileAndLoggregatorAccessLogger(logger, nil, "", accessLogFile)
 	go accessLog.Run()
 
 	conf.EnableSSL = true
@@ -99,8 +111,13 @@ var _ = JustBeforeEach(func() {
 		HealthCheckUserAgent:       "HTTP-Monitor/1.1",
 		HeartbeatOK:                &heartbeatOK,
 		EnableZipkin:               conf.Tracing.EnableZipkin,
+		TraceFormat:                conf.Tracing.Format,
 		ExtraHeadersToLog:          &conf.ExtraHeadersToLog,
 		ForceForwardedProtoHttps:   conf.ForceForwardedProtoHttps,
+		ErrorPages:                 errorPages,
+		HeaderRules:                headerRules,
+		IPFilter:                   ipFilter,
+		CORSPolicies:               corsPolicies,
 	})
 
 	proxyServer, err = net.Listen("tcp", "127.0.0.1:0")
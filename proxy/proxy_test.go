@@ -12,6 +12,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,7 +20,12 @@ import (
 	"time"
 
 	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/cors"
+	"code.cloudfoundry.org/gorouter/errorpage"
+	"code.cloudfoundry.org/gorouter/ipfilter"
 	"code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/rewrite"
 	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/gorouter/test_util"
 	"code.cloudfoundry.org/routing-api/models"
@@ -348,6 +354,334 @@ var _ = Describe("Proxy", func() {
 		Expect(body).To(Equal("502 Bad Gateway: Registered endpoint failed to handle the request.\n"))
 	})
 
+	Context("with custom error pages", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "error-pages")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(dir, "unknown_route.html"), []byte(`<p>{{.Host}} {{.ErrorType}} {{.RequestId}}</p>`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "502.json"), []byte(`{"status": {{.StatusCode}}, "error": {{json .ErrorType}}}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			errorPages, err = errorpage.NewTemplates(logger, dir, 0)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("renders the html template for an unknown route", func() {
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "unknown", "/", nil)
+			req.Header.Set("Accept", "text/html")
+			req.Header.Set("X-Vcap-Request-Id", "some-request-id")
+			conn.WriteRequest(req)
+
+			resp, body := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("text/html; charset=utf-8"))
+			Expect(resp.Header.Get("X-Cf-RouterError")).To(Equal("unknown_route"))
+			Expect(body).To(Equal("<p>unknown unknown_route some-request-id</p>"))
+		})
+
+		It("renders the json template for a bad gateway", func() {
+			ln := registerHandler(r, "enfant-terrible", func(conn *test_util.HttpConn) {
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "enfant-terrible", "/", nil)
+			req.Header.Set("Accept", "application/json")
+			conn.WriteRequest(req)
+
+			resp, body := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(body).To(MatchJSON(`{"status": 502, "error": "endpoint_failure"}`))
+		})
+
+		It("keeps the plain text body for clients that do not negotiate", func() {
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "unknown", "/", nil)
+			conn.WriteRequest(req)
+
+			resp, body := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(body).To(Equal("404 Not Found: Requested route ('unknown') does not exist.\n"))
+		})
+	})
+
+	Context("with header rules", func() {
+		BeforeEach(func() {
+			var err error
+			headerRules, err = rewrite.NewHeaderRules([]config.HeaderRule{
+				{
+					Host: "header-rules",
+					Request: []config.HeaderAction{
+						{Action: "set", Name: "X-Request-Id-Copy", Value: "{{.RequestId}}"},
+						{Action: "remove", Name: "X-Internal"},
+					},
+					Response: []config.HeaderAction{
+						{Action: "remove", Name: "Server"},
+						{Action: "set", Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rewrites the request and response headers", func() {
+			ln := registerHandler(r, "header-rules", func(conn *test_util.HttpConn) {
+				req, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(req.Header.Get("X-Request-Id-Copy")).To(Equal(req.Header.Get(router_http.VcapRequestIdHeader)))
+				Expect(req.Header.Get("X-Internal")).To(BeEmpty())
+
+				resp := test_util.NewResponse(http.StatusOK)
+				resp.Header.Set("Server", "backend")
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "header-rules", "/", nil)
+			req.Header.Set("X-Internal", "secret")
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Server")).To(BeEmpty())
+			Expect(resp.Header.Get("Strict-Transport-Security")).To(Equal("max-age=31536000"))
+		})
+	})
+
+	Context("with a path rewrite", func() {
+		BeforeEach(func() {
+			conf.PathRewrites = []config.PathRewrite{
+				{Route: "rewrite-test/api/orders", StripPrefix: true},
+			}
+		})
+
+		It("strips the context path and maps redirects back", func() {
+			ln := registerHandler(r, "rewrite-test/api/orders", func(conn *test_util.HttpConn) {
+				req, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(req.URL.RequestURI()).To(Equal("/42?expand=true"))
+				Expect(req.Header.Get("X-Forwarded-Prefix")).To(Equal("/api/orders"))
+
+				resp := test_util.NewResponse(http.StatusFound)
+				resp.Header.Set("Location", "/login")
+				resp.Header.Add("Set-Cookie", "session=abc; Path=/")
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "rewrite-test", "/api/orders/42?expand=true", nil)
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusFound))
+			Expect(resp.Header.Get("Location")).To(Equal("/api/orders/login"))
+			Expect(resp.Header.Get("Set-Cookie")).To(Equal("session=abc; Path=/api/orders"))
+		})
+	})
+
+	Context("with a redirect route", func() {
+		It("answers with the redirect without contacting the backend", func() {
+			redirect, err := route.NewRedirect(http.StatusPermanentRedirect, "https://new.example.com{{.RequestURI}}")
+			Expect(err).NotTo(HaveOccurred())
+
+			endpoint := route.NewEndpoint("", "127.0.0.1", 1, "", "", nil, -1, "", models.ModificationTag{})
+			endpoint.Redirect = redirect
+			r.Register("old-domain", endpoint)
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "old-domain", "/some/path?foo=bar", nil)
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusPermanentRedirect))
+			Expect(resp.Header.Get("Location")).To(Equal("https://new.example.com/some/path?foo=bar"))
+		})
+	})
+
+	Context("with ip rules", func() {
+		BeforeEach(func() {
+			var err error
+			ipFilter, err = ipfilter.NewFilter([]config.IPRule{
+				{Host: "admin", Allow: []string{"10.0.0.0/8"}},
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("responds with 403 to clients outside of the allow list", func() {
+			ln := registerHandler(r, "admin", func(conn *test_util.HttpConn) {
+				Fail("the request should not reach the backend")
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "admin", "/", nil)
+			conn.WriteRequest(req)
+
+			resp, body := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+			Expect(resp.Header.Get("X-Cf-RouterError")).To(Equal("forbidden"))
+			Expect(body).To(Equal("403 Forbidden: Access to route ('admin') is not allowed from this address.\n"))
+			Expect(fakeReporter.CaptureForbiddenCallCount()).To(Equal(1))
+		})
+
+		Context("when the route has a cors policy", func() {
+			BeforeEach(func() {
+				var err error
+				corsPolicies, err = cors.NewPolicies([]config.CORSPolicy{
+					{Host: "admin", AllowedOrigins: []string{"*"}, AllowedMethods: []string{"PUT"}},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("responds with 403 to preflight requests before the cors policy answers them", func() {
+				ln := registerHandler(r, "admin", func(conn *test_util.HttpConn) {
+					Fail("the request should not reach the backend")
+				})
+				defer ln.Close()
+
+				conn := dialProxy(proxyServer)
+
+				req := test_util.NewRequest("OPTIONS", "admin", "/", nil)
+				req.Header.Set("Origin", "https://app.example.com")
+				req.Header.Set("Access-Control-Request-Method", "PUT")
+				conn.WriteRequest(req)
+
+				resp, _ := conn.ReadResponse()
+				Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+				Expect(resp.Header.Get("X-Cf-RouterError")).To(Equal("forbidden"))
+				Expect(resp.Header).NotTo(HaveKey("Access-Control-Allow-Origin"))
+			})
+		})
+
+		It("responds with 404 to unknown routes", func() {
+			conn := dialProxy(proxyServer)
+
+			conn.WriteRequest(test_util.NewRequest("GET", "admin", "/", nil))
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+
+		It("does not affect other routes", func() {
+			ln := registerHandler(r, "public", func(conn *test_util.HttpConn) {
+				_, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			conn.WriteRequest(test_util.NewRequest("GET", "public", "/", nil))
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("with cors policies", func() {
+		BeforeEach(func() {
+			var err error
+			corsPolicies, err = cors.NewPolicies([]config.CORSPolicy{
+				{Host: "cors", AllowedOrigins: []string{"https://*.example.com"}, AllowedMethods: []string{"GET", "PUT"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("answers preflight requests without reaching the backend", func() {
+			ln := registerHandler(r, "cors", func(conn *test_util.HttpConn) {
+				Fail("the request should not reach the backend")
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("OPTIONS", "cors", "/", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("https://app.example.com"))
+			Expect(resp.Header.Get("Access-Control-Allow-Methods")).To(Equal("GET, PUT"))
+		})
+
+		It("replaces the cors headers of backend responses", func() {
+			ln := registerHandler(r, "cors", func(conn *test_util.HttpConn) {
+				_, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+
+				resp := test_util.NewResponse(http.StatusOK)
+				resp.Header.Set("Access-Control-Allow-Origin", "*")
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("GET", "cors", "/", nil)
+			req.Header.Set("Origin", "https://evil.com")
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header).NotTo(HaveKey("Access-Control-Allow-Origin"))
+		})
+
+		It("forwards preflight requests for other routes", func() {
+			ln := registerHandler(r, "no-cors", func(conn *test_util.HttpConn) {
+				req, err := http.ReadRequest(conn.Reader)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(req.Method).To(Equal("OPTIONS"))
+
+				resp := test_util.NewResponse(http.StatusOK)
+				resp.Header.Set("Access-Control-Allow-Origin", "*")
+				conn.WriteResponse(resp)
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+
+			req := test_util.NewRequest("OPTIONS", "no-cors", "/", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+			conn.WriteRequest(req)
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+			Expect(resp.Header).NotTo(HaveKey("Vary"))
+		})
+	})
+
 	It("trace headers added on correct TraceKey", func() {
 		ln := registerHandler(r, "trace-test", func(conn *test_util.HttpConn) {
 			_, err := http.ReadRequest(conn.Reader)
@@ -431,6 +765,7 @@ var _ = Describe("Proxy", func() {
 			}).ShouldNot(BeZero())
 
 			Expect(string(payload)).To(ContainSubstring(fmt.Sprintf(`x_b3_traceid:"%s"`, answer)))
+			Expect(string(payload)).To(ContainSubstring(fmt.Sprintf(` trace_id:"%s"`, answer)))
 		})
 	})
 
@@ -1194,6 +1529,31 @@ var _ = Describe("Proxy", func() {
 			Expect(payload[len(payload)-1]).To(Equal(byte('\n')))
 		})
 
+		It("Logs the upstream attempts and reports their connect time", func() {
+			ln := registerHandler(r, "upstream", func(conn *test_util.HttpConn) {
+				conn.CheckLine("GET / HTTP/1.1")
+				conn.WriteResponse(test_util.NewResponse(http.StatusOK))
+				conn.Close()
+			})
+			defer ln.Close()
+
+			conn := dialProxy(proxyServer)
+			conn.WriteRequest(test_util.NewRequest("GET", "upstream", "/", nil))
+
+			resp, _ := conn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var payload []byte
+			Eventually(func() int {
+				accessLogFile.Read(&payload)
+				return len(payload)
+			}).ShouldNot(BeZero())
+
+			Expect(string(payload)).To(MatchRegexp(`lookup_time:[0-9.e-]+ upstream_attempts:1 upstream_addr:"[0-9.:]+" upstream_connect_time:"[0-9.e-]+" upstream_header_time:"[0-9.e-]+" upstream_error:"-"`))
+			Expect(fakeReporter.CaptureUpstreamConnectTimeCallCount()).To(Equal(1))
+			Expect(fakeReporter.CaptureUpstreamAttemptFailedCallCount()).To(Equal(0))
+		})
+
 		It("Logs a request when it exits early", func() {
 			conn := dialProxy(proxyServer)
 
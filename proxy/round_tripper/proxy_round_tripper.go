@@ -4,27 +4,37 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/gorouter/access_log/schema"
 	"code.cloudfoundry.org/gorouter/proxy/handler"
 	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"code.cloudfoundry.org/lager"
 )
 
 type AfterRoundTrip func(rsp *http.Response, endpoint *route.Endpoint, err error)
 
+// NewProxyRoundTripper returns a round tripper that records every attempt
+// in accessLog, unless it is nil.
 func NewProxyRoundTripper(backend bool, transport http.RoundTripper, endpointIterator route.EndpointIterator,
-	logger lager.Logger, afterRoundTrip AfterRoundTrip) http.RoundTripper {
+	logger lager.Logger, accessLog *schema.AccessLogRecord, afterRoundTrip AfterRoundTrip) http.RoundTripper {
 	if backend {
 		return &BackendRoundTripper{
 			transport: transport,
 			iter:      endpointIterator,
 			logger:    logger,
+			accessLog: accessLog,
 			after:     afterRoundTrip,
 		}
 	} else {
 		return &RouteServiceRoundTripper{
 			transport: transport,
 			logger:    logger,
+			accessLog: accessLog,
 			after:     afterRoundTrip,
 		}
 	}
@@ -34,6 +44,7 @@ type BackendRoundTripper struct {
 	iter      route.EndpointIterator
 	transport http.RoundTripper
 	logger    lager.Logger
+	accessLog *schema.AccessLogRecord
 	after     AfterRoundTrip
 }
 
@@ -58,14 +69,22 @@ func (rt *BackendRoundTripper) RoundTrip(request *http.Request) (*http.Response,
 
 		rt.setupRequest(request, endpoint)
 
+		span := tracing.FromContext(request.Context()).Child("backend", tracing.KindClient)
+		span.SetTag("peer.address", endpoint.CanonicalAddr())
+		span.SetTag("cf.app_id", endpoint.ApplicationId)
+		span.SetTag("retry", strconv.Itoa(retry))
+		span.Inject(request.Header)
+
 		// increment connection stats
 		rt.iter.PreRequest(endpoint)
 
-		res, err = rt.transport.RoundTrip(request)
+		res, err = roundTripAttempt(rt.transport, request, endpoint.CanonicalAddr(), false, rt.accessLog)
 
 		// decrement connection stats
 		rt.iter.PostRequest(endpoint)
 
+		finishSpan(span, res, err)
+
 		if err == nil || !retryableError(err) {
 			break
 		}
@@ -110,6 +129,7 @@ type RouteServiceRoundTripper struct {
 	transport http.RoundTripper
 	after     AfterRoundTrip
 	logger    lager.Logger
+	accessLog *schema.AccessLogRecord
 }
 
 func (rt *RouteServiceRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
@@ -117,7 +137,15 @@ func (rt *RouteServiceRoundTripper) RoundTrip(request *http.Request) (*http.Resp
 	var res *http.Response
 
 	for retry := 0; retry < handler.MaxRetries; retry++ {
-		res, err = rt.transport.RoundTrip(request)
+		span := tracing.FromContext(request.Context()).Child("route-service", tracing.KindClient)
+		span.SetTag("peer.address", request.URL.Host)
+		span.SetTag("retry", strconv.Itoa(retry))
+		span.Inject(request.Header)
+
+		res, err = roundTripAttempt(rt.transport, request, request.URL.Host, true, rt.accessLog)
+
+		finishSpan(span, res, err)
+
 		if err == nil || !retryableError(err) {
 			break
 		}
@@ -137,6 +165,79 @@ func (rs *RouteServiceRoundTripper) reportError(err error) {
 	rs.logger.Error("route-service-failed", err)
 }
 
+// attemptTrace times getting a connection for an attempt. Transports may
+// call its hooks from other goroutines.
+type attemptTrace struct {
+	mu          sync.Mutex
+	getConnAt   time.Time
+	connectTime time.Duration
+	gotConn     bool
+}
+
+func (t *attemptTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			t.mu.Lock()
+			t.getConnAt = time.Now()
+			t.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			if !info.Reused && !t.getConnAt.IsZero() {
+				t.connectTime = time.Since(t.getConnAt)
+			}
+			t.gotConn = true
+			t.mu.Unlock()
+		},
+	}
+}
+
+// roundTripAttempt sends request to addr through transport and appends the
+// attempt to accessLog, unless it is nil.
+func roundTripAttempt(transport http.RoundTripper, request *http.Request, addr string, routeService bool,
+	accessLog *schema.AccessLogRecord) (*http.Response, error) {
+	if accessLog == nil {
+		return transport.RoundTrip(request)
+	}
+
+	trace := &attemptTrace{}
+	attempt := schema.UpstreamAttempt{
+		Addr:         addr,
+		RouteService: routeService,
+		StartedAt:    time.Now(),
+	}
+
+	res, err := transport.RoundTrip(request.WithContext(httptrace.WithClientTrace(request.Context(), trace.clientTrace())))
+
+	trace.mu.Lock()
+	attempt.ConnectTime = trace.connectTime
+	// A connection that could not be made took the whole attempt.
+	if !trace.gotConn && !trace.getConnAt.IsZero() {
+		attempt.ConnectTime = time.Since(trace.getConnAt)
+	}
+	trace.mu.Unlock()
+
+	if err != nil {
+		attempt.Error = err.Error()
+	} else {
+		attempt.HeaderTime = time.Since(attempt.StartedAt)
+	}
+
+	accessLog.UpstreamAttempts = append(accessLog.UpstreamAttempts, attempt)
+	return res, err
+}
+
+// finishSpan records the outcome of an attempt. The span ends when the
+// response headers have been received.
+func finishSpan(span *tracing.Span, res *http.Response, err error) {
+	if err != nil {
+		span.SetError(err)
+	} else if res != nil {
+		span.SetTag("http.status_code", strconv.Itoa(res.StatusCode))
+	}
+	span.Finish()
+}
+
 func retryableError(err error) bool {
 	ne, netErr := err.(*net.OpError)
 	if netErr && ne.Op == "dial" {
@@ -2,9 +2,18 @@ package round_tripper_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
+	"code.cloudfoundry.org/gorouter/access_log/schema"
+	router_http "code.cloudfoundry.org/gorouter/common/http"
 	"code.cloudfoundry.org/gorouter/proxy/handler"
 	"code.cloudfoundry.org/gorouter/proxy/round_tripper"
 	roundtripperfakes "code.cloudfoundry.org/gorouter/proxy/round_tripper/fakes"
@@ -13,15 +22,36 @@ import (
 	routefakes "code.cloudfoundry.org/gorouter/route/fakes"
 	"code.cloudfoundry.org/gorouter/routeservice"
 	"code.cloudfoundry.org/gorouter/test_util"
+	"code.cloudfoundry.org/gorouter/tracing"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
 )
 
 type nullVarz struct{}
 
+type fakeSpanExporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (f *fakeSpanExporter) Export(spans []*tracing.Span) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.spans = append(f.spans, spans...)
+	return nil
+}
+
+func (f *fakeSpanExporter) Spans() []*tracing.Span {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.spans
+}
+
 var _ = Describe("ProxyRoundTripper", func() {
 	Context("RoundTrip", func() {
 		var (
@@ -29,6 +59,7 @@ var _ = Describe("ProxyRoundTripper", func() {
 			endpointIterator  *routefakes.FakeEndpointIterator
 			transport         *roundtripperfakes.FakeRoundTripper
 			logger            lager.Logger
+			accessLog         *schema.AccessLogRecord
 			req               *http.Request
 			resp              *proxyfakes.FakeProxyResponseWriter
 			dialError         = &net.OpError{
@@ -45,6 +76,7 @@ var _ = Describe("ProxyRoundTripper", func() {
 
 			logger = lagertest.NewTestLogger("test")
 			transport = &roundtripperfakes.FakeRoundTripper{}
+			accessLog = &schema.AccessLogRecord{}
 		})
 
 		Context("backend", func() {
@@ -58,7 +90,7 @@ var _ = Describe("ProxyRoundTripper", func() {
 				var after round_tripper.AfterRoundTrip
 				servingBackend := true
 				proxyRoundTripper = round_tripper.NewProxyRoundTripper(
-					servingBackend, transport, endpointIterator, logger, after)
+					servingBackend, transport, endpointIterator, logger, accessLog, after)
 			})
 
 			Context("when backend is unavailable", func() {
@@ -109,6 +141,106 @@ var _ = Describe("ProxyRoundTripper", func() {
 					Expect(err).ToNot(HaveOccurred())
 					Expect(endpointIterator.NextCallCount()).To(Equal(2))
 				})
+
+				It("records every attempt in the access log record", func() {
+					_, err := proxyRoundTripper.RoundTrip(req)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(accessLog.UpstreamAttempts).To(HaveLen(2))
+					Expect(accessLog.UpstreamAttempts[0].Error).To(Equal(dialError.Error()))
+					Expect(accessLog.UpstreamAttempts[0].HeaderTime).To(BeZero())
+					Expect(accessLog.UpstreamAttempts[1].Error).To(BeEmpty())
+					for _, a := range accessLog.UpstreamAttempts {
+						Expect(a.RouteService).To(BeFalse())
+						Expect(a.StartedAt).ToNot(BeZero())
+					}
+				})
+
+				Context("when the request is traced", func() {
+					var (
+						exporter *fakeSpanExporter
+						process  ifrit.Process
+						parent   *tracing.Span
+					)
+
+					BeforeEach(func() {
+						exporter = &fakeSpanExporter{}
+						tracer := tracing.New(exporter, router_http.TraceFormatB3, 1, 10, 2, 10*time.Millisecond, logger)
+						process = ifrit.Invoke(tracer)
+
+						parent = tracer.StartSpan(router_http.TraceContext{
+							TraceId: "a3ce929d0e0e4736",
+							SpanId:  "00f067aa0ba902b7",
+							Sampled: "1",
+						}, "GET")
+						req = req.WithContext(tracing.NewContext(req.Context(), parent))
+					})
+
+					AfterEach(func() {
+						process.Signal(os.Interrupt)
+						Eventually(process.Wait()).Should(Receive())
+					})
+
+					It("records a client span for every attempt", func() {
+						_, err := proxyRoundTripper.RoundTrip(req)
+						Expect(err).ToNot(HaveOccurred())
+
+						Eventually(exporter.Spans).Should(HaveLen(2))
+						spans := exporter.Spans()
+						for _, span := range spans {
+							Expect(span.Name).To(Equal("backend"))
+							Expect(span.Kind).To(Equal(tracing.KindClient))
+							Expect(span.ParentId).To(Equal(parent.Id))
+						}
+						Expect(spans[0].Error).To(BeTrue())
+						Expect(spans[0].Tags).To(HaveKeyWithValue("retry", "0"))
+						Expect(spans[1].Error).To(BeFalse())
+						Expect(spans[1].Tags).To(HaveKeyWithValue("retry", "1"))
+
+						Expect(req.Header.Get(router_http.B3SpanIdHeader)).To(Equal(spans[1].Id))
+					})
+				})
+			})
+		})
+
+		Context("backend over a real transport", func() {
+			var backend *httptest.Server
+
+			BeforeEach(func() {
+				backend = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+				backendURL, err := url.Parse(backend.URL)
+				Expect(err).ToNot(HaveOccurred())
+				host, port, err := net.SplitHostPort(backendURL.Host)
+				Expect(err).ToNot(HaveOccurred())
+				portNum, err := strconv.Atoi(port)
+				Expect(err).ToNot(HaveOccurred())
+
+				endpointIterator.NextReturns(route.NewEndpoint("app-id", host, uint16(portNum), "", "", nil, -1, "", models.ModificationTag{}))
+
+				var after round_tripper.AfterRoundTrip
+				proxyRoundTripper = round_tripper.NewProxyRoundTripper(
+					true, &http.Transport{}, endpointIterator, logger, accessLog, after)
+			})
+
+			AfterEach(func() {
+				backend.Close()
+			})
+
+			It("records the connect time of new connections only", func() {
+				for i := 0; i < 2; i++ {
+					res, err := proxyRoundTripper.RoundTrip(test_util.NewRequest("GET", "myapp.com", "/", nil))
+					Expect(err).ToNot(HaveOccurred())
+					ioutil.ReadAll(res.Body)
+					res.Body.Close()
+				}
+
+				Expect(accessLog.UpstreamAttempts).To(HaveLen(2))
+				Expect(accessLog.UpstreamAttempts[0].Addr).To(Equal(backend.Listener.Addr().String()))
+				Expect(accessLog.UpstreamAttempts[0].ConnectTime).To(BeNumerically(">", 0))
+				Expect(accessLog.UpstreamAttempts[0].HeaderTime).To(BeNumerically(">=", accessLog.UpstreamAttempts[0].ConnectTime))
+				Expect(accessLog.UpstreamAttempts[1].ConnectTime).To(BeZero())
 			})
 		})
 
@@ -126,7 +258,7 @@ var _ = Describe("ProxyRoundTripper", func() {
 					Expect(endpoint.Tags).ShouldNot(BeNil())
 				}
 				proxyRoundTripper = round_tripper.NewProxyRoundTripper(
-					servingBackend, transport, endpointIterator, logger, after)
+					servingBackend, transport, endpointIterator, logger, accessLog, after)
 			})
 
 			It("does not fetch the next endpoint", func() {
@@ -175,6 +307,17 @@ var _ = Describe("ProxyRoundTripper", func() {
 					Expect(err).To(HaveOccurred())
 					Expect(roundTripCallCount).To(Equal(3))
 				})
+
+				It("records the attempts as route service attempts", func() {
+					proxyRoundTripper.RoundTrip(req)
+
+					Expect(accessLog.UpstreamAttempts).To(HaveLen(3))
+					for _, a := range accessLog.UpstreamAttempts {
+						Expect(a.Addr).To(Equal("myapp.com"))
+						Expect(a.RouteService).To(BeTrue())
+						Expect(a.Error).ToNot(BeEmpty())
+					}
+				})
 			})
 		})
 	})
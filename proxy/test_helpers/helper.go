@@ -16,6 +16,9 @@ func (_ NullVarz) MarshalJSON() ([]byte, error)
 func (_ NullVarz) ActiveApps() *stats.ActiveApps                                                    { return stats.NewActiveApps() }
 func (_ NullVarz) CaptureBadRequest(*http.Request)                                                  {}
 func (_ NullVarz) CaptureBadGateway(*http.Request)                                                  {}
+func (_ NullVarz) CaptureForbidden(*http.Request)                                                   {}
 func (_ NullVarz) CaptureRoutingRequest(b *route.Endpoint, req *http.Request)                       {}
 func (_ NullVarz) CaptureRoutingResponse(*route.Endpoint, *http.Response, time.Time, time.Duration) {}
+func (_ NullVarz) CaptureUpstreamConnectTime(time.Duration)                                         {}
+func (_ NullVarz) CaptureUpstreamAttemptFailed()                                                    {}
 func (_ NullVarz) CaptureRegistryMessage(msg reporter.ComponentTagged)                              {}
@@ -0,0 +1,251 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/registry/container"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const (
+	AdminAPIPrefix = "/admin/"
+
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 1000
+)
+
+// AdminAPI serves read-only views of the routing table:
+//
+//	GET /admin/routes                         routes sorted by URI, paged with
+//	                                          page and per_page, filtered by
+//	                                          host and app_id
+//	GET /admin/routes/<uri>                   the pool of one route
+//	GET /admin/apps/<app_id>/routes           the routes of an app
+//	GET /admin/instances/<instance_id>/routes the routes of an app instance
+type AdminAPI struct {
+	registry *RouteRegistry
+}
+
+func NewAdminAPI(r *RouteRegistry) *AdminAPI {
+	return &AdminAPI{registry: r}
+}
+
+type adminRoute struct {
+	Uri             route.Uri `json:"uri"`
+	Endpoints       int       `json:"endpoints"`
+	AppIds          []string  `json:"app_ids,omitempty"`
+	RouteServiceUrl string    `json:"route_service_url,omitempty"`
+	Redirect        string    `json:"redirect,omitempty"`
+}
+
+type adminRoutePage struct {
+	Total   int          `json:"total"`
+	Page    int          `json:"page"`
+	PerPage int          `json:"per_page"`
+	Routes  []adminRoute `json:"routes"`
+}
+
+type adminPool struct {
+	Uri             route.Uri              `json:"uri"`
+	ContextPath     string                 `json:"context_path,omitempty"`
+	RouteServiceUrl string                 `json:"route_service_url,omitempty"`
+	Redirect        string                 `json:"redirect,omitempty"`
+	Endpoints       []route.EndpointStatus `json:"endpoints"`
+}
+
+type adminPools struct {
+	ApplicationId     string      `json:"app_id,omitempty"`
+	PrivateInstanceId string      `json:"private_instance_id,omitempty"`
+	Routes            []adminPool `json:"routes"`
+}
+
+type uriPool struct {
+	uri  route.Uri
+	pool *route.Pool
+}
+
+type uriPools []uriPool
+
+func (p uriPools) Len() int           { return len(p) }
+func (p uriPools) Less(i, j int) bool { return p[i].uri < p[j].uri }
+func (p uriPools) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func (a *AdminAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, AdminAPIPrefix)
+	parts := strings.SplitN(path, "/", 3)
+
+	switch {
+	case path == "routes":
+		a.listRoutes(w, req)
+	case parts[0] == "routes" && len(parts) > 1:
+		a.showRoute(w, route.Uri(strings.TrimPrefix(path, "routes/")))
+	case len(parts) == 3 && parts[0] == "apps" && parts[1] != "" && parts[2] == "routes":
+		writeAdminJSON(w, adminPools{
+			ApplicationId: parts[1],
+			Routes: a.poolsOf(func(s route.EndpointStatus) bool {
+				return s.ApplicationId == parts[1]
+			}),
+		})
+	case len(parts) == 3 && parts[0] == "instances" && parts[1] != "" && parts[2] == "routes":
+		writeAdminJSON(w, adminPools{
+			PrivateInstanceId: parts[1],
+			Routes: a.poolsOf(func(s route.EndpointStatus) bool {
+				return s.PrivateInstanceId == parts[1]
+			}),
+		})
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (a *AdminAPI) listRoutes(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	page, err := adminQueryInt(query.Get("page"), 1, math.MaxInt32)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid page: %s", err), http.StatusBadRequest)
+		return
+	}
+	perPage, err := adminQueryInt(query.Get("per_page"), defaultAdminPageSize, maxAdminPageSize)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid per_page: %s", err), http.StatusBadRequest)
+		return
+	}
+	host := query.Get("host")
+	appId := query.Get("app_id")
+
+	routes := []adminRoute{}
+	for _, p := range a.registry.uriPools() {
+		if host != "" && !strings.EqualFold(host, routeHost(p.uri)) {
+			continue
+		}
+
+		r := adminRoute{
+			Uri:             p.uri,
+			RouteServiceUrl: p.pool.RouteServiceUrl(),
+		}
+		if redirect := p.pool.Redirect(); redirect != nil {
+			r.Redirect = redirect.Target
+		}
+		apps := map[string]struct{}{}
+		p.pool.Each(func(e *route.Endpoint) {
+			r.Endpoints++
+			if e.ApplicationId != "" {
+				apps[e.ApplicationId] = struct{}{}
+			}
+		})
+		if _, ok := apps[appId]; appId != "" && !ok {
+			continue
+		}
+		for id := range apps {
+			r.AppIds = append(r.AppIds, id)
+		}
+		sort.Strings(r.AppIds)
+
+		routes = append(routes, r)
+	}
+
+	result := adminRoutePage{
+		Total:   len(routes),
+		Page:    page,
+		PerPage: perPage,
+		Routes:  []adminRoute{},
+	}
+	if start := (page - 1) * perPage; start < len(routes) {
+		end := start + perPage
+		if end > len(routes) {
+			end = len(routes)
+		}
+		result.Routes = routes[start:end]
+	}
+	writeAdminJSON(w, result)
+}
+
+func (a *AdminAPI) showRoute(w http.ResponseWriter, uri route.Uri) {
+	uri = uri.RouteKey()
+
+	a.registry.RLock()
+	pool := a.registry.byUri.Find(uri)
+	a.registry.RUnlock()
+
+	if pool == nil {
+		http.Error(w, fmt.Sprintf("route not found: %s", uri), http.StatusNotFound)
+		return
+	}
+	writeAdminJSON(w, newAdminPool(uri, pool, pool.EndpointStatuses()))
+}
+
+// poolsOf returns the routes with endpoints that match, with only those
+// endpoints
+func (a *AdminAPI) poolsOf(match func(route.EndpointStatus) bool) []adminPool {
+	pools := []adminPool{}
+	for _, p := range a.registry.uriPools() {
+		var endpoints []route.EndpointStatus
+		for _, s := range p.pool.EndpointStatuses() {
+			if match(s) {
+				endpoints = append(endpoints, s)
+			}
+		}
+		if len(endpoints) > 0 {
+			pools = append(pools, newAdminPool(p.uri, p.pool, endpoints))
+		}
+	}
+	return pools
+}
+
+func newAdminPool(uri route.Uri, pool *route.Pool, endpoints []route.EndpointStatus) adminPool {
+	p := adminPool{
+		Uri:             uri,
+		ContextPath:     pool.ContextPath(),
+		RouteServiceUrl: pool.RouteServiceUrl(),
+		Endpoints:       endpoints,
+	}
+	if redirect := pool.Redirect(); redirect != nil {
+		p.Redirect = redirect.Target
+	}
+	return p
+}
+
+// uriPools returns every route with its pool, sorted by URI
+func (r *RouteRegistry) uriPools() uriPools {
+	var pools uriPools
+
+	r.RLock()
+	r.byUri.EachNodeWithPool(func(t *container.Trie) {
+		pools = append(pools, uriPool{uri: route.Uri(t.ToPath()), pool: t.Pool})
+	})
+	r.RUnlock()
+
+	sort.Sort(pools)
+	return pools
+}
+
+func adminQueryInt(value string, defaultValue, max int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if i < 1 || i > max {
+		return 0, fmt.Errorf("%d is not between 1 and %d", i, max)
+	}
+	return i, nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,168 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	. "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AdminAPI", func() {
+	var (
+		r     *RouteRegistry
+		admin *AdminAPI
+	)
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest(method, "http://status"+path, nil))
+		return w
+	}
+
+	get := func(path string) map[string]interface{} {
+		w := request("GET", path)
+		Expect(w.Code).To(Equal(http.StatusOK), w.Body.String())
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var body map[string]interface{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+		return body
+	}
+
+	uris := func(routes interface{}) []string {
+		var u []string
+		for _, r := range routes.([]interface{}) {
+			u = append(u, r.(map[string]interface{})["uri"].(string))
+		}
+		return u
+	}
+
+	BeforeEach(func() {
+		c := config.DefaultConfig()
+		c.RedirectRoutes = []config.RedirectRoute{{Route: "old.example.com", Target: "https://new.example.com/"}}
+		r = NewRouteRegistry(lagertest.NewTestLogger("test"), c, new(fakes.FakeRouteRegistryReporter))
+		admin = NewAdminAPI(r)
+
+		modTag := models.ModificationTag{Guid: "abc", Index: 1}
+		r.Register("foo.example.com", route.NewEndpoint("app-1", "10.0.0.1", 8080, "instance-1", "0",
+			map[string]string{"component": "web"}, 60, "", modTag))
+		r.Register("foo.example.com", route.NewEndpoint("app-1", "10.0.0.2", 8080, "instance-2", "1", nil, 60, "", modTag))
+		r.Register("foo.example.com/api", route.NewEndpoint("app-2", "10.0.0.3", 8080, "instance-3", "0", nil, 60, "https://rs.example.com", modTag))
+		r.Register("bar.example.com", route.NewEndpoint("app-2", "10.0.0.4", 8080, "instance-4", "0", nil, 60, "", modTag))
+	})
+
+	Context("listing routes", func() {
+		It("lists routes sorted by uri", func() {
+			body := get("/admin/routes")
+			Expect(body["total"]).To(BeEquivalentTo(4))
+			Expect(body["page"]).To(BeEquivalentTo(1))
+			Expect(body["per_page"]).To(BeEquivalentTo(50))
+			Expect(uris(body["routes"])).To(Equal([]string{"bar.example.com", "foo.example.com", "foo.example.com/api", "old.example.com"}))
+
+			routes := body["routes"].([]interface{})
+			Expect(routes[1]).To(Equal(map[string]interface{}{
+				"uri":       "foo.example.com",
+				"endpoints": float64(2),
+				"app_ids":   []interface{}{"app-1"},
+			}))
+			Expect(routes[2].(map[string]interface{})["route_service_url"]).To(Equal("https://rs.example.com"))
+			Expect(routes[3]).To(Equal(map[string]interface{}{
+				"uri":       "old.example.com",
+				"endpoints": float64(0),
+				"redirect":  "https://new.example.com/",
+			}))
+		})
+
+		It("pages routes", func() {
+			body := get("/admin/routes?page=2&per_page=3")
+			Expect(body["total"]).To(BeEquivalentTo(4))
+			Expect(uris(body["routes"])).To(Equal([]string{"old.example.com"}))
+
+			body = get("/admin/routes?page=3&per_page=3")
+			Expect(body["routes"]).To(BeEmpty())
+		})
+
+		It("filters routes by host and app", func() {
+			Expect(uris(get("/admin/routes?host=FOO.example.com")["routes"])).To(Equal([]string{"foo.example.com", "foo.example.com/api"}))
+			Expect(uris(get("/admin/routes?app_id=app-2")["routes"])).To(Equal([]string{"bar.example.com", "foo.example.com/api"}))
+			Expect(uris(get("/admin/routes?host=foo.example.com&app_id=app-2")["routes"])).To(Equal([]string{"foo.example.com/api"}))
+		})
+
+		It("rejects invalid paging", func() {
+			for _, query := range []string{"page=0", "page=x", "per_page=0", "per_page=1001"} {
+				Expect(request("GET", "/admin/routes?"+query).Code).To(Equal(http.StatusBadRequest), query)
+			}
+		})
+	})
+
+	Context("showing a route", func() {
+		It("returns the pool of the route", func() {
+			body := get("/admin/routes/Foo.example.com")
+			Expect(body["uri"]).To(Equal("foo.example.com"))
+
+			endpoints := body["endpoints"].([]interface{})
+			Expect(endpoints).To(HaveLen(2))
+			endpoint := endpoints[0].(map[string]interface{})
+			Expect(endpoint["address"]).To(Equal("10.0.0.1:8080"))
+			Expect(endpoint["app_id"]).To(Equal("app-1"))
+			Expect(endpoint["private_instance_id"]).To(Equal("instance-1"))
+			Expect(endpoint["tags"]).To(Equal(map[string]interface{}{"component": "web"}))
+			Expect(endpoint["modification_tag"]).To(Equal(map[string]interface{}{"guid": "abc", "index": float64(1)}))
+			Expect(endpoint["ttl"]).To(BeEquivalentTo(60))
+			Expect(endpoint).To(HaveKey("last_updated"))
+			Expect(endpoint).To(HaveKey("last_update_age_seconds"))
+			Expect(endpoint["connections"]).To(BeEquivalentTo(0))
+			Expect(endpoint["failing"]).To(BeFalse())
+		})
+
+		It("returns routes with a path", func() {
+			body := get("/admin/routes/foo.example.com/api")
+			Expect(body["uri"]).To(Equal("foo.example.com/api"))
+			Expect(body["context_path"]).To(Equal("/api"))
+			Expect(body["route_service_url"]).To(Equal("https://rs.example.com"))
+		})
+
+		It("returns 404 for unknown routes", func() {
+			Expect(request("GET", "/admin/routes/unknown.example.com").Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	It("lists the routes of an app", func() {
+		body := get("/admin/apps/app-2/routes")
+		Expect(body["app_id"]).To(Equal("app-2"))
+		Expect(uris(body["routes"])).To(Equal([]string{"bar.example.com", "foo.example.com/api"}))
+
+		Expect(get("/admin/apps/unknown/routes")["routes"]).To(BeEmpty())
+	})
+
+	It("lists the routes of an instance with its endpoints only", func() {
+		body := get("/admin/instances/instance-2/routes")
+		Expect(body["private_instance_id"]).To(Equal("instance-2"))
+		Expect(uris(body["routes"])).To(Equal([]string{"foo.example.com"}))
+
+		endpoints := body["routes"].([]interface{})[0].(map[string]interface{})["endpoints"].([]interface{})
+		Expect(endpoints).To(HaveLen(1))
+		Expect(endpoints[0].(map[string]interface{})["address"]).To(Equal("10.0.0.2:8080"))
+	})
+
+	It("is read-only", func() {
+		for _, method := range []string{"POST", "PUT", "DELETE", "PATCH"} {
+			Expect(request(method, "/admin/routes").Code).To(Equal(http.StatusMethodNotAllowed), method)
+		}
+	})
+
+	It("returns 404 for unknown paths", func() {
+		for _, path := range []string{"/admin/", "/admin/apps", "/admin/apps//routes", "/admin/instances/instance-1"} {
+			Expect(request("GET", path).Code).To(Equal(http.StatusNotFound), path)
+		}
+	})
+})
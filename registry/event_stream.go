@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// eventStreamWriteTimeout is how long a write to an event stream may block
+// before the client is considered gone
+const eventStreamWriteTimeout = 10 * time.Second
+
+// EventStream serves the changes of the registry as they happen, as
+// server-sent events or, with format=json, as one JSON object per line. The
+// host and app_id query parameters limit the events to a host and an app.
+//
+// The connection is hijacked so that the timeouts of the status server do not
+// end the stream. Instead each write must complete within
+// eventStreamWriteTimeout. The response ends when the client goes away, falls
+// too far behind or the registry events are closed.
+type EventStream struct {
+	logger    lager.Logger
+	registry  *RouteRegistry
+	heartbeat time.Duration
+}
+
+// NewEventStream returns an EventStream that writes a heartbeat when there
+// were no events for the heartbeat interval, so that clients and proxies keep
+// the connection open.
+func NewEventStream(logger lager.Logger, r *RouteRegistry, heartbeat time.Duration) *EventStream {
+	return &EventStream{
+		logger:    logger,
+		registry:  r,
+		heartbeat: heartbeat,
+	}
+}
+
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := req.URL.Query()
+	format := query.Get("format")
+	contentType := "text/event-stream"
+	switch format {
+	case "", "sse":
+		format = "sse"
+	case "json":
+		contentType = "application/x-ndjson"
+	default:
+		http.Error(w, fmt.Sprintf("unknown format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := RouteEventFilter{Host: query.Get("host"), ApplicationId: query.Get("app_id")}
+	events, cancel := s.registry.SubscribeEvents(filter)
+	defer cancel()
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		s.logger.Error("route-event-stream-failed", err)
+		return
+	}
+	defer conn.Close()
+	// Clear the deadlines of the status server, writes get their own below.
+	conn.SetDeadline(time.Time{})
+
+	logData := lager.Data{"remote_addr": req.RemoteAddr, "host": filter.Host, "app_id": filter.ApplicationId, "format": format}
+	s.logger.Info("route-event-stream-started", logData)
+	defer s.logger.Info("route-event-stream-ended", logData)
+
+	flush := func() error {
+		conn.SetWriteDeadline(time.Now().Add(eventStreamWriteTimeout))
+		return buf.Flush()
+	}
+
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: %s\r\nCache-Control: no-cache\r\nConnection: close\r\n\r\n", contentType)
+	if err := flush(); err != nil {
+		return
+	}
+
+	// Nothing more is expected from the client, a read returns when it
+	// closes the connection.
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, buf.Reader)
+		close(gone)
+	}()
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				if !s.registry.events.closed() {
+					s.logger.Info("route-event-stream-dropped", logData)
+				}
+				return
+			}
+			b, err := json.Marshal(e)
+			if err != nil {
+				s.logger.Error("route-event-marshal-failed", err)
+				continue
+			}
+			if format == "sse" {
+				fmt.Fprintf(buf, "event: %s\ndata: %s\n\n", e.Type, b)
+			} else {
+				fmt.Fprintf(buf, "%s\n", b)
+			}
+		case <-heartbeat.C:
+			if format == "sse" {
+				fmt.Fprint(buf, ": heartbeat\n\n")
+			} else {
+				fmt.Fprint(buf, "\n")
+			}
+		case <-gone:
+			return
+		}
+
+		if err := flush(); err != nil {
+			return
+		}
+	}
+}
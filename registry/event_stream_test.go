@@ -0,0 +1,131 @@
+package registry_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	. "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventStream", func() {
+	var (
+		r           *RouteRegistry
+		server      *httptest.Server
+		fooEndpoint *route.Endpoint
+		heartbeat   time.Duration
+	)
+
+	stream := func(query string) (*http.Response, *bufio.Reader) {
+		resp, err := http.Get(server.URL + "/routes/events" + query)
+		Expect(err).ToNot(HaveOccurred())
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	readLine := func(reader *bufio.Reader) string {
+		line, err := reader.ReadString('\n')
+		Expect(err).ToNot(HaveOccurred())
+		return line
+	}
+
+	BeforeEach(func() {
+		heartbeat = time.Minute
+		r = NewRouteRegistry(lagertest.NewTestLogger("test"), config.DefaultConfig(), new(fakes.FakeRouteRegistryReporter))
+		fooEndpoint = route.NewEndpoint("12345", "192.168.1.1", 1234, "id1", "0", nil, -1, "", models.ModificationTag{})
+	})
+
+	JustBeforeEach(func() {
+		server = httptest.NewServer(NewEventStream(lagertest.NewTestLogger("test"), r, heartbeat))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("streams server-sent events", func() {
+		resp, reader := stream("")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(resp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		r.Register("foo.example.com", fooEndpoint)
+
+		Expect(readLine(reader)).To(Equal("event: route-added\n"))
+		Expect(readLine(reader)).To(HavePrefix("data: {"))
+		Expect(readLine(reader)).To(Equal("\n"))
+		Expect(readLine(reader)).To(Equal("event: endpoint-registered\n"))
+
+		var e RouteEvent
+		data := readLine(reader)
+		Expect(json.Unmarshal([]byte(data[len("data: "):]), &e)).To(Succeed())
+		Expect(e.Uri).To(Equal(route.Uri("foo.example.com")))
+		Expect(e.Address).To(Equal("192.168.1.1:1234"))
+		Expect(e.ApplicationId).To(Equal("12345"))
+	})
+
+	It("streams JSON lines matching the filter", func() {
+		resp, reader := stream("?format=json&app_id=54321")
+		defer resp.Body.Close()
+		Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+		r.Register("foo.example.com", fooEndpoint)
+		r.Register("bar.example.com", route.NewEndpoint("54321", "192.168.1.2", 4321, "id2", "0", nil, -1, "", models.ModificationTag{}))
+
+		var e RouteEvent
+		Expect(json.Unmarshal([]byte(readLine(reader)), &e)).To(Succeed())
+		Expect(e.Type).To(Equal(RouteAdded))
+		Expect(e.Uri).To(Equal(route.Uri("bar.example.com")))
+		Expect(json.Unmarshal([]byte(readLine(reader)), &e)).To(Succeed())
+		Expect(e.Type).To(Equal(EndpointRegistered))
+		Expect(e.ApplicationId).To(Equal("54321"))
+	})
+
+	Context("without events", func() {
+		BeforeEach(func() {
+			heartbeat = 10 * time.Millisecond
+		})
+
+		It("writes heartbeats", func() {
+			resp, reader := stream("")
+			defer resp.Body.Close()
+
+			Expect(readLine(reader)).To(Equal(": heartbeat\n"))
+		})
+	})
+
+	It("ends the stream when the events are closed", func() {
+		resp, reader := stream("")
+		defer resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+		r.CloseEvents()
+
+		_, err := reader.ReadString('\n')
+		Expect(err).To(Equal(io.EOF))
+	})
+
+	It("rejects unknown formats", func() {
+		resp, err := http.Get(server.URL + "/routes/events?format=xml")
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("rejects other methods", func() {
+		resp, err := http.Post(server.URL+"/routes/events", "application/json", nil)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
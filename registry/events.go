@@ -0,0 +1,169 @@
+package registry
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+const (
+	RouteAdded           = "route-added"
+	EndpointRegistered   = "endpoint-registered"
+	EndpointUnregistered = "endpoint-unregistered"
+	EndpointPruned       = "endpoint-pruned"
+)
+
+// routeEventBuffer is the number of events a subscriber may fall behind
+// before it is dropped
+const routeEventBuffer = 1024
+
+// RouteEvent is a change of the routing table
+type RouteEvent struct {
+	Type              string                 `json:"type"`
+	Time              time.Time              `json:"time"`
+	Uri               route.Uri              `json:"uri"`
+	Address           string                 `json:"address,omitempty"`
+	ApplicationId     string                 `json:"app_id,omitempty"`
+	PrivateInstanceId string                 `json:"private_instance_id,omitempty"`
+	ModificationTag   models.ModificationTag `json:"modification_tag"`
+}
+
+func newRouteEvent(eventType string, uri route.Uri, endpoint *route.Endpoint) RouteEvent {
+	return RouteEvent{
+		Type:              eventType,
+		Time:              time.Now().UTC(),
+		Uri:               uri,
+		Address:           endpoint.CanonicalAddr(),
+		ApplicationId:     endpoint.ApplicationId,
+		PrivateInstanceId: endpoint.PrivateInstanceId,
+		ModificationTag:   endpoint.ModificationTag,
+	}
+}
+
+// RouteEventFilter selects the events of one host, one app or both. An empty
+// field matches every event.
+type RouteEventFilter struct {
+	Host          string
+	ApplicationId string
+}
+
+func (f RouteEventFilter) Matches(e RouteEvent) bool {
+	if f.ApplicationId != "" && f.ApplicationId != e.ApplicationId {
+		return false
+	}
+	if f.Host != "" && !strings.EqualFold(f.Host, routeHost(e.Uri)) {
+		return false
+	}
+	return true
+}
+
+// routeHost returns the host of a route, without its path
+func routeHost(uri route.Uri) string {
+	host := string(uri)
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+type routeEventSubscriber struct {
+	filter RouteEventFilter
+	events chan RouteEvent
+}
+
+// routeEvents passes the changes of the registry to its subscribers. Events
+// are published with the registry lock held, so they are sent in the order
+// the changes were made. A subscriber that falls behind is dropped rather
+// than slowing down the registry: its channel is closed. Once closed, every
+// channel is closed, including those of later subscribers.
+type routeEvents struct {
+	lock        sync.Mutex
+	subscribers map[*routeEventSubscriber]struct{}
+	stopped     bool
+}
+
+func (s *routeEvents) subscribe(filter RouteEventFilter) *routeEventSubscriber {
+	sub := &routeEventSubscriber{
+		filter: filter,
+		events: make(chan RouteEvent, routeEventBuffer),
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		close(sub.events)
+		return sub
+	}
+	if s.subscribers == nil {
+		s.subscribers = make(map[*routeEventSubscriber]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (s *routeEvents) unsubscribe(sub *routeEventSubscriber) {
+	s.lock.Lock()
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+	s.lock.Unlock()
+}
+
+func (s *routeEvents) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stopped = true
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (s *routeEvents) closed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stopped
+}
+
+func (s *routeEvents) active() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.subscribers) > 0
+}
+
+func (s *routeEvents) publish(e RouteEvent) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for sub := range s.subscribers {
+		if !sub.filter.Matches(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// SubscribeEvents returns the changes of the registry that match filter from
+// now on. The channel is closed when the subscriber falls behind, the events
+// are closed or cancel is called, which must be done when the events are no
+// longer read.
+func (r *RouteRegistry) SubscribeEvents(filter RouteEventFilter) (<-chan RouteEvent, func()) {
+	sub := r.events.subscribe(filter)
+	return sub.events, func() { r.events.unsubscribe(sub) }
+}
+
+// CloseEvents closes the events of every subscriber, now and later, which ends
+// the event streams. It is called when the router stops.
+func (r *RouteRegistry) CloseEvents() {
+	r.events.close()
+}
@@ -0,0 +1,159 @@
+package registry_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	. "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Route events", func() {
+	var (
+		configObj   *config.Config
+		r           *RouteRegistry
+		fooEndpoint *route.Endpoint
+		barEndpoint *route.Endpoint
+		events      <-chan RouteEvent
+		cancel      func()
+	)
+
+	types := func() []string {
+		var t []string
+		for {
+			select {
+			case e := <-events:
+				t = append(t, e.Type)
+			default:
+				return t
+			}
+		}
+	}
+
+	BeforeEach(func() {
+		configObj = config.DefaultConfig()
+		configObj.PruneStaleDropletsInterval = 50 * time.Millisecond
+		configObj.DropletStaleThreshold = 24 * time.Millisecond
+
+		r = NewRouteRegistry(lagertest.NewTestLogger("test"), configObj, new(fakes.FakeRouteRegistryReporter))
+		fooEndpoint = route.NewEndpoint("12345", "192.168.1.1", 1234, "id1", "0", nil, -1, "", models.ModificationTag{})
+		barEndpoint = route.NewEndpoint("54321", "192.168.1.2", 4321, "id2", "0", nil, -1, "", models.ModificationTag{})
+
+		events, cancel = r.SubscribeEvents(RouteEventFilter{})
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("sends an event when a route and an endpoint are added", func() {
+		r.Register("Foo.example.com/Path?x=1", fooEndpoint)
+
+		var e RouteEvent
+		Expect(events).To(Receive(&e))
+		Expect(e.Type).To(Equal(RouteAdded))
+		Expect(e.Uri).To(Equal(route.Uri("foo.example.com/path")))
+
+		Expect(events).To(Receive(&e))
+		Expect(e.Type).To(Equal(EndpointRegistered))
+		Expect(e.Uri).To(Equal(route.Uri("foo.example.com/path")))
+		Expect(e.Address).To(Equal("192.168.1.1:1234"))
+		Expect(e.ApplicationId).To(Equal("12345"))
+		Expect(e.PrivateInstanceId).To(Equal("id1"))
+		Expect(e.Time).ToNot(BeZero())
+	})
+
+	It("does not send an event when an endpoint registers again", func() {
+		r.Register("foo.example.com", fooEndpoint)
+		r.Register("foo.example.com", route.NewEndpoint("12345", "192.168.1.1", 1234, "id1", "0", nil, -1, "", models.ModificationTag{}))
+		r.Register("foo.example.com", barEndpoint)
+
+		Expect(types()).To(Equal([]string{RouteAdded, EndpointRegistered, EndpointRegistered}))
+	})
+
+	It("sends an event when another instance takes over an address", func() {
+		r.Register("foo.example.com", fooEndpoint)
+		r.Register("foo.example.com", route.NewEndpoint("12345", "192.168.1.1", 1234, "id3", "1", nil, -1, "", models.ModificationTag{}))
+
+		Expect(types()).To(Equal([]string{RouteAdded, EndpointRegistered, EndpointRegistered}))
+	})
+
+	It("sends an event when an endpoint is unregistered", func() {
+		r.Register("foo.example.com", fooEndpoint)
+		r.Unregister("foo.example.com", fooEndpoint)
+		r.Unregister("foo.example.com", fooEndpoint)
+
+		Expect(types()).To(Equal([]string{RouteAdded, EndpointRegistered, EndpointUnregistered}))
+	})
+
+	It("sends an event when an endpoint is pruned", func() {
+		r.Register("foo.example.com", fooEndpoint)
+		Expect(types()).To(Equal([]string{RouteAdded, EndpointRegistered}))
+
+		r.StartPruningCycle()
+		defer r.StopPruningCycle()
+
+		var e RouteEvent
+		Eventually(events).Should(Receive(&e))
+		Expect(e.Type).To(Equal(EndpointPruned))
+		Expect(e.Uri).To(Equal(route.Uri("foo.example.com")))
+		Expect(e.Address).To(Equal("192.168.1.1:1234"))
+	})
+
+	It("filters events by host and app", func() {
+		byHost, cancelHost := r.SubscribeEvents(RouteEventFilter{Host: "FOO.example.com"})
+		defer cancelHost()
+		byApp, cancelApp := r.SubscribeEvents(RouteEventFilter{ApplicationId: "54321"})
+		defer cancelApp()
+
+		r.Register("foo.example.com/path", fooEndpoint)
+		r.Register("bar.example.com", barEndpoint)
+		r.Register("foo.example.com", barEndpoint)
+
+		var e RouteEvent
+		for i := 0; i < 4; i++ {
+			Expect(byHost).To(Receive(&e))
+			Expect(e.Uri).To(HavePrefix("foo.example.com"))
+		}
+		Expect(byHost).ToNot(Receive())
+
+		for i := 0; i < 4; i++ {
+			Expect(byApp).To(Receive(&e))
+			Expect(e.ApplicationId).To(Equal("54321"))
+		}
+		Expect(byApp).ToNot(Receive())
+	})
+
+	It("closes the events of a subscriber that falls behind", func() {
+		for i := 0; i < 2000; i++ {
+			r.Register("foo.example.com", route.NewEndpoint("12345", "192.168.1.1", uint16(1000+i), "", "0", nil, -1, "", models.ModificationTag{}))
+		}
+
+		received := 0
+		for range events {
+			received++
+		}
+		Expect(received).To(BeNumerically("<", 2001))
+	})
+
+	It("closes the events of every subscriber when closed", func() {
+		r.CloseEvents()
+		Expect(events).To(BeClosed())
+
+		later, cancelLater := r.SubscribeEvents(RouteEventFilter{})
+		Expect(later).To(BeClosed())
+		cancelLater()
+	})
+
+	It("closes the events when cancelled", func() {
+		cancel()
+		Expect(events).To(BeClosed())
+		cancel()
+	})
+})
@@ -50,8 +50,16 @@ type RouteRegistry struct {
 
 	reporter reporter.RouteRegistryReporter
 
+	pathRewrites map[route.Uri]config.PathRewrite
+
 	ticker           *time.Ticker
 	timeOfLastUpdate time.Time
+
+	// the creation time of the snapshot routes were restored from at
+	// startup, zero without a warm start
+	snapshotCreatedAt time.Time
+
+	events routeEvents
 }
 
 func NewRouteRegistry(logger lager.Logger, c *config.Config, reporter reporter.RouteRegistryReporter) *RouteRegistry {
@@ -63,6 +71,15 @@ func NewRouteRegistry(logger lager.Logger, c *config.Config, reporter reporter.R
 	r.dropletStaleThreshold = c.DropletStaleThreshold
 	r.suspendPruning = func() bool { return false }
 
+	r.pathRewrites = make(map[route.Uri]config.PathRewrite)
+	for _, rw := range c.PathRewrites {
+		r.pathRewrites[route.Uri(rw.Route).RouteKey()] = rw
+	}
+
+	for _, rd := range c.RedirectRoutes {
+		r.addRedirectRoute(rd)
+	}
+
 	r.reporter = reporter
 	return r
 }
@@ -76,17 +93,27 @@ func (r *RouteRegistry) Register(uri route.Uri, endpoint *route.Endpoint) {
 	r.Lock()
 
 	uri = uri.RouteKey()
+	pool, poolAdded := r.poolFor(uri)
 
-	pool := r.byUri.Find(uri)
-	if pool == nil {
-		contextPath := parseContextPath(uri)
-		pool = route.NewPool(r.dropletStaleThreshold/4, contextPath)
-		r.byUri.Insert(uri, pool)
-		r.logger.Debug("uri-added", lager.Data{"uri": uri})
+	var previous *route.Endpoint
+	publish := r.events.active()
+	if publish {
+		previous = pool.FindByAddress(endpoint.CanonicalAddr())
 	}
 
 	endpointAdded := pool.Put(endpoint)
 
+	if publish {
+		if poolAdded {
+			r.events.publish(newRouteEvent(RouteAdded, uri, endpoint))
+		}
+		// Refreshing an endpoint is not a change worth an event, replacing
+		// another instance at the same address is.
+		if endpointAdded && (previous == nil || previous.PrivateInstanceId != endpoint.PrivateInstanceId) {
+			r.events.publish(newRouteEvent(EndpointRegistered, uri, endpoint))
+		}
+	}
+
 	r.timeOfLastUpdate = t
 	r.Unlock()
 
@@ -110,6 +137,7 @@ func (r *RouteRegistry) Unregister(uri route.Uri, endpoint *route.Endpoint) {
 		endpointRemoved := pool.Remove(endpoint)
 		if endpointRemoved {
 			r.logger.Debug("endpoint-unregistered", data)
+			r.events.publish(newRouteEvent(EndpointUnregistered, uri, endpoint))
 		} else {
 			r.logger.Debug("endpoint-not-unregistered", data)
 		}
@@ -144,16 +172,22 @@ func (r *RouteRegistry) Lookup(uri route.Uri) *route.Pool {
 func (r *RouteRegistry) LookupWithInstance(uri route.Uri, appId string, appIndex string) *route.Pool {
 	uri = uri.RouteKey()
 	p := r.Lookup(uri)
+	if p == nil {
+		return nil
+	}
 
-	var surgicalPool *route.Pool
+	var instance *route.Endpoint
 
 	p.Each(func(e *route.Endpoint) {
 		if (e.ApplicationId == appId) && (e.PrivateInstanceIndex == appIndex) {
-			surgicalPool = route.NewPool(0, "")
-			surgicalPool.Put(e)
+			instance = e
 		}
 	})
-	return surgicalPool
+
+	if instance == nil {
+		return nil
+	}
+	return p.InstancePool(instance)
 }
 
 func (r *RouteRegistry) StartPruningCycle() {
@@ -243,6 +277,7 @@ func (r *RouteRegistry) pruneStaleDroplets() {
 			addresses := []string{}
 			for _, e := range endpoints {
 				addresses = append(addresses, e.CanonicalAddr())
+				r.events.publish(newRouteEvent(EndpointPruned, route.Uri(t.ToPath()), e))
 			}
 			r.logger.Info("pruned-route", lager.Data{"uri": t.ToPath(), "endpoints": addresses})
 		}
@@ -263,6 +298,55 @@ func (r *RouteRegistry) freshenRoutes() {
 	})
 }
 
+// addRedirectRoute inserts a pool that is answered with a redirect. It is
+// never pruned, but backends may still register to the same route.
+func (r *RouteRegistry) addRedirectRoute(rd config.RedirectRoute) {
+	uri := route.Uri(rd.Route).RouteKey()
+
+	redirect, err := route.NewRedirect(rd.StatusCode, rd.Target)
+	if err != nil {
+		r.logger.Error("invalid-redirect-route", err, lager.Data{"uri": uri})
+		return
+	}
+
+	pool := route.NewPool(r.dropletStaleThreshold/4, parseContextPath(uri))
+	pool.SetRouteUri(uri)
+	pool.SetRedirect(redirect)
+	r.byUri.Insert(uri, pool)
+	r.logger.Debug("redirect-route-added", lager.Data{"uri": uri, "target": rd.Target})
+}
+
+// poolFor returns the pool of uri, adding one when there is none, and whether
+// it was added. The lock must be held.
+func (r *RouteRegistry) poolFor(uri route.Uri) (*route.Pool, bool) {
+	pool := r.byUri.Find(uri)
+	if pool != nil {
+		return pool, false
+	}
+
+	contextPath := parseContextPath(uri)
+	pool = route.NewPool(r.dropletStaleThreshold/4, contextPath)
+	pool.SetRouteUri(uri)
+	r.setPathRewrite(uri, pool)
+	r.byUri.Insert(uri, pool)
+	r.logger.Debug("uri-added", lager.Data{"uri": uri})
+	return pool, true
+}
+
+func (r *RouteRegistry) setPathRewrite(uri route.Uri, pool *route.Pool) {
+	rw, ok := r.pathRewrites[uri.RouteKey()]
+	if !ok {
+		return
+	}
+
+	pathRewrite, err := route.NewPathRewrite(pool.ContextPath(), rw)
+	if err != nil {
+		r.logger.Error("invalid-path-rewrite", err, lager.Data{"uri": uri})
+		return
+	}
+	pool.SetPathRewrite(pathRewrite)
+}
+
 func parseContextPath(uri route.Uri) string {
 	contextPath := "/"
 	split := strings.SplitN(strings.TrimPrefix(uri.String(), "/"), "/", 2)
@@ -517,6 +517,11 @@ var _ = Describe("RouteRegistry", func() {
 				Expect(iter.Next().CanonicalAddr()).To(Equal("192.168.1.1:1234"))
 			})
 
+			It("records the route of the pool", func() {
+				r.Register("Dora.app.com/env", m)
+				Expect(r.Lookup("dora.app.com/env/abc?foo=bar").RouteUri()).To(Equal(route.Uri("dora.app.com/env")))
+			})
+
 			It("using nested context path and query string", func() {
 				r.Register("dora.app.com/env/abc", m)
 				p := r.Lookup("dora.app.com/env/abc?foo=bar&baz=bing")
@@ -525,6 +530,27 @@ var _ = Describe("RouteRegistry", func() {
 				iter := p.Endpoints("", "")
 				Expect(iter.Next().CanonicalAddr()).To(Equal("192.168.1.1:1234"))
 			})
+
+			Context("when the route has a path rewrite", func() {
+				BeforeEach(func() {
+					configObj.PathRewrites = []config.PathRewrite{
+						{Route: "Dora.app.com/env/", StripPrefix: true},
+					}
+					r = NewRouteRegistry(logger, configObj, reporter)
+				})
+
+				It("attaches the rewrite to the pool", func() {
+					r.Register("dora.app.com/env", m)
+					r.Register("dora.app.com/other", m)
+
+					p := r.Lookup("dora.app.com/env/abc")
+					Expect(p.PathRewrite()).NotTo(BeNil())
+					Expect(p.PathRewrite().RewriteURI("/env/abc")).To(Equal("/abc"))
+
+					p = r.Lookup("dora.app.com/other")
+					Expect(p.PathRewrite()).To(BeNil())
+				})
+			})
 		})
 	})
 
@@ -559,6 +585,23 @@ var _ = Describe("RouteRegistry", func() {
 			Expect(r.NumEndpoints()).To(Equal(2))
 		})
 
+		It("keeps the route, context path, path rewrite and redirect", func() {
+			configObj.PathRewrites = []config.PathRewrite{{Route: "baz.com/env", StripPrefix: true}}
+			configObj.RedirectRoutes = []config.RedirectRoute{{Route: "qux.com", Target: "https://example.com/"}}
+			r = NewRouteRegistry(logger, configObj, reporter)
+			r.Register("baz.com/env", route.NewEndpoint("app-1-ID", "192.168.1.1", 1234, "", "0", nil, -1, "", modTag))
+			r.Register("qux.com", route.NewEndpoint("app-1-ID", "192.168.1.1", 1234, "", "0", nil, -1, "", modTag))
+
+			p := r.LookupWithInstance("baz.com/env", "app-1-ID", "0")
+			Expect(p.RouteUri()).To(Equal(route.Uri("baz.com/env")))
+			Expect(p.ContextPath()).To(Equal("/env"))
+			Expect(p.PathRewrite().RewriteURI("/env/abc")).To(Equal("/abc"))
+
+			p = r.LookupWithInstance("qux.com", "app-1-ID", "0")
+			Expect(p.Redirect()).NotTo(BeNil())
+			Expect(p.Redirect().Target).To(Equal("https://example.com/"))
+		})
+
 		Context("when given an incorrect app index", func() {
 			BeforeEach(func() {
 				appId = "app-2-ID"
@@ -658,6 +701,21 @@ var _ = Describe("RouteRegistry", func() {
 			Expect(r.Lookup("foo/path")).NotTo(BeNil())
 		})
 
+		It("keeps redirect routes from the configuration", func() {
+			configObj.RedirectRoutes = []config.RedirectRoute{
+				{Route: "old.example.com", StatusCode: 308, Target: "https://new.example.com{{.RequestURI}}"},
+			}
+			r = NewRouteRegistry(logger, configObj, reporter)
+			Expect(r.NumUris()).To(Equal(1))
+
+			r.StartPruningCycle()
+			time.Sleep(configObj.PruneStaleDropletsInterval + configObj.DropletStaleThreshold)
+
+			p := r.Lookup("old.example.com/some/path")
+			Expect(p).NotTo(BeNil())
+			Expect(p.Redirect().StatusCode).To(Equal(308))
+		})
+
 		It("skips fresh droplets", func() {
 			endpoint := route.NewEndpoint("", "192.168.1.1", 1234, "", "", nil, -1, "", modTag)
 
@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/registry/container"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager"
+)
+
+const snapshotVersion = 1
+
+// snapshotFile is the content of a registry snapshot. The checksum covers the
+// routes, so that a file that was cut short or changed is ignored.
+type snapshotFile struct {
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Checksum  string          `json:"checksum"`
+	Routes    json.RawMessage `json:"routes"`
+}
+
+// WriteSnapshot writes the endpoints of every route to file. Endpoints
+// restored from an earlier snapshot are left out until they register again.
+// The file is replaced by a rename, so a failed write leaves the previous
+// snapshot in place.
+func (r *RouteRegistry) WriteSnapshot(file string) error {
+	routes := make(map[string][]route.EndpointSnapshot)
+
+	r.RLock()
+	r.byUri.EachNodeWithPool(func(t *container.Trie) {
+		var endpoints []route.EndpointSnapshot
+		t.Pool.Each(func(e *route.Endpoint) {
+			if !e.Restored() {
+				endpoints = append(endpoints, e.Snapshot())
+			}
+		})
+		if len(endpoints) > 0 {
+			routes[t.ToPath()] = endpoints
+		}
+	})
+	r.RUnlock()
+
+	b, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	b, err = json.Marshal(snapshotFile{
+		Version:   snapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Checksum:  hex.EncodeToString(sum[:]),
+		Routes:    b,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// RestoreSnapshot registers the endpoints of the snapshot in c.File, which
+// are pruned after c.StaleThreshold unless they register again. A snapshot
+// that is older than c.MaxAge, of another version or fails its checksum is
+// ignored with an error. A missing file is not an error.
+func (r *RouteRegistry) RestoreSnapshot(c config.RegistrySnapshotConfig) error {
+	b, err := ioutil.ReadFile(c.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var s snapshotFile
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("invalid registry snapshot: %s", err)
+	}
+	if s.Version != snapshotVersion {
+		return fmt.Errorf("registry snapshot version %d is not supported", s.Version)
+	}
+	age := time.Since(s.CreatedAt)
+	if age > c.MaxAge || age < -time.Minute {
+		return fmt.Errorf("registry snapshot created at %s is too old", s.CreatedAt)
+	}
+	sum := sha256.Sum256(s.Routes)
+	if hex.EncodeToString(sum[:]) != s.Checksum {
+		return errors.New("registry snapshot checksum does not match")
+	}
+
+	var routes map[string][]route.EndpointSnapshot
+	if err := json.Unmarshal(s.Routes, &routes); err != nil {
+		return fmt.Errorf("invalid registry snapshot routes: %s", err)
+	}
+
+	restored := make(map[route.Uri][]*route.Endpoint, len(routes))
+	count := 0
+	for uri, snapshots := range routes {
+		for _, es := range snapshots {
+			endpoint, err := route.RestoreEndpoint(es, c.StaleThreshold)
+			if err != nil {
+				return fmt.Errorf("invalid registry snapshot route %s: %s", uri, err)
+			}
+			restored[route.Uri(uri)] = append(restored[route.Uri(uri)], endpoint)
+			count++
+		}
+	}
+
+	r.Lock()
+	for uri, endpoints := range restored {
+		pool, _ := r.poolFor(uri.RouteKey())
+		for _, endpoint := range endpoints {
+			pool.Put(endpoint)
+		}
+	}
+	if count > 0 {
+		r.snapshotCreatedAt = s.CreatedAt
+		r.timeOfLastUpdate = time.Now()
+	}
+	r.Unlock()
+
+	r.logger.Info("registry-snapshot-restored", lager.Data{
+		"routes":    len(restored),
+		"endpoints": count,
+		"age":       age.String(),
+	})
+	return nil
+}
+
+// WarmStarted returns true when routes were restored from a snapshot that is
+// younger than maxAge
+func (r *RouteRegistry) WarmStarted(maxAge time.Duration) bool {
+	r.RLock()
+	defer r.RUnlock()
+	return !r.snapshotCreatedAt.IsZero() && time.Since(r.snapshotCreatedAt) <= maxAge
+}
+
+// Snapshotter writes registry snapshots periodically and once more when it is
+// signalled to stop.
+type Snapshotter struct {
+	logger   lager.Logger
+	registry *RouteRegistry
+	file     string
+	interval time.Duration
+}
+
+func NewSnapshotter(logger lager.Logger, r *RouteRegistry, c config.RegistrySnapshotConfig) *Snapshotter {
+	return &Snapshotter{
+		logger:   logger,
+		registry: r,
+		file:     c.File,
+		interval: c.Interval,
+	}
+}
+
+func (s *Snapshotter) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.write()
+		case <-signals:
+			s.write()
+			return nil
+		}
+	}
+}
+
+func (s *Snapshotter) write() {
+	if err := s.registry.WriteSnapshot(s.file); err != nil {
+		s.logger.Error("registry-snapshot-failed", err, lager.Data{"file": s.file})
+	}
+}
@@ -0,0 +1,197 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	. "code.cloudfoundry.org/gorouter/registry"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-api/models"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry snapshots", func() {
+	var (
+		dir            string
+		snapshotConfig config.RegistrySnapshotConfig
+		configObj      *config.Config
+		r              *RouteRegistry
+		fooEndpoint    *route.Endpoint
+		barEndpoint    *route.Endpoint
+	)
+
+	newRegistry := func() *RouteRegistry {
+		return NewRouteRegistry(lagertest.NewTestLogger("test"), configObj, new(fakes.FakeRouteRegistryReporter))
+	}
+
+	addresses := func(registry *RouteRegistry, uri route.Uri) []string {
+		var a []string
+		pool := registry.Lookup(uri)
+		if pool == nil {
+			return a
+		}
+		pool.Each(func(e *route.Endpoint) {
+			a = append(a, e.CanonicalAddr())
+		})
+		return a
+	}
+
+	rewrite := func(f func(snapshot map[string]interface{})) {
+		b, err := ioutil.ReadFile(snapshotConfig.File)
+		Expect(err).ToNot(HaveOccurred())
+		var snapshot map[string]interface{}
+		Expect(json.Unmarshal(b, &snapshot)).To(Succeed())
+		f(snapshot)
+		b, err = json.Marshal(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(snapshotConfig.File, b, 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "registry-snapshot")
+		Expect(err).ToNot(HaveOccurred())
+
+		configObj = config.DefaultConfig()
+		snapshotConfig = configObj.RegistrySnapshot
+		snapshotConfig.File = filepath.Join(dir, "routes.json")
+
+		r = newRegistry()
+		fooEndpoint = route.NewEndpoint("12345", "192.168.1.1", 1234, "id1", "0", nil, -1, "", models.ModificationTag{})
+		barEndpoint = route.NewEndpoint("54321", "192.168.1.2", 4321, "id2", "0", nil, -1, "https://my-rs.com", models.ModificationTag{})
+		r.Register("foo", fooEndpoint)
+		r.Register("bar.com/path", barEndpoint)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("restores the routes of a snapshot", func() {
+		Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+
+		Expect(restored.WarmStarted(time.Minute)).To(BeTrue())
+		Expect(restored.NumEndpoints()).To(Equal(2))
+		Expect(addresses(restored, "foo")).To(ConsistOf("192.168.1.1:1234"))
+		Expect(addresses(restored, "bar.com/path")).To(ConsistOf("192.168.1.2:4321"))
+		Expect(restored.Lookup("bar.com/path").RouteServiceUrl()).To(Equal("https://my-rs.com"))
+	})
+
+	It("is not warm started by an older snapshot", func() {
+		Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+		b, err := ioutil.ReadFile(snapshotConfig.File)
+		Expect(err).ToNot(HaveOccurred())
+		var snapshot map[string]json.RawMessage
+		Expect(json.Unmarshal(b, &snapshot)).To(Succeed())
+		snapshot["created_at"], err = json.Marshal(time.Now().Add(-time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		b, err = json.Marshal(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(snapshotConfig.File, b, 0644)).To(Succeed())
+
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+		Expect(restored.NumEndpoints()).To(Equal(2))
+		Expect(restored.WarmStarted(30 * time.Second)).To(BeFalse())
+		Expect(restored.WarmStarted(2 * time.Minute)).To(BeTrue())
+	})
+
+	It("replaces restored endpoints when they register again", func() {
+		Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+		restored.Register("foo", fooEndpoint)
+
+		restored.Lookup("foo").Each(func(e *route.Endpoint) {
+			Expect(e).To(BeIdenticalTo(fooEndpoint))
+		})
+	})
+
+	It("does not write restored endpoints that have not registered again", func() {
+		Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+		restored.Register("foo", fooEndpoint)
+		Expect(restored.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+
+		again := newRegistry()
+		Expect(again.RestoreSnapshot(snapshotConfig)).To(Succeed())
+		Expect(addresses(again, "foo")).To(ConsistOf("192.168.1.1:1234"))
+		Expect(addresses(again, "bar.com/path")).To(BeEmpty())
+	})
+
+	It("does nothing without a snapshot", func() {
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+		Expect(restored.WarmStarted(time.Minute)).To(BeFalse())
+		Expect(restored.NumEndpoints()).To(Equal(0))
+	})
+
+	It("ignores snapshots that cannot be trusted", func() {
+		changes := map[string]func(map[string]interface{}){
+			"too old": func(s map[string]interface{}) {
+				s["created_at"] = time.Now().Add(-time.Hour).Format(time.RFC3339)
+			},
+			"other version": func(s map[string]interface{}) {
+				s["version"] = 2
+			},
+			"bad checksum": func(s map[string]interface{}) {
+				s["routes"] = map[string]interface{}{"baz": []interface{}{map[string]interface{}{"address": "10.0.0.1:80"}}}
+			},
+		}
+		for name, change := range changes {
+			Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+			rewrite(change)
+
+			restored := newRegistry()
+			Expect(restored.RestoreSnapshot(snapshotConfig)).ToNot(Succeed(), name)
+			Expect(restored.WarmStarted(time.Minute)).To(BeFalse(), name)
+			Expect(restored.NumEndpoints()).To(Equal(0), name)
+		}
+	})
+
+	It("ignores a truncated snapshot", func() {
+		Expect(r.WriteSnapshot(snapshotConfig.File)).To(Succeed())
+		b, err := ioutil.ReadFile(snapshotConfig.File)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(snapshotConfig.File, b[:len(b)/2], 0644)).To(Succeed())
+
+		restored := newRegistry()
+		Expect(restored.RestoreSnapshot(snapshotConfig)).ToNot(Succeed())
+		Expect(restored.NumEndpoints()).To(Equal(0))
+	})
+
+	Context("Snapshotter", func() {
+		It("writes a snapshot periodically and when it stops", func() {
+			snapshotConfig.Interval = 10 * time.Millisecond
+			process := ifrit.Invoke(NewSnapshotter(lagertest.NewTestLogger("test"), r, snapshotConfig))
+
+			Eventually(func() error {
+				_, err := os.Stat(snapshotConfig.File)
+				return err
+			}).Should(Succeed())
+
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+
+			restored := newRegistry()
+			Expect(restored.RestoreSnapshot(snapshotConfig)).To(Succeed())
+			Expect(restored.NumEndpoints()).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,183 @@
+package rewrite
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+)
+
+const (
+	ActionSet    = "set"
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+)
+
+// HeaderData is available to templated header values, e.g.
+// "{{.RequestId}}" or "{{.ClientIP}}".
+type HeaderData struct {
+	Host      string
+	Path      string
+	Method    string
+	RequestId string
+	ClientIP  string
+}
+
+type headerAction struct {
+	action string
+	name   string
+	value  string
+	tmpl   *template.Template
+}
+
+type headerRule struct {
+	matcher  route.Matcher
+	request  []headerAction
+	response []headerAction
+}
+
+// HeaderRules applies the configured header rules, in order, to the requests
+// sent to backends and to the responses returned to clients.
+type HeaderRules struct {
+	rules []headerRule
+}
+
+func NewHeaderRules(cfg []config.HeaderRule) (*HeaderRules, error) {
+	h := &HeaderRules{}
+
+	for i, c := range cfg {
+		rule := headerRule{matcher: route.NewMatcher(c.Host, c.Path)}
+
+		var err error
+		rule.request, err = compileActions(c.Request)
+		if err != nil {
+			return nil, fmt.Errorf("header rule %d: %s", i, err)
+		}
+		rule.response, err = compileActions(c.Response)
+		if err != nil {
+			return nil, fmt.Errorf("header rule %d: %s", i, err)
+		}
+
+		h.rules = append(h.rules, rule)
+	}
+
+	return h, nil
+}
+
+// RewriteRequest applies request actions to target, the request sent to the
+// backend, using the original request for matching and template values.
+func (h *HeaderRules) RewriteRequest(source *http.Request, target *http.Request) {
+	if h == nil || len(h.rules) == 0 {
+		return
+	}
+
+	var data *HeaderData
+	for _, rule := range h.rules {
+		if len(rule.request) == 0 || !rule.matcher.Match(source.Host, source.URL.Path) {
+			continue
+		}
+		if data == nil {
+			data = newHeaderData(source)
+		}
+		apply(rule.request, target.Header, data)
+	}
+}
+
+// RewriteResponse applies response actions to the headers of a proxied
+// response before they are written to the client.
+func (h *HeaderRules) RewriteResponse(request *http.Request, header http.Header) {
+	if h == nil || len(h.rules) == 0 {
+		return
+	}
+
+	var data *HeaderData
+	for _, rule := range h.rules {
+		if len(rule.response) == 0 || !rule.matcher.Match(request.Host, request.URL.Path) {
+			continue
+		}
+		if data == nil {
+			data = newHeaderData(request)
+		}
+		apply(rule.response, header, data)
+	}
+}
+
+func compileActions(cfg []config.HeaderAction) ([]headerAction, error) {
+	var actions []headerAction
+
+	for _, c := range cfg {
+		if c.Name == "" {
+			return nil, fmt.Errorf("missing header name for action %q", c.Action)
+		}
+
+		a := headerAction{
+			action: c.Action,
+			name:   http.CanonicalHeaderKey(c.Name),
+			value:  c.Value,
+		}
+
+		switch c.Action {
+		case ActionSet, ActionAdd:
+			if strings.Contains(c.Value, "{{") {
+				tmpl, err := template.New(c.Name).Parse(c.Value)
+				if err != nil {
+					return nil, err
+				}
+				a.tmpl = tmpl
+			}
+		case ActionRemove:
+		default:
+			return nil, fmt.Errorf("unknown action %q for header %s", c.Action, c.Name)
+		}
+
+		actions = append(actions, a)
+	}
+
+	return actions, nil
+}
+
+func apply(actions []headerAction, header http.Header, data *HeaderData) {
+	for _, a := range actions {
+		switch a.action {
+		case ActionSet:
+			header.Set(a.name, a.render(data))
+		case ActionAdd:
+			header.Add(a.name, a.render(data))
+		case ActionRemove:
+			header.Del(a.name)
+		}
+	}
+}
+
+func (a headerAction) render(data *HeaderData) string {
+	if a.tmpl == nil {
+		return a.value
+	}
+
+	var buf bytes.Buffer
+	if err := a.tmpl.Execute(&buf, data); err != nil {
+		return a.value
+	}
+	return buf.String()
+}
+
+func newHeaderData(request *http.Request) *HeaderData {
+	clientIP, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		clientIP = request.RemoteAddr
+	}
+
+	return &HeaderData{
+		Host:      request.Host,
+		Path:      request.URL.Path,
+		Method:    request.Method,
+		RequestId: router_http.RequestId(request),
+		ClientIP:  clientIP,
+	}
+}
@@ -0,0 +1,127 @@
+package rewrite_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/rewrite"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HeaderRules", func() {
+	var (
+		cfg    []config.HeaderRule
+		rules  *rewrite.HeaderRules
+		source *http.Request
+		target *http.Request
+	)
+
+	BeforeEach(func() {
+		var err error
+		source, err = http.NewRequest("GET", "http://foo.example.com/api/orders", nil)
+		Expect(err).NotTo(HaveOccurred())
+		source.RemoteAddr = "10.0.0.1:34567"
+		source.Header.Set("X-Vcap-Request-Id", "some-request-id")
+
+		target, err = http.NewRequest("GET", "http://10.0.0.2:8080/api/orders", nil)
+		Expect(err).NotTo(HaveOccurred())
+		target.Header.Set("X-Remove-Me", "value")
+
+		cfg = []config.HeaderRule{
+			{
+				Request: []config.HeaderAction{
+					{Action: "set", Name: "x-router-identity", Value: "gorouter"},
+					{Action: "remove", Name: "X-Remove-Me"},
+				},
+				Response: []config.HeaderAction{
+					{Action: "remove", Name: "Server"},
+					{Action: "set", Name: "Strict-Transport-Security", Value: "max-age=31536000"},
+				},
+			},
+			{
+				Host: "*.example.com",
+				Path: "/api",
+				Request: []config.HeaderAction{
+					{Action: "add", Name: "X-Client", Value: "{{.ClientIP}}"},
+					{Action: "add", Name: "X-Client", Value: "{{.RequestId}}"},
+				},
+			},
+			{
+				Host: "bar.example.com",
+				Request: []config.HeaderAction{
+					{Action: "set", Name: "X-Bar", Value: "bar"},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		rules, err = rewrite.NewHeaderRules(cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("RewriteRequest", func() {
+		It("applies every matching rule in order", func() {
+			rules.RewriteRequest(source, target)
+
+			Expect(target.Header.Get("X-Router-Identity")).To(Equal("gorouter"))
+			Expect(target.Header).NotTo(HaveKey("X-Remove-Me"))
+			Expect(target.Header["X-Client"]).To(Equal([]string{"10.0.0.1", "some-request-id"}))
+			Expect(target.Header).NotTo(HaveKey("X-Bar"))
+		})
+
+		It("skips rules scoped to another path", func() {
+			source.URL.Path = "/web"
+			rules.RewriteRequest(source, target)
+
+			Expect(target.Header.Get("X-Router-Identity")).To(Equal("gorouter"))
+			Expect(target.Header).NotTo(HaveKey("X-Client"))
+		})
+	})
+
+	Describe("RewriteResponse", func() {
+		It("applies response actions", func() {
+			header := http.Header{}
+			header.Set("Server", "nginx")
+
+			rules.RewriteResponse(source, header)
+
+			Expect(header).NotTo(HaveKey("Server"))
+			Expect(header.Get("Strict-Transport-Security")).To(Equal("max-age=31536000"))
+		})
+	})
+
+	Context("when there are no rules", func() {
+		It("does nothing", func() {
+			var nilRules *rewrite.HeaderRules
+			nilRules.RewriteRequest(source, target)
+			Expect(target.Header.Get("X-Remove-Me")).To(Equal("value"))
+		})
+	})
+
+	Describe("NewHeaderRules", func() {
+		It("fails on an unknown action", func() {
+			_, err := rewrite.NewHeaderRules([]config.HeaderRule{
+				{Request: []config.HeaderAction{{Action: "replace", Name: "X-Foo"}}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails on a missing header name", func() {
+			_, err := rewrite.NewHeaderRules([]config.HeaderRule{
+				{Response: []config.HeaderAction{{Action: "remove"}}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails on an invalid template", func() {
+			_, err := rewrite.NewHeaderRules([]config.HeaderRule{
+				{Request: []config.HeaderAction{{Action: "set", Name: "X-Foo", Value: "{{.Broken"}}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
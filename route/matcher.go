@@ -0,0 +1,41 @@
+package route
+
+import "strings"
+
+// Matcher matches a request host and path against a host pattern and a path
+// prefix. A host pattern of "*.example.com" matches every subdomain of
+// example.com and an empty pattern matches every host. The path prefix only
+// matches whole path segments and, like routes, ignores case.
+type Matcher struct {
+	host string
+	path string
+}
+
+func NewMatcher(host, path string) Matcher {
+	return Matcher{
+		host: strings.ToLower(host),
+		path: strings.TrimSuffix(path, "/"),
+	}
+}
+
+func (m Matcher) Match(host, path string) bool {
+	host = hostname(strings.ToLower(host))
+
+	switch {
+	case m.host == "":
+	case strings.HasPrefix(m.host, "*."):
+		if !strings.HasSuffix(host, m.host[1:]) {
+			return false
+		}
+	case host != m.host:
+		return false
+	}
+
+	if m.path == "" {
+		return true
+	}
+	if len(path) < len(m.path) || !strings.EqualFold(path[:len(m.path)], m.path) {
+		return false
+	}
+	return len(path) == len(m.path) || path[len(m.path)] == '/'
+}
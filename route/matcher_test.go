@@ -0,0 +1,44 @@
+package route_test
+
+import (
+	"code.cloudfoundry.org/gorouter/route"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Matcher", func() {
+	It("matches every request when empty", func() {
+		m := route.NewMatcher("", "")
+		Expect(m.Match("foo.example.com", "/")).To(BeTrue())
+		Expect(m.Match("", "")).To(BeTrue())
+	})
+
+	It("matches the host case insensitively and ignores the port", func() {
+		m := route.NewMatcher("Foo.example.com", "")
+		Expect(m.Match("foo.EXAMPLE.com:8080", "/bar")).To(BeTrue())
+		Expect(m.Match("bar.example.com", "/bar")).To(BeFalse())
+	})
+
+	It("matches subdomains of a wildcard host", func() {
+		m := route.NewMatcher("*.example.com", "")
+		Expect(m.Match("foo.example.com", "/")).To(BeTrue())
+		Expect(m.Match("foo.bar.example.com", "/")).To(BeTrue())
+		Expect(m.Match("example.com", "/")).To(BeFalse())
+		Expect(m.Match("fooexample.com", "/")).To(BeFalse())
+	})
+
+	It("matches whole path segments", func() {
+		m := route.NewMatcher("foo.example.com", "/api/")
+		Expect(m.Match("foo.example.com", "/api")).To(BeTrue())
+		Expect(m.Match("foo.example.com", "/api/orders")).To(BeTrue())
+		Expect(m.Match("foo.example.com", "/apis")).To(BeFalse())
+		Expect(m.Match("foo.example.com", "/")).To(BeFalse())
+	})
+
+	It("matches the path case insensitively", func() {
+		m := route.NewMatcher("foo.example.com", "/Api")
+		Expect(m.Match("foo.example.com", "/API")).To(BeTrue())
+		Expect(m.Match("foo.example.com", "/api/Orders")).To(BeTrue())
+		Expect(m.Match("foo.example.com", "/APIS")).To(BeFalse())
+	})
+})
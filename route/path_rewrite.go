@@ -0,0 +1,158 @@
+package route
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/config"
+)
+
+var cookiePathPattern = regexp.MustCompile(`(?i)(;\s*path=)([^;]*)`)
+
+// PathRewrite maps the paths of a context-path route between what clients
+// see and what its backends see. Only prefix changes are reversed when
+// rewriting Location headers and cookie paths; regex rewrites are one-way.
+type PathRewrite struct {
+	prefix        string
+	backendPrefix string
+	regex         *regexp.Regexp
+	replacement   string
+}
+
+func NewPathRewrite(contextPath string, c config.PathRewrite) (*PathRewrite, error) {
+	p := &PathRewrite{
+		prefix:        strings.TrimSuffix(contextPath, "/"),
+		backendPrefix: strings.TrimSuffix(contextPath, "/"),
+		replacement:   c.Replacement,
+	}
+
+	if c.ReplacePrefix != "" {
+		p.backendPrefix = strings.TrimSuffix(c.ReplacePrefix, "/")
+	} else if c.StripPrefix {
+		p.backendPrefix = ""
+	}
+
+	if c.Regex != "" {
+		var err error
+		p.regex, err = regexp.Compile(c.Regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Prefix returns the part of the path removed by the rewrite, or an empty
+// string when the prefix is sent to the backend unchanged.
+func (p *PathRewrite) Prefix() string {
+	if p == nil || p.prefix == p.backendPrefix {
+		return ""
+	}
+	return p.prefix
+}
+
+// RewriteURI rewrites the path of a request URI, keeping its query and,
+// for absolute-form URIs, its scheme and host.
+func (p *PathRewrite) RewriteURI(requestURI string) string {
+	if p == nil {
+		return requestURI
+	}
+
+	var base string
+	if !strings.HasPrefix(requestURI, "/") {
+		if i := strings.Index(requestURI, "://"); i >= 0 {
+			end := strings.Index(requestURI[i+3:], "/")
+			if end < 0 {
+				return requestURI
+			}
+			base, requestURI = requestURI[:i+3+end], requestURI[i+3+end:]
+		}
+	}
+
+	path, query := requestURI, ""
+	if i := strings.Index(requestURI, "?"); i >= 0 {
+		path, query = requestURI[:i], requestURI[i:]
+	}
+
+	if rest, ok := trimPathPrefix(path, p.prefix); ok {
+		path = p.backendPrefix + rest
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	if p.regex != nil {
+		path = p.regex.ReplaceAllString(path, p.replacement)
+	}
+
+	return base + path + query
+}
+
+// RewriteResponse maps the Location header and the Path attribute of
+// cookies set by the backend back to the path seen by the client. Location
+// headers pointing to another host are left alone.
+func (p *PathRewrite) RewriteResponse(host string, header http.Header) {
+	if p == nil || p.prefix == p.backendPrefix {
+		return
+	}
+
+	if location := header.Get("Location"); location != "" {
+		u, err := url.Parse(location)
+		if err == nil && (u.Host == "" || strings.EqualFold(hostname(u.Host), hostname(host))) {
+			if path, ok := p.reverse(u.Path); ok {
+				u.Path = path
+				u.RawPath = ""
+				header.Set("Location", u.String())
+			}
+		}
+	}
+
+	cookies := header["Set-Cookie"]
+	for i, cookie := range cookies {
+		cookies[i] = cookiePathPattern.ReplaceAllStringFunc(cookie, func(attr string) string {
+			m := cookiePathPattern.FindStringSubmatch(attr)
+			if path, ok := p.reverse(m[2]); ok {
+				return m[1] + path
+			}
+			return attr
+		})
+	}
+}
+
+func (p *PathRewrite) reverse(path string) (string, bool) {
+	if rest, ok := trimPathPrefix(path, p.backendPrefix); ok {
+		if rest == "/" {
+			rest = ""
+		}
+		if p.prefix+rest == "" {
+			return "/", true
+		}
+		return p.prefix + rest, true
+	}
+	return path, false
+}
+
+// trimPathPrefix removes prefix from path when it matches whole segments.
+func trimPathPrefix(path, prefix string) (string, bool) {
+	if prefix == "" {
+		return path, strings.HasPrefix(path, "/") || path == ""
+	}
+	if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return path, false
+	}
+	rest := path[len(prefix):]
+	if rest != "" && rest[0] != '/' {
+		return path, false
+	}
+	return rest, true
+}
+
+func hostname(host string) string {
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		return host[:i]
+	}
+	return host
+}
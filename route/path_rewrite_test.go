@@ -0,0 +1,126 @@
+package route_test
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PathRewrite", func() {
+	var (
+		cfg         config.PathRewrite
+		pathRewrite *route.PathRewrite
+	)
+
+	BeforeEach(func() {
+		cfg = config.PathRewrite{Route: "example.com/api/orders"}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		pathRewrite, err = route.NewPathRewrite("/api/orders", cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when stripping the prefix", func() {
+		BeforeEach(func() {
+			cfg.StripPrefix = true
+		})
+
+		It("removes the context path from the request URI", func() {
+			Expect(pathRewrite.Prefix()).To(Equal("/api/orders"))
+			Expect(pathRewrite.RewriteURI("/api/orders/42?expand=true")).To(Equal("/42?expand=true"))
+			Expect(pathRewrite.RewriteURI("/api/orders")).To(Equal("/"))
+			Expect(pathRewrite.RewriteURI("/API/Orders/42")).To(Equal("/42"))
+			Expect(pathRewrite.RewriteURI("http://example.com/api/orders/42")).To(Equal("http://example.com/42"))
+		})
+
+		It("leaves paths outside of the context path alone", func() {
+			Expect(pathRewrite.RewriteURI("/api/ordersx")).To(Equal("/api/ordersx"))
+		})
+
+		It("maps Location headers and cookie paths back to the context path", func() {
+			header := http.Header{}
+			header.Set("Location", "http://example.com:8080/42?a=b")
+			header.Add("Set-Cookie", "session=abc; Path=/; HttpOnly")
+			header.Add("Set-Cookie", "other=def; path=/items")
+
+			pathRewrite.RewriteResponse("example.com", header)
+
+			Expect(header.Get("Location")).To(Equal("http://example.com:8080/api/orders/42?a=b"))
+			Expect(header["Set-Cookie"]).To(Equal([]string{
+				"session=abc; Path=/api/orders; HttpOnly",
+				"other=def; path=/api/orders/items",
+			}))
+		})
+
+		It("does not rewrite redirects to another host", func() {
+			header := http.Header{}
+			header.Set("Location", "https://login.example.com/auth")
+
+			pathRewrite.RewriteResponse("example.com", header)
+
+			Expect(header.Get("Location")).To(Equal("https://login.example.com/auth"))
+		})
+	})
+
+	Context("when replacing the prefix", func() {
+		BeforeEach(func() {
+			cfg.ReplacePrefix = "/v1/"
+		})
+
+		It("replaces the context path", func() {
+			Expect(pathRewrite.RewriteURI("/api/orders/42")).To(Equal("/v1/42"))
+		})
+
+		It("only maps paths under the new prefix back", func() {
+			header := http.Header{}
+			header.Set("Location", "/v1/42")
+			header.Add("Set-Cookie", "session=abc; Path=/static")
+
+			pathRewrite.RewriteResponse("example.com", header)
+
+			Expect(header.Get("Location")).To(Equal("/api/orders/42"))
+			Expect(header.Get("Set-Cookie")).To(Equal("session=abc; Path=/static"))
+		})
+	})
+
+	Context("with a regex", func() {
+		BeforeEach(func() {
+			cfg.StripPrefix = true
+			cfg.Regex = `^/(\d+)$`
+			cfg.Replacement = "/orders/$1"
+		})
+
+		It("applies the regex after the prefix rewrite", func() {
+			Expect(pathRewrite.RewriteURI("/api/orders/42?a=b")).To(Equal("/orders/42?a=b"))
+			Expect(pathRewrite.RewriteURI("/api/orders/abc")).To(Equal("/abc"))
+		})
+	})
+
+	Context("when the prefix is unchanged", func() {
+		It("does not rewrite responses", func() {
+			header := http.Header{}
+			header.Set("Location", "/42")
+
+			pathRewrite.RewriteResponse("example.com", header)
+
+			Expect(pathRewrite.Prefix()).To(BeEmpty())
+			Expect(header.Get("Location")).To(Equal("/42"))
+		})
+	})
+
+	It("fails on an invalid regex", func() {
+		_, err := route.NewPathRewrite("/", config.PathRewrite{Regex: "("})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("is a no-op when nil", func() {
+		var nilRewrite *route.PathRewrite
+		Expect(nilRewrite.RewriteURI("/foo")).To(Equal("/foo"))
+	})
+})
@@ -46,9 +46,12 @@ type Endpoint struct {
 	PrivateInstanceId    string
 	staleThreshold       time.Duration
 	RouteServiceUrl      string
+	Redirect             *Redirect
 	PrivateInstanceIndex string
 	ModificationTag      models.ModificationTag
 	Stats                *Stats
+
+	restored bool
 }
 
 //go:generate counterfeiter -o fakes/fake_endpoint_iterator.go . EndpointIterator
@@ -71,8 +74,11 @@ type Pool struct {
 	endpoints []*endpointElem
 	index     map[string]*endpointElem
 
+	uri             Uri
 	contextPath     string
 	routeServiceUrl string
+	redirect        *Redirect
+	pathRewrite     *PathRewrite
 
 	retryAfterFailure time.Duration
 	nextIdx           int
@@ -107,15 +113,47 @@ func (p *Pool) ContextPath() string {
 	return p.contextPath
 }
 
+// SetRouteUri records the route the pool is registered for, including its
+// context path.
+func (p *Pool) SetRouteUri(uri Uri) {
+	p.lock.Lock()
+	p.uri = uri
+	p.lock.Unlock()
+}
+
+func (p *Pool) RouteUri() Uri {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.uri
+}
+
+func (p *Pool) SetPathRewrite(pathRewrite *PathRewrite) {
+	p.lock.Lock()
+	p.pathRewrite = pathRewrite
+	p.lock.Unlock()
+}
+
+func (p *Pool) PathRewrite() *PathRewrite {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.pathRewrite
+}
+
 // Returns true if endpoint was added or updated, false otherwise
 func (p *Pool) Put(endpoint *Endpoint) bool {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	e, found := p.index[endpoint.CanonicalAddr()]
+	if p.mixesRedirects(endpoint, e) {
+		return false
+	}
 	if found {
 		if e.endpoint != endpoint {
-			if !e.endpoint.ModificationTag.SucceededBy(&endpoint.ModificationTag) {
+			if endpoint.restored && !e.endpoint.restored {
+				return false
+			}
+			if !e.endpoint.restored && !e.endpoint.ModificationTag.SucceededBy(&endpoint.ModificationTag) {
 				return false
 			}
 
@@ -156,6 +194,41 @@ func (p *Pool) RouteServiceUrl() string {
 	}
 }
 
+// SetRedirect turns the pool into a redirect route that is kept even when it
+// has no endpoints.
+func (p *Pool) SetRedirect(redirect *Redirect) {
+	p.lock.Lock()
+	p.redirect = redirect
+	p.lock.Unlock()
+}
+
+// mixesRedirects returns true when adding endpoint in place of replaced would
+// leave redirect and normal endpoints in the pool. The lock must be held.
+func (p *Pool) mixesRedirects(endpoint *Endpoint, replaced *endpointElem) bool {
+	for _, e := range p.endpoints {
+		if e != replaced {
+			return (e.endpoint.Redirect != nil) != (endpoint.Redirect != nil)
+		}
+	}
+	return false
+}
+
+// Redirect returns the redirect set on the pool, or else the redirect of its
+// endpoints. Put keeps redirect and normal endpoints out of the same pool, so
+// either every endpoint redirects or none does.
+func (p *Pool) Redirect() *Redirect {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.redirect != nil {
+		return p.redirect
+	}
+	if len(p.endpoints) > 0 {
+		return p.endpoints[0].endpoint.Redirect
+	}
+	return nil
+}
+
 func (p *Pool) PruneEndpoints(defaultThreshold time.Duration) []*Endpoint {
 	p.lock.Lock()
 
@@ -227,6 +300,26 @@ func (p *Pool) Endpoints(defaultLoadBalance, initial string) EndpointIterator {
 	}
 }
 
+// InstancePool returns a pool of endpoint alone, with the route, context path,
+// path rewrite and redirect of p.
+func (p *Pool) InstancePool(endpoint *Endpoint) *Pool {
+	pool := NewPool(0, p.ContextPath())
+
+	p.lock.Lock()
+	pool.uri = p.uri
+	pool.pathRewrite = p.pathRewrite
+	pool.redirect = p.redirect
+	p.lock.Unlock()
+
+	pool.Put(endpoint)
+	return pool
+}
+
+// FindByAddress returns the endpoint registered at addr, or nil
+func (p *Pool) FindByAddress(addr string) *Endpoint {
+	return p.findById(addr)
+}
+
 func (p *Pool) findById(id string) *Endpoint {
 	var endpoint *Endpoint
 	p.lock.Lock()
@@ -242,9 +335,10 @@ func (p *Pool) findById(id string) *Endpoint {
 func (p *Pool) IsEmpty() bool {
 	p.lock.Lock()
 	l := len(p.endpoints)
+	redirect := p.redirect
 	p.lock.Unlock()
 
-	return l == 0
+	return l == 0 && redirect == nil
 }
 
 func (p *Pool) MarkUpdated(t time.Time) {
@@ -293,10 +387,14 @@ func (e *Endpoint) MarshalJSON() ([]byte, error) {
 		Address         string `json:"address"`
 		TTL             int    `json:"ttl"`
 		RouteServiceUrl string `json:"route_service_url,omitempty"`
+		Redirect        string `json:"redirect,omitempty"`
 	}
 
 	jsonObj.Address = e.addr
 	jsonObj.RouteServiceUrl = e.RouteServiceUrl
+	if e.Redirect != nil {
+		jsonObj.Redirect = e.Redirect.Target
+	}
 	jsonObj.TTL = int(e.staleThreshold.Seconds())
 	return json.Marshal(jsonObj)
 }
@@ -104,6 +104,71 @@ var _ = Describe("Pool", func() {
 		})
 	})
 
+	Context("Redirect", func() {
+		var redirect *route.Redirect
+
+		BeforeEach(func() {
+			var err error
+			redirect, err = route.NewRedirect(301, "https://example.com")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the redirect of the first endpoint", func() {
+			Expect(pool.Redirect()).To(BeNil())
+
+			pool.Put(&route.Endpoint{Redirect: redirect})
+			Expect(pool.Redirect()).To(Equal(redirect))
+		})
+
+		It("prefers the redirect set on the pool", func() {
+			other, err := route.NewRedirect(302, "https://other.example.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			pool.Put(&route.Endpoint{Redirect: other})
+			pool.SetRedirect(redirect)
+			Expect(pool.Redirect()).To(Equal(redirect))
+		})
+
+		It("keeps the pool from being empty", func() {
+			pool.SetRedirect(redirect)
+			Expect(pool.IsEmpty()).To(BeFalse())
+		})
+
+		It("does not mix redirect and normal endpoints", func() {
+			Expect(pool.Put(route.NewEndpoint("", "1.2.3.4", 5678, "", "", nil, -1, "", modTag))).To(BeTrue())
+
+			redirecting := route.NewEndpoint("", "1.2.3.5", 5678, "", "", nil, -1, "", modTag)
+			redirecting.Redirect = redirect
+			Expect(pool.Put(redirecting)).To(BeFalse())
+			Expect(pool.Redirect()).To(BeNil())
+		})
+
+		It("lets the only endpoint be replaced by a redirect", func() {
+			Expect(pool.Put(route.NewEndpoint("", "1.2.3.4", 5678, "", "", nil, -1, "", modTag))).To(BeTrue())
+
+			redirecting := route.NewEndpoint("", "1.2.3.4", 5678, "", "", nil, -1, "", modTag)
+			redirecting.Redirect = redirect
+			Expect(pool.Put(redirecting)).To(BeTrue())
+			Expect(pool.Redirect()).To(Equal(redirect))
+		})
+	})
+
+	Context("InstancePool", func() {
+		It("returns a pool of the endpoint with the settings of the pool", func() {
+			redirect, err := route.NewRedirect(302, "https://example.com")
+			Expect(err).NotTo(HaveOccurred())
+			pool = route.NewPool(2*time.Minute, "/env")
+			pool.SetRedirect(redirect)
+			endpoint := route.NewEndpoint("", "1.2.3.4", 5678, "", "", nil, -1, "", modTag)
+			pool.Put(endpoint)
+
+			instancePool := pool.InstancePool(endpoint)
+			Expect(instancePool.ContextPath()).To(Equal("/env"))
+			Expect(instancePool.Redirect()).To(Equal(redirect))
+			Expect(instancePool.Endpoints("", "").Next()).To(Equal(endpoint))
+		})
+	})
+
 	Context("Remove", func() {
 		It("removes endpoints", func() {
 			endpoint := &route.Endpoint{}
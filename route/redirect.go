@@ -0,0 +1,75 @@
+package route
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"text/template"
+
+	"code.cloudfoundry.org/gorouter/config"
+)
+
+// RedirectData is available to redirect targets, e.g.
+// "https://{{.Host}}{{.RequestURI}}".
+type RedirectData struct {
+	Scheme     string
+	Host       string
+	Path       string
+	RawQuery   string
+	RequestURI string
+}
+
+// Redirect is a route answered by the router itself with a redirect to
+// Target instead of being proxied to a backend.
+type Redirect struct {
+	StatusCode int
+	Target     string
+
+	tmpl *template.Template
+}
+
+// NewRedirect compiles a redirect target. The status code defaults to 301.
+func NewRedirect(statusCode int, target string) (*Redirect, error) {
+	if statusCode == 0 {
+		statusCode = http.StatusMovedPermanently
+	}
+	if !config.ValidRedirectStatusCode(statusCode) {
+		return nil, fmt.Errorf("invalid redirect status code %d", statusCode)
+	}
+	if target == "" {
+		return nil, fmt.Errorf("missing redirect target")
+	}
+
+	tmpl, err := template.New("redirect").Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Redirect{
+		StatusCode: statusCode,
+		Target:     target,
+		tmpl:       tmpl,
+	}, nil
+}
+
+// Location renders the redirect target for a request.
+func (r *Redirect) Location(request *http.Request) (string, error) {
+	scheme := "http"
+	if request.TLS != nil || request.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	data := RedirectData{
+		Scheme:     scheme,
+		Host:       hostname(request.Host),
+		Path:       request.URL.EscapedPath(),
+		RawQuery:   request.URL.RawQuery,
+		RequestURI: request.URL.RequestURI(),
+	}
+
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,53 @@
+package route_test
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"code.cloudfoundry.org/gorouter/route"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redirect", func() {
+	var req *http.Request
+
+	BeforeEach(func() {
+		var err error
+		req, err = http.NewRequest("GET", "http://old.example.com:8080/foo%20bar?a=b", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("renders the target with the request values", func() {
+		redirect, err := route.NewRedirect(http.StatusFound, "{{.Scheme}}://new.example.com{{.Path}}?{{.RawQuery}}&from={{.Host}}")
+		Expect(err).NotTo(HaveOccurred())
+
+		location, err := redirect.Location(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(location).To(Equal("http://new.example.com/foo%20bar?a=b&from=old.example.com"))
+	})
+
+	It("detects https requests", func() {
+		redirect, err := route.NewRedirect(0, "{{.Scheme}}://new.example.com{{.RequestURI}}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(redirect.StatusCode).To(Equal(http.StatusMovedPermanently))
+
+		req.TLS = &tls.ConnectionState{}
+		location, err := redirect.Location(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(location).To(Equal("https://new.example.com/foo%20bar?a=b"))
+	})
+
+	It("rejects status codes that are not redirects", func() {
+		_, err := route.NewRedirect(http.StatusOK, "https://example.com")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects an empty or invalid target", func() {
+		_, err := route.NewRedirect(http.StatusMovedPermanently, "")
+		Expect(err).To(HaveOccurred())
+
+		_, err = route.NewRedirect(http.StatusMovedPermanently, "{{.Host")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -90,8 +90,16 @@ func (r *RoundRobin) EndpointFailed() {
 	}
 }
 
+// PreRequest and PostRequest only keep the connection count up to date for
+// reporting; round robin does not use it to pick endpoints.
 func (r *RoundRobin) PreRequest(e *Endpoint) {
+	if e.Stats != nil {
+		e.Stats.NumberConnections.Increment()
+	}
 }
 
 func (r *RoundRobin) PostRequest(e *Endpoint) {
+	if e.Stats != nil {
+		e.Stats.NumberConnections.Decrement()
+	}
 }
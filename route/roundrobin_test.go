@@ -199,4 +199,19 @@ var _ = Describe("RoundRobin", func() {
 			Expect(n1).ToNot(Equal(n2))
 		})
 	})
+
+	Describe("PreRequest and PostRequest", func() {
+		It("tracks the requests in flight to the endpoint", func() {
+			e1 := route.NewEndpoint("", "1.2.3.4", 5678, "", "", nil, -1, "", modTag)
+			pool.Put(e1)
+
+			iter := route.NewRoundRobin(pool, "")
+			iter.PreRequest(e1)
+			iter.PreRequest(e1)
+			Expect(e1.Stats.NumberConnections.Count()).To(BeEquivalentTo(2))
+
+			iter.PostRequest(e1)
+			Expect(e1.Stats.NumberConnections.Count()).To(BeEquivalentTo(1))
+		})
+	})
 })
@@ -0,0 +1,75 @@
+package route
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// EndpointSnapshot is an endpoint as it is written to a registry snapshot
+type EndpointSnapshot struct {
+	Address              string                 `json:"address"`
+	ApplicationId        string                 `json:"app_id,omitempty"`
+	Tags                 map[string]string      `json:"tags,omitempty"`
+	PrivateInstanceId    string                 `json:"private_instance_id,omitempty"`
+	PrivateInstanceIndex string                 `json:"private_instance_index,omitempty"`
+	RouteServiceUrl      string                 `json:"route_service_url,omitempty"`
+	RedirectStatusCode   int                    `json:"redirect_status_code,omitempty"`
+	RedirectTarget       string                 `json:"redirect_target,omitempty"`
+	ModificationTag      models.ModificationTag `json:"modification_tag"`
+}
+
+func (e *Endpoint) Snapshot() EndpointSnapshot {
+	s := EndpointSnapshot{
+		Address:              e.addr,
+		ApplicationId:        e.ApplicationId,
+		Tags:                 e.Tags,
+		PrivateInstanceId:    e.PrivateInstanceId,
+		PrivateInstanceIndex: e.PrivateInstanceIndex,
+		RouteServiceUrl:      e.RouteServiceUrl,
+		ModificationTag:      e.ModificationTag,
+	}
+	if e.Redirect != nil {
+		s.RedirectStatusCode = e.Redirect.StatusCode
+		s.RedirectTarget = e.Redirect.Target
+	}
+	return s
+}
+
+// RestoreEndpoint makes the endpoint of a snapshot, which is pruned after
+// staleThreshold unless it registers again. Any registration for the same
+// address replaces it, whatever its modification tag.
+func RestoreEndpoint(s EndpointSnapshot, staleThreshold time.Duration) (*Endpoint, error) {
+	if _, _, err := net.SplitHostPort(s.Address); err != nil {
+		return nil, fmt.Errorf("invalid endpoint address %q: %s", s.Address, err)
+	}
+
+	e := &Endpoint{
+		ApplicationId:        s.ApplicationId,
+		addr:                 s.Address,
+		Tags:                 s.Tags,
+		PrivateInstanceId:    s.PrivateInstanceId,
+		PrivateInstanceIndex: s.PrivateInstanceIndex,
+		staleThreshold:       staleThreshold,
+		RouteServiceUrl:      s.RouteServiceUrl,
+		ModificationTag:      s.ModificationTag,
+		Stats:                NewStats(),
+		restored:             true,
+	}
+	if s.RedirectTarget != "" {
+		redirect, err := NewRedirect(s.RedirectStatusCode, s.RedirectTarget)
+		if err != nil {
+			return nil, err
+		}
+		e.Redirect = redirect
+	}
+	return e, nil
+}
+
+// Restored returns true for an endpoint loaded from a snapshot that has not
+// registered again
+func (e *Endpoint) Restored() bool {
+	return e.restored
+}
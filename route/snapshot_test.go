@@ -0,0 +1,98 @@
+package route_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EndpointSnapshot", func() {
+	var (
+		modTag   models.ModificationTag
+		endpoint *route.Endpoint
+	)
+
+	BeforeEach(func() {
+		modTag = models.ModificationTag{Guid: "abc", Index: 2}
+		endpoint = route.NewEndpoint("app-id", "1.2.3.4", 5678, "instance-id", "1",
+			map[string]string{"component": "web"}, 30, "https://rs.example.com", modTag)
+	})
+
+	It("restores the endpoint", func() {
+		restored, err := route.RestoreEndpoint(endpoint.Snapshot(), time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(restored.CanonicalAddr()).To(Equal("1.2.3.4:5678"))
+		Expect(restored.ApplicationId).To(Equal("app-id"))
+		Expect(restored.PrivateInstanceId).To(Equal("instance-id"))
+		Expect(restored.PrivateInstanceIndex).To(Equal("1"))
+		Expect(restored.Tags).To(Equal(map[string]string{"component": "web"}))
+		Expect(restored.RouteServiceUrl).To(Equal("https://rs.example.com"))
+		Expect(restored.ModificationTag).To(Equal(modTag))
+		Expect(restored.Restored()).To(BeTrue())
+		Expect(endpoint.Restored()).To(BeFalse())
+	})
+
+	It("restores the redirect", func() {
+		redirect, err := route.NewRedirect(302, "https://example.com/")
+		Expect(err).ToNot(HaveOccurred())
+		endpoint.Redirect = redirect
+
+		restored, err := route.RestoreEndpoint(endpoint.Snapshot(), time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored.Redirect.StatusCode).To(Equal(302))
+		Expect(restored.Redirect.Target).To(Equal("https://example.com/"))
+	})
+
+	It("fails on an invalid address", func() {
+		snapshot := endpoint.Snapshot()
+		snapshot.Address = "1.2.3.4"
+
+		_, err := route.RestoreEndpoint(snapshot, time.Minute)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("prunes the restored endpoint after the stale threshold", func() {
+		restored, err := route.RestoreEndpoint(endpoint.Snapshot(), 10*time.Millisecond)
+		Expect(err).ToNot(HaveOccurred())
+
+		pool := route.NewPool(2*time.Minute, "")
+		pool.Put(restored)
+		time.Sleep(20 * time.Millisecond)
+
+		Expect(pool.PruneEndpoints(time.Minute)).To(ConsistOf(restored))
+	})
+
+	Context("in a pool", func() {
+		var pool *route.Pool
+
+		BeforeEach(func() {
+			pool = route.NewPool(2*time.Minute, "")
+		})
+
+		It("is replaced by a registration with the same modification tag", func() {
+			restored, err := route.RestoreEndpoint(endpoint.Snapshot(), time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pool.Put(restored)).To(BeTrue())
+
+			Expect(pool.Put(endpoint)).To(BeTrue())
+			pool.Each(func(e *route.Endpoint) {
+				Expect(e).To(BeIdenticalTo(endpoint))
+			})
+		})
+
+		It("does not replace a registered endpoint", func() {
+			Expect(pool.Put(endpoint)).To(BeTrue())
+
+			snapshot := endpoint.Snapshot()
+			snapshot.ModificationTag.Index++
+			restored, err := route.RestoreEndpoint(snapshot, time.Minute)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.Put(restored)).To(BeFalse())
+		})
+	})
+})
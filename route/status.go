@@ -0,0 +1,62 @@
+package route
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/routing-api/models"
+)
+
+// EndpointStatus is an endpoint of a pool with the state the pool keeps for
+// it. An endpoint is failing while requests skip it after a failure.
+type EndpointStatus struct {
+	Address              string                 `json:"address"`
+	ApplicationId        string                 `json:"app_id,omitempty"`
+	PrivateInstanceId    string                 `json:"private_instance_id,omitempty"`
+	PrivateInstanceIndex string                 `json:"private_instance_index,omitempty"`
+	Tags                 map[string]string      `json:"tags,omitempty"`
+	ModificationTag      models.ModificationTag `json:"modification_tag"`
+	TTL                  int                    `json:"ttl"`
+	RouteServiceUrl      string                 `json:"route_service_url,omitempty"`
+	LastUpdated          time.Time              `json:"last_updated"`
+	LastUpdateAge        float64                `json:"last_update_age_seconds"`
+	Connections          int64                  `json:"connections"`
+	FailedAt             *time.Time             `json:"failed_at,omitempty"`
+	Failing              bool                   `json:"failing"`
+	Restored             bool                   `json:"restored,omitempty"`
+}
+
+// EndpointStatuses returns the status of every endpoint of the pool
+func (p *Pool) EndpointStatuses() []EndpointStatus {
+	now := time.Now()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	statuses := make([]EndpointStatus, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		endpoint := e.endpoint
+		s := EndpointStatus{
+			Address:              endpoint.addr,
+			ApplicationId:        endpoint.ApplicationId,
+			PrivateInstanceId:    endpoint.PrivateInstanceId,
+			PrivateInstanceIndex: endpoint.PrivateInstanceIndex,
+			Tags:                 endpoint.Tags,
+			ModificationTag:      endpoint.ModificationTag,
+			TTL:                  int(endpoint.staleThreshold.Seconds()),
+			RouteServiceUrl:      endpoint.RouteServiceUrl,
+			LastUpdated:          e.updated.UTC(),
+			LastUpdateAge:        now.Sub(e.updated).Seconds(),
+			Restored:             endpoint.restored,
+		}
+		if endpoint.Stats != nil {
+			s.Connections = endpoint.Stats.NumberConnections.Count()
+		}
+		if e.failedAt != nil {
+			failedAt := e.failedAt.UTC()
+			s.FailedAt = &failedAt
+			s.Failing = now.Sub(failedAt) <= p.retryAfterFailure
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
@@ -0,0 +1,67 @@
+package route_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/routing-api/models"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EndpointStatuses", func() {
+	var (
+		pool     *route.Pool
+		endpoint *route.Endpoint
+		modTag   models.ModificationTag
+	)
+
+	BeforeEach(func() {
+		pool = route.NewPool(time.Minute, "")
+		modTag = models.ModificationTag{Guid: "abc", Index: 3}
+		endpoint = route.NewEndpoint("app-id", "1.2.3.4", 5678, "instance-id", "2",
+			map[string]string{"component": "web"}, 30, "https://rs.example.com", modTag)
+		pool.Put(endpoint)
+	})
+
+	It("returns the state of every endpoint", func() {
+		endpoint.Stats.NumberConnections.Increment()
+		endpoint.Stats.NumberConnections.Increment()
+
+		statuses := pool.EndpointStatuses()
+		Expect(statuses).To(HaveLen(1))
+
+		s := statuses[0]
+		Expect(s.Address).To(Equal("1.2.3.4:5678"))
+		Expect(s.ApplicationId).To(Equal("app-id"))
+		Expect(s.PrivateInstanceId).To(Equal("instance-id"))
+		Expect(s.PrivateInstanceIndex).To(Equal("2"))
+		Expect(s.Tags).To(Equal(map[string]string{"component": "web"}))
+		Expect(s.ModificationTag).To(Equal(modTag))
+		Expect(s.TTL).To(Equal(30))
+		Expect(s.RouteServiceUrl).To(Equal("https://rs.example.com"))
+		Expect(s.LastUpdated).To(BeTemporally("~", time.Now(), time.Second))
+		Expect(s.LastUpdateAge).To(BeNumerically("<", 1))
+		Expect(s.Connections).To(Equal(int64(2)))
+		Expect(s.FailedAt).To(BeNil())
+		Expect(s.Failing).To(BeFalse())
+	})
+
+	It("returns the age of the last update", func() {
+		pool.MarkUpdated(time.Now().Add(-time.Minute))
+
+		s := pool.EndpointStatuses()[0]
+		Expect(s.LastUpdateAge).To(BeNumerically("~", 60, 1))
+	})
+
+	It("returns failed endpoints", func() {
+		iter := pool.Endpoints("", "")
+		Expect(iter.Next()).To(Equal(endpoint))
+		iter.EndpointFailed()
+
+		s := pool.EndpointStatuses()[0]
+		Expect(s.FailedAt).ToNot(BeNil())
+		Expect(*s.FailedAt).To(BeTemporally("~", time.Now(), time.Second))
+		Expect(s.Failing).To(BeTrue())
+	})
+})
@@ -1,12 +1,26 @@
 package route
 
 import (
+	"context"
 	"errors"
 	"strings"
 )
 
 type Uri string
 
+type uriKey struct{}
+
+// NewUriContext returns a context carrying the route a request was routed to.
+func NewUriContext(ctx context.Context, uri Uri) context.Context {
+	return context.WithValue(ctx, uriKey{}, uri)
+}
+
+// UriFromContext returns the route carried by the context, or "".
+func UriFromContext(ctx context.Context) Uri {
+	uri, _ := ctx.Value(uriKey{}).(Uri)
+	return uri
+}
+
 func (u Uri) ToLower() Uri {
 	return Uri(strings.ToLower(u.String()))
 }
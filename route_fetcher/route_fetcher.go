@@ -7,6 +7,7 @@ import (
 
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
 	"code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/route"
 	"code.cloudfoundry.org/lager"
@@ -22,6 +23,7 @@ type RouteFetcher struct {
 	RouteRegistry                      registry.RegistryInterface
 	FetchRoutesInterval                time.Duration
 	SubscriptionRetryIntervalInSeconds int
+	Reporter                           reporter.RouteFetcherReporter
 
 	logger          lager.Logger
 	endpoints       []models.Route
@@ -94,7 +96,7 @@ func (r *RouteFetcher) startEventCycle() {
 			r.logger.Debug("fetching-token")
 			token, err := r.UaaClient.FetchToken(forceUpdate)
 			if err != nil {
-				metrics.IncrementCounter(TokenFetchErrors)
+				r.captureError(TokenFetchErrors)
 				r.logger.Error("failed-to-fetch-token", err)
 			} else {
 				r.logger.Debug("token-fetched-successfully")
@@ -116,13 +118,20 @@ func (r *RouteFetcher) startEventCycle() {
 	}()
 }
 
+func (r *RouteFetcher) captureError(name string) {
+	metrics.IncrementCounter(name)
+	if r.Reporter != nil {
+		r.Reporter.CaptureRouteFetcherError(name)
+	}
+}
+
 func (r *RouteFetcher) subscribeToEvents(token *schema.Token) error {
 	r.client.SetToken(token.AccessToken)
 
 	r.logger.Info("Subscribing-to-routing-api-event-stream")
 	source, err := r.client.SubscribeToEventsWithMaxRetries(maxRetries)
 	if err != nil {
-		metrics.IncrementCounter(SubscribeEventsErrors)
+		r.captureError(SubscribeEventsErrors)
 		r.logger.Error("Failed-to-subscribe-to-routing-api-event-stream: ", err)
 		return err
 	}
@@ -139,7 +148,7 @@ func (r *RouteFetcher) subscribeToEvents(token *schema.Token) error {
 	for {
 		event, err = source.Next()
 		if err != nil {
-			metrics.IncrementCounter(SubscribeEventsErrors)
+			r.captureError(SubscribeEventsErrors)
 			r.logger.Error("Failed to get next event: ", err)
 
 			closeErr := source.Close()
@@ -198,7 +207,7 @@ func (r *RouteFetcher) fetchRoutesWithTokenRefresh() ([]models.Route, error) {
 		r.logger.Debug("syncer-fetching-token")
 		token, tokenErr := r.UaaClient.FetchToken(forceUpdate)
 		if tokenErr != nil {
-			metrics.IncrementCounter(TokenFetchErrors)
+			r.captureError(TokenFetchErrors)
 			return []models.Route{}, tokenErr
 		}
 		r.client.SetToken(token.AccessToken)
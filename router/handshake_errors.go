@@ -0,0 +1,72 @@
+package router
+
+import (
+	"log"
+	"strings"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
+)
+
+// handshakeErrorPrefix starts the message net/http logs when the TLS
+// handshake of a new connection fails. The remote address, ": " and the
+// error follow.
+const handshakeErrorPrefix = "http: TLS handshake error from "
+
+// handshakeErrorWriter counts the TLS handshake failures of a listener from
+// the errors logged by its http.Server. Every message is still written to the
+// standard logger, as it is when the server has no ErrorLog.
+type handshakeErrorWriter struct {
+	listener string
+	reporter reporter.TLSReporter
+}
+
+// NewHandshakeErrorLog returns an ErrorLog for the http.Server of listener
+// that reports its TLS handshake failures by reason.
+func NewHandshakeErrorLog(listener string, tlsReporter reporter.TLSReporter) *log.Logger {
+	return log.New(&handshakeErrorWriter{listener: listener, reporter: tlsReporter}, "", 0)
+}
+
+func (w *handshakeErrorWriter) Write(p []byte) (int, error) {
+	msg := string(p)
+	if w.reporter != nil && strings.HasPrefix(msg, handshakeErrorPrefix) {
+		rest := msg[len(handshakeErrorPrefix):]
+		if i := strings.Index(rest, ": "); i >= 0 {
+			w.reporter.CaptureTLSHandshakeFailure(w.listener, HandshakeFailureReason(rest[i+2:]))
+		}
+	}
+
+	log.Print(msg)
+	return len(p), nil
+}
+
+// HandshakeFailureReason sorts the error of a failed TLS handshake into a
+// short reason that can be used as a metric name or label.
+func HandshakeFailureReason(err string) string {
+	switch {
+	case strings.Contains(err, "HTTP request to an HTTPS server"),
+		strings.Contains(err, "does not look like a TLS handshake"):
+		return "not_tls"
+	case strings.Contains(err, "i/o timeout"):
+		return "timeout"
+	case strings.HasSuffix(strings.TrimSpace(err), "EOF"),
+		strings.Contains(err, "connection reset by peer"),
+		strings.Contains(err, "broken pipe"):
+		return "client_closed"
+	case strings.Contains(err, "remote error: "):
+		// The client sent an alert, usually because it rejected the
+		// certificate of the router.
+		return "remote_alert"
+	case strings.Contains(err, "no cipher suite supported"),
+		strings.Contains(err, "no mutual cipher"):
+		return "no_shared_cipher"
+	case strings.Contains(err, "unsupported versions"),
+		strings.Contains(err, "protocol version"),
+		strings.Contains(err, "unsupported SSLv2"):
+		return "protocol_version"
+	case strings.Contains(err, "certificate"),
+		strings.Contains(err, "x509: "):
+		return "client_certificate"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,72 @@
+package router_test
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
+	. "code.cloudfoundry.org/gorouter/router"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HandshakeErrorLog", func() {
+	var (
+		fakeReporter *fakes.FakeTLSReporter
+		server       *httptest.Server
+	)
+
+	BeforeEach(func() {
+		fakeReporter = new(fakes.FakeTLSReporter)
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Config.ErrorLog = NewHandshakeErrorLog("https", fakeReporter)
+		server.StartTLS()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports failed handshakes of the listener by reason", func() {
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{ServerName: "example.com"})
+		Expect(err).To(HaveOccurred())
+		if conn != nil {
+			conn.Close()
+		}
+
+		Eventually(fakeReporter.CaptureTLSHandshakeFailureCallCount).Should(Equal(1))
+		listener, reason := fakeReporter.CaptureTLSHandshakeFailureArgsForCall(0)
+		Expect(listener).To(Equal("https"))
+		Expect(reason).To(Equal("remote_alert"))
+	})
+
+	It("does not report successful handshakes", func() {
+		resp, err := server.Client().Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+
+		Consistently(fakeReporter.CaptureTLSHandshakeFailureCallCount).Should(Equal(0))
+	})
+})
+
+var _ = Describe("HandshakeFailureReason", func() {
+	It("sorts handshake errors into reasons", func() {
+		reasons := map[string]string{
+			"client sent an HTTP request to an HTTPS server":           "not_tls",
+			"tls: first record does not look like a TLS handshake":     "not_tls",
+			"read tcp 10.0.0.1:443: i/o timeout":                       "timeout",
+			"EOF":                                                      "client_closed",
+			"read tcp 10.0.0.1:443: connection reset by peer":          "client_closed",
+			"remote error: tls: bad certificate":                       "remote_alert",
+			"tls: no cipher suite supported by both client and server": "no_shared_cipher",
+			"tls: client offered only unsupported versions: [301]":     "protocol_version",
+			"tls: client didn't provide a certificate":                 "client_certificate",
+			"tls: unexpected message":                                  "other",
+		}
+		for err, reason := range reasons {
+			Expect(HandshakeFailureReason(err)).To(Equal(reason), err)
+		}
+	})
+})
@@ -25,6 +25,7 @@ import (
 	"code.cloudfoundry.org/gorouter/config"
 	"code.cloudfoundry.org/gorouter/handlers"
 	"code.cloudfoundry.org/gorouter/metrics/monitor"
+	"code.cloudfoundry.org/gorouter/metrics/reporter"
 	"code.cloudfoundry.org/gorouter/proxy"
 	"code.cloudfoundry.org/gorouter/registry"
 	"code.cloudfoundry.org/gorouter/varz"
@@ -51,6 +52,8 @@ type Router struct {
 	varz       varz.Varz
 	component  *common.VcapComponent
 
+	tlsReporter reporter.TLSReporter
+
 	listener         net.Listener
 	tlsListener      net.Listener
 	closeConnections bool
@@ -70,7 +73,8 @@ type Router struct {
 }
 
 func NewRouter(logger lager.Logger, cfg *config.Config, p proxy.Proxy, mbusClient *nats.Conn, r *registry.RouteRegistry,
-	v varz.Varz, heartbeatOK *int32, logCounter *schema.LogCounter, errChan chan error) (*Router, error) {
+	v varz.Varz, heartbeatOK *int32, logCounter *schema.LogCounter, errChan chan error, statusHandlers map[string]http.Handler,
+	tlsReporter reporter.TLSReporter) (*Router, error) {
 
 	var host string
 	if cfg.Status.Port != 0 {
@@ -89,7 +93,7 @@ func NewRouter(logger lager.Logger, cfg *config.Config, p proxy.Proxy, mbusClien
 	}
 
 	healthz := &health.Healthz{}
-	health := handlers.NewHealthcheck("", heartbeatOK, logger)
+	health := handlers.NewHealthcheck("", heartbeatOK, nil, logger)
 	component := &common.VcapComponent{
 		Config:  cfg,
 		Varz:    varz,
@@ -98,7 +102,8 @@ func NewRouter(logger lager.Logger, cfg *config.Config, p proxy.Proxy, mbusClien
 		InfoRoutes: map[string]json.Marshaler{
 			"/routes": r,
 		},
-		Logger: logger,
+		Handlers: statusHandlers,
+		Logger:   logger,
 	}
 
 	routerErrChan := errChan
@@ -113,6 +118,7 @@ func NewRouter(logger lager.Logger, cfg *config.Config, p proxy.Proxy, mbusClien
 		registry:     r,
 		varz:         v,
 		component:    component,
+		tlsReporter:  tlsReporter,
 		serveDone:    make(chan struct{}),
 		tlsServeDone: make(chan struct{}),
 		idleConns:    make(map[net.Conn]struct{}),
@@ -132,14 +138,33 @@ func NewRouter(logger lager.Logger, cfg *config.Config, p proxy.Proxy, mbusClien
 }
 
 type gorouterHandler struct {
-	handler http.Handler
-	logger  lager.Logger
+	handler   http.Handler
+	requestId router_http.RequestIdPolicy
+	logger    lager.Logger
 }
 
 func (h *gorouterHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
 	// The X-Vcap-Request-Id must be set before the request is passed into the
 	// dropsonde InstrumentedHandler
-	router_http.SetVcapRequestIdHeader(req, h.logger)
+	id := h.requestId.SetRequestIdHeader(req, h.logger)
+	req = router_http.WithRequestId(req, id)
+
+	h.handler.ServeHTTP(res, req)
+}
+
+// requestIdHandler restores the request ID chosen by gorouterHandler, which
+// dropsonde replaces when X-Vcap-Request-Id is not a UUID, and echoes it in
+// the response.
+type requestIdHandler struct {
+	handler http.Handler
+	header  string
+}
+
+func (h *requestIdHandler) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if id := router_http.RequestId(req); id != "" {
+		req.Header.Set(h.header, id)
+		res.Header().Set(h.header, id)
+	}
 
 	h.handler.ServeHTTP(res, req)
 }
@@ -153,6 +178,12 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	r.ScheduleFlushApps()
 
 	lbOKDelay := r.config.StartResponseDelayInterval - r.config.LoadBalancerHealthyThreshold
+	if r.registry.WarmStarted(r.config.RegistrySnapshot.WarmStartMaxAge) {
+		// The routes restored from a fresh snapshot stand in for the
+		// registrations the delay waits for.
+		r.logger.Info("skipping-start-response-delay-after-warm-start")
+		lbOKDelay = 0
+	}
 
 	totalWaitDelay := r.config.LoadBalancerHealthyThreshold
 	if lbOKDelay > 0 {
@@ -174,19 +205,31 @@ func (r *Router) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 
 	r.logger.Info("completed-wait")
 
-	handler := gorouterHandler{handler: dropsonde.InstrumentedHandler(r.proxy), logger: r.logger}
+	requestId := router_http.RequestIdPolicy{
+		Header: r.config.RequestId.Header,
+		Mode:   r.config.RequestId.Mode,
+	}
+	handler := gorouterHandler{
+		handler:   dropsonde.InstrumentedHandler(&requestIdHandler{handler: r.proxy, header: requestId.HeaderName()}),
+		requestId: requestId,
+		logger:    r.logger,
+	}
 
-	server := &http.Server{
-		Handler:   &handler,
-		ConnState: r.HandleConnState,
+	// The TLS listener has its own server so that its handshake failures can
+	// be told apart, with the same settings as the plain one.
+	newServer := func() *http.Server {
+		return &http.Server{
+			Handler:   &handler,
+			ConnState: r.HandleConnState,
+		}
 	}
 
-	err := r.serveHTTP(server, r.errChan)
+	err := r.serveHTTP(newServer(), r.errChan)
 	if err != nil {
 		r.errChan <- err
 		return err
 	}
-	err = r.serveHTTPS(server, r.errChan)
+	err = r.serveHTTPS(newServer(), r.errChan)
 	if err != nil {
 		r.errChan <- err
 		return err
@@ -283,6 +326,7 @@ func (r *Router) serveHTTPS(server *http.Server, errChan chan error) error {
 		}
 
 		r.tlsListener = tls.NewListener(listener, tlsConfig)
+		server.ErrorLog = NewHandshakeErrorLog("https", r.tlsReporter)
 
 		r.logger.Info("tls-listener-started", lager.Data{"address": r.tlsListener.Addr()})
 
@@ -368,6 +412,7 @@ func (r *Router) Stop() {
 	r.logger.Info("gorouter.stopping")
 
 	r.stopListening()
+	r.registry.CloseEvents()
 
 	r.connLock.Lock()
 	r.closeIdleConns()
@@ -223,7 +223,7 @@ var _ = Describe("Router", func() {
 		})
 
 		errChan := make(chan error, 2)
-		rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan)
+		rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan, nil, nil)
 		Expect(err).ToNot(HaveOccurred())
 
 		opts := &mbus.SubscriberOpts{
@@ -468,7 +468,7 @@ var _ = Describe("Router", func() {
 				errChan = make(chan error, 2)
 				config.LoadBalancerHealthyThreshold = 2 * time.Second
 				config.Port = 8347
-				rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan)
+				rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 				runRouterHealthcheck := func(r *router.Router) {
 					signals := make(chan os.Signal)
@@ -562,7 +562,7 @@ var _ = Describe("Router", func() {
 				config.LoadBalancerHealthyThreshold = 2 * time.Second
 				config.StartResponseDelayInterval = 4 * time.Second
 				config.Port = 9348
-				rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan)
+				rtr, err = router.NewRouter(logger, config, p, mbusClient, registry, varz, &healthCheck, logcounter, errChan, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 
 				signals := make(chan os.Signal)
@@ -607,7 +607,7 @@ var _ = Describe("Router", func() {
 
 				errChan = make(chan error, 2)
 				var err error
-				rtr, err = router.NewRouter(logger, config, proxy, mbusClient, registry, varz, &healthCheck, logcounter, errChan)
+				rtr, err = router.NewRouter(logger, config, proxy, mbusClient, registry, varz, &healthCheck, logcounter, errChan, nil, nil)
 				Expect(err).ToNot(HaveOccurred())
 				runRouter(rtr)
 			})
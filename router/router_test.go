@@ -34,6 +34,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/gorouter/metrics/reporter/fakes"
@@ -99,7 +100,7 @@ var _ = Describe("Router", func() {
 		var healthCheck int32
 		healthCheck = 0
 		logcounter := schema.NewLogCounter()
-		router, err = NewRouter(logger, config, proxy, mbusClient, registry, varz, &healthCheck, logcounter, nil)
+		router, err = NewRouter(logger, config, proxy, mbusClient, registry, varz, &healthCheck, logcounter, nil, nil, nil)
 
 		Expect(err).ToNot(HaveOccurred())
 
@@ -438,6 +439,51 @@ var _ = Describe("Router", func() {
 		Expect(resp.StatusCode).To(Equal(http.StatusOK))
 	})
 
+	Context("when the request id mode is append", func() {
+		BeforeEach(func() {
+			config.RequestId.Mode = "append"
+			config.RequestId.Header = "X-Request-Id"
+		})
+
+		It("keeps the incoming request id and echoes it in the response", func() {
+			done := make(chan http.Header)
+			app := testcommon.NewTestApp([]route.Uri{"foo.vcap.me"}, config.Port, mbusClient, nil, "")
+			app.AddHandler("/", func(w http.ResponseWriter, r *http.Request) {
+				_, err := ioutil.ReadAll(r.Body)
+				Expect(err).NotTo(HaveOccurred())
+				w.WriteHeader(http.StatusOK)
+				done <- r.Header
+			})
+
+			app.Listen()
+			go app.RegisterRepeatedly(1 * time.Second)
+
+			Eventually(func() bool {
+				return appRegistered(registry, app)
+			}).Should(BeTrue())
+
+			conn, err := net.Dial("tcp", net.JoinHostPort(config.Ip, strconv.Itoa(int(config.Port))))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			httpConn := test_util.NewHttpConn(conn)
+
+			req := test_util.NewRequest("GET", "foo.vcap.me", "/", nil)
+			req.Header.Add("X-Request-Id", "lb-1234")
+
+			httpConn.WriteRequest(req)
+
+			var header http.Header
+			Eventually(done).Should(Receive(&header))
+			Expect(header.Get("X-Request-Id")).To(HavePrefix("lb-1234::"))
+			Expect(header.Get(router_http.VcapRequestIdHeader)).To(MatchRegexp(uuid_regex))
+
+			resp, _ := httpConn.ReadResponse()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("X-Request-Id")).To(Equal(header.Get("X-Request-Id")))
+		})
+	})
+
 	It("handles a /routes request", func() {
 		var client http.Client
 		var req *http.Request
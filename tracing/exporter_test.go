@@ -0,0 +1,111 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/tracing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exporters", func() {
+	var (
+		server *httptest.Server
+		bodies chan []byte
+		status int
+		spans  []*tracing.Span
+	)
+
+	BeforeEach(func() {
+		bodies = make(chan []byte, 1)
+		status = http.StatusAccepted
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			Expect(r.Method).To(Equal("POST"))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			bodies <- body
+			w.WriteHeader(status)
+		}))
+
+		spans = []*tracing.Span{{
+			TraceId:  "a3ce929d0e0e4736",
+			Id:       "00f067aa0ba902b7",
+			ParentId: "e457b5a2e4d86bd1",
+			Name:     "GET",
+			Kind:     tracing.KindClient,
+			Start:    time.Unix(1500000000, 0),
+			Duration: 1500 * time.Microsecond,
+			Tags:     map[string]string{"error": "dial tcp: refused", "peer.address": "10.0.0.1:8080"},
+			Error:    true,
+		}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Zipkin", func() {
+		It("posts spans in the Zipkin v2 JSON format", func() {
+			exporter := tracing.NewZipkinExporter(server.URL, "gorouter", http.DefaultClient)
+			Expect(exporter.Export(spans)).To(Succeed())
+
+			Expect(string(<-bodies)).To(MatchJSON(`[{
+				"traceId": "a3ce929d0e0e4736",
+				"id": "00f067aa0ba902b7",
+				"parentId": "e457b5a2e4d86bd1",
+				"name": "get",
+				"kind": "CLIENT",
+				"timestamp": 1500000000000000,
+				"duration": 1500,
+				"localEndpoint": {"serviceName": "gorouter"},
+				"tags": {"error": "dial tcp: refused", "peer.address": "10.0.0.1:8080"}
+			}]`))
+		})
+
+		It("fails when the collector rejects the spans", func() {
+			status = http.StatusBadRequest
+			exporter := tracing.NewZipkinExporter(server.URL, "gorouter", http.DefaultClient)
+			Expect(exporter.Export(spans)).To(MatchError(ContainSubstring("400")))
+		})
+	})
+
+	Describe("OTLP", func() {
+		It("posts spans in the OTLP/HTTP JSON format", func() {
+			exporter := tracing.NewOTLPExporter(server.URL, "gorouter", http.DefaultClient)
+			Expect(exporter.Export(spans)).To(Succeed())
+
+			var req map[string]interface{}
+			Expect(json.Unmarshal(<-bodies, &req)).To(Succeed())
+
+			resourceSpans := req["resourceSpans"].([]interface{})[0].(map[string]interface{})
+			Expect(resourceSpans["resource"]).To(Equal(map[string]interface{}{
+				"attributes": []interface{}{
+					map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "gorouter"}},
+				},
+			}))
+
+			span := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0]
+			Expect(span).To(Equal(map[string]interface{}{
+				"traceId":           "0000000000000000a3ce929d0e0e4736",
+				"spanId":            "00f067aa0ba902b7",
+				"parentSpanId":      "e457b5a2e4d86bd1",
+				"name":              "GET",
+				"kind":              float64(3),
+				"startTimeUnixNano": "1500000000000000000",
+				"endTimeUnixNano":   "1500000000001500000",
+				"attributes": []interface{}{
+					map[string]interface{}{"key": "error", "value": map[string]interface{}{"stringValue": "dial tcp: refused"}},
+					map[string]interface{}{"key": "peer.address", "value": map[string]interface{}{"stringValue": "10.0.0.1:8080"}},
+				},
+				"status": map[string]interface{}{"code": float64(2), "message": "dial tcp: refused"},
+			}))
+		})
+	})
+})
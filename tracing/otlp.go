@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	otlpKindServer     = 2
+	otlpKindClient     = 3
+	otlpStatusUnset    = 0
+	otlpStatusError    = 2
+	otlpScopeName      = "code.cloudfoundry.org/gorouter"
+	otlpServiceNameKey = "service.name"
+)
+
+// OTLPExporter posts spans to an OTLP/HTTP collector using the JSON
+// encoding, e.g. http://localhost:4318/v1/traces.
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func NewOTLPExporter(endpoint, serviceName string, client *http.Client) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      client,
+	}
+}
+
+func (e *OTLPExporter) Export(spans []*Span) error {
+	ospans := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		kind := otlpKindServer
+		if s.Kind == KindClient {
+			kind = otlpKindClient
+		}
+
+		status := otlpStatus{Code: otlpStatusUnset}
+		if s.Error {
+			status = otlpStatus{Code: otlpStatusError, Message: s.Tags["error"]}
+		}
+
+		start := s.Start.UnixNano()
+		ospans = append(ospans, otlpSpan{
+			TraceId:           widenTraceId(s.TraceId),
+			SpanId:            s.Id,
+			ParentSpanId:      s.ParentId,
+			Name:              s.Name,
+			Kind:              kind,
+			StartTimeUnixNano: strconv.FormatInt(start, 10),
+			EndTimeUnixNano:   strconv.FormatInt(start+int64(s.Duration), 10),
+			Attributes:        attributes(s.Tags),
+			Status:            status,
+		})
+	}
+
+	body, err := json.Marshal(otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: []otlpAttribute{{Key: otlpServiceNameKey, Value: otlpValue{StringValue: e.serviceName}}},
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: ospans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	return post(e.client, e.endpoint, body)
+}
+
+// widenTraceId pads 64 bit B3 trace ids to the 128 bit OTLP requires.
+func widenTraceId(traceId string) string {
+	if len(traceId) == 16 {
+		return strings.Repeat("0", 16) + traceId
+	}
+	return traceId
+}
+
+func attributes(tags map[string]string) []otlpAttribute {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otlpAttribute, 0, len(tags))
+	for _, k := range keys {
+		attrs = append(attrs, otlpAttribute{Key: k, Value: otlpValue{StringValue: tags[k]}})
+	}
+	return attrs
+}
@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+)
+
+const (
+	KindServer = "SERVER"
+	KindClient = "CLIENT"
+)
+
+type spanKey struct{}
+
+// Span is a timed operation of the router within a trace. All methods are
+// safe to call on a nil span, which is what requests that are not sampled
+// carry.
+type Span struct {
+	TraceId  string
+	Id       string
+	ParentId string
+	Name     string
+	Kind     string
+	Start    time.Time
+	Duration time.Duration
+	Tags     map[string]string
+	Error    bool
+
+	tracer   *Tracer
+	mu       sync.Mutex
+	finished bool
+}
+
+// NewContext returns a context carrying the span.
+func NewContext(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext returns the span carried by the context, or nil.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Child starts a span whose parent is s.
+func (s *Span) Child(name, kind string) *Span {
+	if s == nil {
+		return nil
+	}
+
+	id, err := router_http.NewSpanId()
+	if err != nil {
+		return nil
+	}
+
+	return &Span{
+		TraceId:  s.TraceId,
+		Id:       id,
+		ParentId: s.Id,
+		Name:     name,
+		Kind:     kind,
+		Start:    time.Now(),
+		Tags:     map[string]string{},
+		tracer:   s.tracer,
+	}
+}
+
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.Tags[key] = value
+	s.mu.Unlock()
+}
+
+// SetError marks the span as failed and records the error message.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.Error = true
+	s.Tags["error"] = err.Error()
+	s.mu.Unlock()
+}
+
+// Inject writes the span to the trace context headers so that the next hop
+// becomes its child.
+func (s *Span) Inject(header http.Header) {
+	if s == nil {
+		return
+	}
+
+	router_http.WriteTraceContextHeaders(header, s.tracer.format, router_http.TraceContext{
+		TraceId:      s.TraceId,
+		SpanId:       s.Id,
+		ParentSpanId: s.ParentId,
+		Sampled:      "1",
+	})
+}
+
+// Finish records the duration of the span and queues it for export. Only
+// the first call has an effect.
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	s.Duration = time.Since(s.Start)
+	s.mu.Unlock()
+
+	s.tracer.enqueue(s)
+}
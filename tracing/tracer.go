@@ -0,0 +1,178 @@
+package tracing
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/lager"
+)
+
+// Exporter sends a batch of finished spans to a collector.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Tracer records the spans of sampled requests and exports them in batches
+// from its own goroutine. Spans that do not fit into the buffer are dropped
+// so that export never blocks the proxy.
+type Tracer struct {
+	format        string
+	sampleRate    float64
+	exporter      Exporter
+	spans         chan *Span
+	batchSize     int
+	flushInterval time.Duration
+	logger        lager.Logger
+
+	randLock sync.Mutex
+	rand     *rand.Rand
+
+	dropped  uint64
+	exported uint64
+}
+
+// NewTracer creates a tracer for the configured exporter. It returns nil when
+// no exporter is configured.
+func NewTracer(c config.Tracing, logger lager.Logger) (*Tracer, error) {
+	var exporter Exporter
+	client := &http.Client{Timeout: c.Exporter.Timeout}
+
+	switch c.Exporter.Type {
+	case "":
+		return nil, nil
+	case config.TRACE_EXPORTER_ZIPKIN:
+		exporter = NewZipkinExporter(c.Exporter.Endpoint, c.ServiceName, client)
+	case config.TRACE_EXPORTER_OTLP:
+		exporter = NewOTLPExporter(c.Exporter.Endpoint, c.ServiceName, client)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", c.Exporter.Type)
+	}
+
+	return New(exporter, c.Format, c.SampleRate, c.Exporter.BufferSize, c.Exporter.BatchSize, c.Exporter.FlushInterval, logger), nil
+}
+
+func New(exporter Exporter, format string, sampleRate float64, bufferSize, batchSize int, flushInterval time.Duration, logger lager.Logger) *Tracer {
+	return &Tracer{
+		format:        format,
+		sampleRate:    sampleRate,
+		exporter:      exporter,
+		spans:         make(chan *Span, bufferSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		logger:        logger,
+		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Sample decides whether a trace without an incoming sampling decision is
+// recorded, returning "1" or "0".
+func (t *Tracer) Sample() string {
+	if t == nil {
+		return ""
+	}
+
+	t.randLock.Lock()
+	sampled := t.rand.Float64() < t.sampleRate
+	t.randLock.Unlock()
+
+	if sampled {
+		return "1"
+	}
+	return "0"
+}
+
+// StartSpan starts the router's server span for a request. It returns nil
+// when the trace is not sampled.
+func (t *Tracer) StartSpan(tc router_http.TraceContext, name string) *Span {
+	if t == nil || tc.Sampled != "1" {
+		return nil
+	}
+
+	return &Span{
+		TraceId:  tc.TraceId,
+		Id:       tc.SpanId,
+		ParentId: tc.ParentSpanId,
+		Name:     name,
+		Kind:     KindServer,
+		Start:    time.Now(),
+		Tags:     map[string]string{},
+		tracer:   t,
+	}
+}
+
+// Dropped returns the number of spans dropped because the buffer was full.
+func (t *Tracer) Dropped() uint64 {
+	return atomic.LoadUint64(&t.dropped)
+}
+
+// Exported returns the number of spans sent to the collector.
+func (t *Tracer) Exported() uint64 {
+	return atomic.LoadUint64(&t.exported)
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	select {
+	case t.spans <- span:
+	default:
+		atomic.AddUint64(&t.dropped, 1)
+	}
+}
+
+func (t *Tracer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, t.batchSize)
+	var reportedDropped uint64
+
+	flush := func() {
+		if dropped := t.Dropped(); dropped != reportedDropped {
+			t.logger.Info("tracing-spans-dropped", lager.Data{"total": dropped})
+			reportedDropped = dropped
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		if err := t.exporter.Export(batch); err != nil {
+			t.logger.Error("tracing-export-failed", err, lager.Data{"spans": len(batch)})
+		} else {
+			atomic.AddUint64(&t.exported, uint64(len(batch)))
+		}
+		batch = make([]*Span, 0, t.batchSize)
+	}
+
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) >= t.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-signals:
+			for {
+				select {
+				case span := <-t.spans:
+					batch = append(batch, span)
+					if len(batch) >= t.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return nil
+				}
+			}
+		}
+	}
+}
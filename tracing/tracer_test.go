@@ -0,0 +1,188 @@
+package tracing_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	router_http "code.cloudfoundry.org/gorouter/common/http"
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/tracing"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+type fakeExporter struct {
+	mu      sync.Mutex
+	batches [][]*tracing.Span
+	err     error
+}
+
+func (f *fakeExporter) Export(spans []*tracing.Span) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, spans)
+	return f.err
+}
+
+func (f *fakeExporter) Batches() [][]*tracing.Span {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.batches
+}
+
+func (f *fakeExporter) Spans() []*tracing.Span {
+	var spans []*tracing.Span
+	for _, b := range f.Batches() {
+		spans = append(spans, b...)
+	}
+	return spans
+}
+
+var _ = Describe("Tracer", func() {
+	var (
+		exporter *fakeExporter
+		tracer   *tracing.Tracer
+		logger   *lagertest.TestLogger
+		tc       router_http.TraceContext
+	)
+
+	BeforeEach(func() {
+		exporter = &fakeExporter{}
+		logger = lagertest.NewTestLogger("tracer")
+		tracer = tracing.New(exporter, router_http.TraceFormatBoth, 1, 10, 2, 10*time.Millisecond, logger)
+		tc = router_http.TraceContext{
+			TraceId:      "4bf92f3577b34da6a3ce929d0e0e4736",
+			SpanId:       "00f067aa0ba902b7",
+			ParentSpanId: "e457b5a2e4d86bd1",
+			Sampled:      "1",
+		}
+	})
+
+	Describe("NewTracer", func() {
+		It("returns nil without an exporter", func() {
+			t, err := tracing.NewTracer(config.Tracing{}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).To(BeNil())
+			Expect(t.Sample()).To(BeEmpty())
+			Expect(t.StartSpan(tc, "GET")).To(BeNil())
+		})
+
+		It("creates a tracer for the configured exporter", func() {
+			t, err := tracing.NewTracer(config.Tracing{
+				Format:     "b3",
+				SampleRate: 1,
+				Exporter:   config.TraceExporterConfig{Type: "otlp", Endpoint: "http://localhost:4318/v1/traces", BufferSize: 1, BatchSize: 1, FlushInterval: time.Second},
+			}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).NotTo(BeNil())
+		})
+	})
+
+	Describe("Sample", func() {
+		It("samples according to the sample rate", func() {
+			Expect(tracer.Sample()).To(Equal("1"))
+
+			tracer = tracing.New(exporter, router_http.TraceFormatB3, 0, 10, 2, time.Second, logger)
+			Expect(tracer.Sample()).To(Equal("0"))
+		})
+	})
+
+	Describe("StartSpan", func() {
+		It("does not record traces that are not sampled", func() {
+			tc.Sampled = "0"
+			Expect(tracer.StartSpan(tc, "GET")).To(BeNil())
+		})
+
+		It("starts a server span for the router span of the trace context", func() {
+			span := tracer.StartSpan(tc, "GET")
+			Expect(span.TraceId).To(Equal(tc.TraceId))
+			Expect(span.Id).To(Equal(tc.SpanId))
+			Expect(span.ParentId).To(Equal(tc.ParentSpanId))
+			Expect(span.Kind).To(Equal(tracing.KindServer))
+		})
+	})
+
+	Describe("Span", func() {
+		It("starts child spans and injects them into headers", func() {
+			span := tracer.StartSpan(tc, "GET")
+			child := span.Child("backend", tracing.KindClient)
+			Expect(child.TraceId).To(Equal(tc.TraceId))
+			Expect(child.ParentId).To(Equal(tc.SpanId))
+			Expect(child.Id).To(MatchRegexp(`^[[:xdigit:]]{16}$`))
+
+			header := http.Header{}
+			child.Inject(header)
+			Expect(header.Get(router_http.B3SpanIdHeader)).To(Equal(child.Id))
+			Expect(header.Get(router_http.B3ParentSpanIdHeader)).To(Equal(tc.SpanId))
+			Expect(header.Get("traceparent")).To(Equal("00-" + tc.TraceId + "-" + child.Id + "-01"))
+		})
+
+		It("is safe to use when nil", func() {
+			var span *tracing.Span
+			child := span.Child("backend", tracing.KindClient)
+			Expect(child).To(BeNil())
+			child.SetTag("a", "b")
+			child.SetError(errors.New("boom"))
+			child.Inject(http.Header{})
+			child.Finish()
+		})
+	})
+
+	Describe("Run", func() {
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			process = ifrit.Invoke(tracer)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive())
+		})
+
+		It("exports finished spans in batches", func() {
+			for i := 0; i < 3; i++ {
+				span := tracer.StartSpan(tc, "GET")
+				span.Finish()
+				span.Finish()
+			}
+
+			Eventually(exporter.Spans).Should(HaveLen(3))
+			Expect(exporter.Batches()[0]).To(HaveLen(2))
+			Expect(tracer.Exported()).To(BeEquivalentTo(3))
+		})
+
+		It("logs export failures", func() {
+			exporter.err = errors.New("collector down")
+			tracer.StartSpan(tc, "GET").Finish()
+
+			Eventually(logger).Should(gbytes.Say("tracing-export-failed"))
+		})
+	})
+
+	It("drops spans when the buffer is full", func() {
+		tracer = tracing.New(exporter, router_http.TraceFormatB3, 1, 1, 1, time.Second, logger)
+		tracer.StartSpan(tc, "GET").Finish()
+		tracer.StartSpan(tc, "GET").Finish()
+
+		Expect(tracer.Dropped()).To(BeEquivalentTo(1))
+	})
+
+	It("flushes buffered spans when stopped", func() {
+		tracer = tracing.New(exporter, router_http.TraceFormatB3, 1, 10, 5, time.Hour, logger)
+		tracer.StartSpan(tc, "GET").Finish()
+
+		process := ifrit.Invoke(tracer)
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+
+		Expect(exporter.Spans()).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ZipkinExporter posts spans to the Zipkin v2 JSON API, e.g.
+// http://localhost:9411/api/v2/spans.
+type ZipkinExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinSpan struct {
+	TraceId       string            `json:"traceId"`
+	Id            string            `json:"id"`
+	ParentId      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint zipkinEndpoint    `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+func NewZipkinExporter(endpoint, serviceName string, client *http.Client) *ZipkinExporter {
+	return &ZipkinExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      client,
+	}
+}
+
+func (e *ZipkinExporter) Export(spans []*Span) error {
+	zspans := make([]zipkinSpan, 0, len(spans))
+	for _, s := range spans {
+		zspans = append(zspans, zipkinSpan{
+			TraceId:       s.TraceId,
+			Id:            s.Id,
+			ParentId:      s.ParentId,
+			Name:          strings.ToLower(s.Name),
+			Kind:          s.Kind,
+			Timestamp:     s.Start.UnixNano() / int64(time.Microsecond),
+			Duration:      microseconds(s.Duration),
+			LocalEndpoint: zipkinEndpoint{ServiceName: e.serviceName},
+			Tags:          s.Tags,
+		})
+	}
+
+	body, err := json.Marshal(zspans)
+	if err != nil {
+		return err
+	}
+	return post(e.client, e.endpoint, body)
+}
+
+// microseconds rounds up so that short spans are not reported as having no
+// duration.
+func microseconds(d time.Duration) int64 {
+	us := int64(d / time.Microsecond)
+	if us < 1 {
+		return 1
+	}
+	return us
+}
+
+func post(client *http.Client, endpoint string, body []byte) error {
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector responded with %d", resp.StatusCode)
+	}
+	return nil
+}
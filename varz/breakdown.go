@@ -0,0 +1,212 @@
+package varz
+
+import (
+	"container/heap"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	"code.cloudfoundry.org/gorouter/stats/container"
+)
+
+type lruHttpMetricEntry struct {
+	key    string
+	metric *HttpMetric
+	used   int64 // Sequence number of the last capture
+	i      int   // Index in the least recently used heap
+}
+
+type byLastUseHeap struct{ container.Heap }
+
+func (x *byLastUseHeap) Init() {
+	x.Heap.HeapType = x
+}
+
+func (x *byLastUseHeap) Less(i, j int) bool {
+	yi := x.Heap.Get(i).(*lruHttpMetricEntry)
+	yj := x.Heap.Get(j).(*lruHttpMetricEntry)
+	return yi.used < yj.used
+}
+
+func (x *byLastUseHeap) SetIndex(i, j int) {
+	y := x.Heap.Get(i).(*lruHttpMetricEntry)
+	y.i = j
+}
+
+// LRUHttpMetric is a TaggedHttpMetric holding at most max tags. Capturing a
+// new tag evicts the least recently used one.
+type LRUHttpMetric struct {
+	sync.Mutex
+
+	max  int
+	used int64
+	m    map[string]*lruHttpMetricEntry
+	h    byLastUseHeap
+}
+
+func NewLRUHttpMetric(max int) *LRUHttpMetric {
+	x := &LRUHttpMetric{max: max}
+
+	x.m = make(map[string]*lruHttpMetricEntry)
+	x.h.Init()
+
+	return x
+}
+
+func (x *LRUHttpMetric) httpMetric(t string) *HttpMetric {
+	x.Lock()
+	defer x.Unlock()
+
+	x.used++
+
+	y := x.m[t]
+	if y != nil {
+		y.used = x.used
+		heap.Fix(&x.h, y.i)
+		return y.metric
+	}
+
+	if len(x.m) >= x.max {
+		z := heap.Pop(&x.h).(*lruHttpMetricEntry)
+		delete(x.m, z.key)
+	}
+
+	y = &lruHttpMetricEntry{key: t, metric: NewHttpMetric(), used: x.used}
+	x.m[t] = y
+	heap.Push(&x.h, y)
+
+	return y.metric
+}
+
+func (x *LRUHttpMetric) CaptureRequest(t string) {
+	x.httpMetric(t).CaptureRequest()
+}
+
+func (x *LRUHttpMetric) CaptureResponse(t string, y *http.Response, z time.Duration) {
+	x.httpMetric(t).CaptureResponse(y, z)
+}
+
+// Get returns the metric of tag t, or nil when it is not tracked.
+func (x *LRUHttpMetric) Get(t string) *HttpMetric {
+	x.Lock()
+	defer x.Unlock()
+
+	if y := x.m[t]; y != nil {
+		return y.metric
+	}
+	return nil
+}
+
+func (x *LRUHttpMetric) Len() int {
+	x.Lock()
+	defer x.Unlock()
+
+	return len(x.m)
+}
+
+func (x *LRUHttpMetric) MarshalJSON() ([]byte, error) {
+	x.Lock()
+	y := make(map[string]*HttpMetric, len(x.m))
+	for t, z := range x.m {
+		y[t] = z.metric
+	}
+	x.Unlock()
+
+	return json.Marshal(y)
+}
+
+// Breakdown tracks the requests, responses and latency of each application
+// and each route. Routes are tracked by the route they were routed to,
+// including its context path, or by their host when that is unknown.
+type Breakdown struct {
+	Apps   *LRUHttpMetric `json:"apps,omitempty"`
+	Routes *LRUHttpMetric `json:"routes,omitempty"`
+}
+
+func NewBreakdown(c config.VarzBreakdownConfig) *Breakdown {
+	x := &Breakdown{}
+
+	if c.ByApp {
+		x.Apps = NewLRUHttpMetric(c.MaxEntries)
+	}
+	if c.ByRoute {
+		x.Routes = NewLRUHttpMetric(c.MaxEntries)
+	}
+
+	return x
+}
+
+func (x *Breakdown) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
+	if x.Apps != nil && b.ApplicationId != "" {
+		x.Apps.CaptureRequest(b.ApplicationId)
+	}
+	if x.Routes != nil {
+		x.Routes.CaptureRequest(routeKey(req))
+	}
+}
+
+// CaptureRoutingResponse captures the response for the route of the request
+// it answers, so responses that were never received are only captured for
+// the application.
+func (x *Breakdown) CaptureRoutingResponse(b *route.Endpoint, res *http.Response, d time.Duration) {
+	if x.Apps != nil && b.ApplicationId != "" {
+		x.Apps.CaptureResponse(b.ApplicationId, res, d)
+	}
+	if x.Routes != nil && res != nil && res.Request != nil {
+		x.Routes.CaptureResponse(routeKey(res.Request), res, d)
+	}
+}
+
+// ServeHTTP serves the breakdown as JSON. The app_id and route query
+// parameters select a single application or route.
+func (x *Breakdown) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var y interface{} = x
+
+	query := req.URL.Query()
+	switch {
+	case query.Get("app_id") != "":
+		y = x.lookup(x.Apps, query.Get("app_id"))
+	case query.Get("route") != "":
+		y = x.lookup(x.Routes, routeHost(query.Get("route")))
+	}
+
+	w.Header().Set("Connection", "close")
+	w.Header().Set("Content-Type", "application/json")
+	if y == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	enc.Encode(y)
+}
+
+func (x *Breakdown) lookup(y *LRUHttpMetric, t string) interface{} {
+	if y == nil {
+		return nil
+	}
+	if z := y.Get(t); z != nil {
+		return z
+	}
+	return nil
+}
+
+func routeKey(req *http.Request) string {
+	if uri := route.UriFromContext(req.Context()); uri != "" {
+		return string(uri)
+	}
+	return routeHost(req.Host)
+}
+
+func routeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,138 @@
+package varz_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/gorouter/config"
+	"code.cloudfoundry.org/gorouter/route"
+	. "code.cloudfoundry.org/gorouter/varz"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRUHttpMetric", func() {
+	var metric *LRUHttpMetric
+
+	BeforeEach(func() {
+		metric = NewLRUHttpMetric(2)
+	})
+
+	It("captures requests and responses by tag", func() {
+		metric.CaptureRequest("a")
+		metric.CaptureResponse("a", &http.Response{StatusCode: http.StatusNotFound}, time.Millisecond)
+
+		Expect(metric.Get("a").Requests.Count()).To(BeEquivalentTo(1))
+		Expect(metric.Get("a").Responses4xx.Count()).To(BeEquivalentTo(1))
+		Expect(metric.Get("b")).To(BeNil())
+	})
+
+	It("evicts the least recently used tag", func() {
+		metric.CaptureRequest("a")
+		metric.CaptureRequest("b")
+		metric.CaptureRequest("a")
+		metric.CaptureRequest("c")
+
+		Expect(metric.Len()).To(Equal(2))
+		Expect(metric.Get("a")).NotTo(BeNil())
+		Expect(metric.Get("b")).To(BeNil())
+		Expect(metric.Get("c")).NotTo(BeNil())
+	})
+
+	It("marshals the metrics by tag", func() {
+		metric.CaptureRequest("a")
+
+		b, err := json.Marshal(metric)
+		Expect(err).NotTo(HaveOccurred())
+
+		var y map[string]map[string]interface{}
+		Expect(json.Unmarshal(b, &y)).To(Succeed())
+		Expect(y["a"]["requests"]).To(Equal(float64(1)))
+	})
+})
+
+var _ = Describe("Breakdown", func() {
+	var (
+		breakdown *Breakdown
+		endpoint  *route.Endpoint
+		req       *http.Request
+	)
+
+	get := func(url string) (int, map[string]interface{}) {
+		recorder := httptest.NewRecorder()
+		breakdown.ServeHTTP(recorder, httptest.NewRequest("GET", url, nil))
+
+		var y map[string]interface{}
+		if recorder.Code == http.StatusOK {
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &y)).To(Succeed())
+		}
+		return recorder.Code, y
+	}
+
+	BeforeEach(func() {
+		breakdown = NewBreakdown(config.VarzBreakdownConfig{ByApp: true, ByRoute: true, MaxEntries: 10})
+		endpoint = &route.Endpoint{ApplicationId: "app-guid"}
+		req, _ = http.NewRequest("GET", "http://example.com/", nil)
+
+		breakdown.CaptureRoutingRequest(endpoint, req)
+		breakdown.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: http.StatusOK, Request: req}, time.Millisecond)
+	})
+
+	It("only tracks the configured breakdowns", func() {
+		breakdown = NewBreakdown(config.VarzBreakdownConfig{ByApp: true, MaxEntries: 10})
+		breakdown.CaptureRoutingRequest(endpoint, req)
+
+		Expect(breakdown.Apps.Len()).To(Equal(1))
+		Expect(breakdown.Routes).To(BeNil())
+	})
+
+	It("does not capture the route of responses that were not received", func() {
+		breakdown.CaptureRoutingResponse(endpoint, nil, time.Millisecond)
+
+		Expect(breakdown.Apps.Get("app-guid").ResponsesXxx.Count()).To(BeEquivalentTo(1))
+		Expect(breakdown.Routes.Get("example.com").ResponsesXxx.Count()).To(BeEquivalentTo(0))
+	})
+
+	It("serves the breakdown", func() {
+		code, y := get("/varz/breakdown")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(y).To(HaveKey("apps"))
+		Expect(y).To(HaveKey("routes"))
+	})
+
+	It("serves a single application", func() {
+		code, y := get("/varz/breakdown?app_id=app-guid")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(y["requests"]).To(Equal(float64(1)))
+		Expect(y["responses_2xx"]).To(Equal(float64(1)))
+	})
+
+	It("serves a single route", func() {
+		code, y := get("/varz/breakdown?route=EXAMPLE.com:80")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(y["requests"]).To(Equal(float64(1)))
+	})
+
+	It("tracks requests by the route they were routed to", func() {
+		routed := req.WithContext(route.NewUriContext(req.Context(), "example.com/api"))
+		breakdown.CaptureRoutingRequest(endpoint, routed)
+		breakdown.CaptureRoutingResponse(endpoint, &http.Response{StatusCode: http.StatusOK, Request: routed}, time.Millisecond)
+
+		Expect(breakdown.Routes.Get("example.com/api").Requests.Count()).To(BeEquivalentTo(1))
+		Expect(breakdown.Routes.Get("example.com/api").Responses2xx.Count()).To(BeEquivalentTo(1))
+		Expect(breakdown.Routes.Get("example.com").Requests.Count()).To(BeEquivalentTo(1))
+
+		code, y := get("/varz/breakdown?route=example.com/api")
+		Expect(code).To(Equal(http.StatusOK))
+		Expect(y["requests"]).To(Equal(float64(1)))
+	})
+
+	It("returns 404 for untracked applications", func() {
+		code, _ := get("/varz/breakdown?app_id=unknown")
+		Expect(code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -28,12 +28,15 @@ type varz struct {
 	Urls     int `json:"urls"`
 	Droplets int `json:"droplets"`
 
-	BadRequests    int     `json:"bad_requests"`
-	BadGateways    int     `json:"bad_gateways"`
-	RequestsPerSec float64 `json:"requests_per_sec"`
+	BadRequests       int     `json:"bad_requests"`
+	BadGateways       int     `json:"bad_gateways"`
+	ForbiddenRequests int     `json:"forbidden_requests"`
+	RequestsPerSec    float64 `json:"requests_per_sec"`
 
 	TopApps []topAppsEntry `json:"top10_app_requests"`
 
+	Breakdown *Breakdown `json:"breakdown,omitempty"`
+
 	MillisSinceLastRegistryUpdate int64 `json:"ms_since_last_registry_update"`
 }
 
@@ -164,8 +167,11 @@ type Varz interface {
 
 	CaptureBadRequest(req *http.Request)
 	CaptureBadGateway(req *http.Request)
+	CaptureForbidden(req *http.Request)
 	CaptureRoutingRequest(b *route.Endpoint, req *http.Request)
 	CaptureRoutingResponse(b *route.Endpoint, res *http.Response, startedAt time.Time, d time.Duration)
+	CaptureUpstreamConnectTime(d time.Duration)
+	CaptureUpstreamAttemptFailed()
 }
 
 type RealVarz struct {
@@ -177,7 +183,14 @@ type RealVarz struct {
 }
 
 func NewVarz(r *registry.RouteRegistry) Varz {
+	return NewVarzWithBreakdown(r, nil)
+}
+
+// NewVarzWithBreakdown creates a Varz that also captures requests in the
+// per application and per route breakdown, if not nil.
+func NewVarzWithBreakdown(r *registry.RouteRegistry, b *Breakdown) Varz {
 	x := &RealVarz{r: r}
+	x.Breakdown = b
 
 	x.activeApps = stats.NewActiveApps()
 	x.topApps = stats.NewTopApps()
@@ -239,6 +252,12 @@ func (x *RealVarz) CaptureBadGateway(*http.Request) {
 	x.Unlock()
 }
 
+func (x *RealVarz) CaptureForbidden(*http.Request) {
+	x.Lock()
+	x.ForbiddenRequests++
+	x.Unlock()
+}
+
 func (x *RealVarz) CaptureAppStats(b *route.Endpoint, t time.Time) {
 	if b.ApplicationId != "" {
 		x.activeApps.Mark(b.ApplicationId, t)
@@ -260,6 +279,10 @@ func (x *RealVarz) CaptureRoutingRequest(b *route.Endpoint, req *http.Request) {
 	x.varz.All.CaptureRequest()
 
 	x.Unlock()
+
+	if x.Breakdown != nil {
+		x.Breakdown.CaptureRoutingRequest(b, req)
+	}
 }
 
 func (x *RealVarz) CaptureRoutingResponse(endpoint *route.Endpoint, response *http.Response, startedAt time.Time, duration time.Duration) {
@@ -277,8 +300,18 @@ func (x *RealVarz) CaptureRoutingResponse(endpoint *route.Endpoint, response *ht
 	x.varz.All.CaptureResponse(response, duration)
 
 	x.Unlock()
+
+	if x.Breakdown != nil {
+		x.Breakdown.CaptureRoutingResponse(endpoint, response, duration)
+	}
 }
 
+// Upstream attempts are reported by the metrics reporters and are not part
+// of varz.
+func (x *RealVarz) CaptureUpstreamConnectTime(d time.Duration) {}
+
+func (x *RealVarz) CaptureUpstreamAttemptFailed() {}
+
 func transform(x interface{}, y map[string]interface{}) error {
 	var b []byte
 	var err error
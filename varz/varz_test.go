@@ -46,6 +46,7 @@ var _ = Describe("Varz", func() {
 			"requests",
 			"bad_requests",
 			"bad_gateways",
+			"forbidden_requests",
 			"requests_per_sec",
 			"top10_app_requests",
 			"ms_since_last_registry_update",
@@ -106,6 +107,13 @@ var _ = Describe("Varz", func() {
 		Expect(findValue(Varz, "bad_gateways")).To(Equal(float64(2)))
 	})
 
+	It("updates forbidden requests", func() {
+		r := &http.Request{}
+
+		Varz.CaptureForbidden(r)
+		Expect(findValue(Varz, "forbidden_requests")).To(Equal(float64(1)))
+	})
+
 	It("updates requests", func() {
 		b := &route.Endpoint{}
 		r := http.Request{}
@@ -209,6 +217,33 @@ var _ = Describe("Varz", func() {
 		Expect(findValue(Varz, "latency", "95").(float64)).To(Equal(float64(duration) / float64(time.Second)))
 		Expect(findValue(Varz, "latency", "99").(float64)).To(Equal(float64(duration) / float64(time.Second)))
 	})
+
+	It("does not include a breakdown by default", func() {
+		b, err := json.Marshal(Varz)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).NotTo(ContainSubstring("breakdown"))
+	})
+
+	Context("with a breakdown", func() {
+		BeforeEach(func() {
+			breakdown := NewBreakdown(config.VarzBreakdownConfig{ByApp: true, ByRoute: true, MaxEntries: 10})
+			Varz = NewVarzWithBreakdown(Registry, breakdown)
+		})
+
+		It("updates the breakdown by application and route", func() {
+			b := &route.Endpoint{ApplicationId: "app-guid"}
+			req, _ := http.NewRequest("GET", "http://Example.com:8080/", nil)
+			res := &http.Response{StatusCode: http.StatusBadGateway, Request: req}
+
+			Varz.CaptureRoutingRequest(b, req)
+			Varz.CaptureRoutingResponse(b, res, time.Now(), time.Millisecond)
+
+			Expect(findValue(Varz, "breakdown", "apps", "app-guid", "requests")).To(Equal(float64(1)))
+			Expect(findValue(Varz, "breakdown", "apps", "app-guid", "responses_5xx")).To(Equal(float64(1)))
+			Expect(findValue(Varz, "breakdown", "routes", "example.com", "requests")).To(Equal(float64(1)))
+			Expect(findValue(Varz, "breakdown", "routes", "example.com", "responses_5xx")).To(Equal(float64(1)))
+		})
+	})
 })
 
 // Extract value using key(s) from JSON data